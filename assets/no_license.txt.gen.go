@@ -1,7 +0,0 @@
-// AUTOMATICALLY GENERATED FILE. DO NOT EDIT.
-
-package assets
-
-var no_license = txt(asset{Name: "no_license.txt", Content: "" +
-	"---\ntitle: No License\nsource: \"http://choosealicense.com/no-license/\"\n\ndescription: You retain all rights and do not permit distribution, reproduction, or derivative works. You may grant some rights in cases where you publish your source code to a site that requires accepting terms of service. For example, publishing code in a public repository on GitHub requires that you allow others to view and fork your code.\n\nhow: Simply do nothing, though including a copyright notice is recommended.\n\nnote: This option may be subject to the Terms Of Use of the site where you publish your source code.\n\nrequired:\n  - include-copyright\n\npermitted:\n  - commercial-use\n  - private-use\n\nforbidden:\n  - modifications\n  - distribution\n  - sublicense\n\n---\n\nCopyright [year] [fullname]\n" +
-	"", etag: `"Rfz7rnQm9Y0="`})
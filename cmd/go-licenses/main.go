@@ -0,0 +1,13 @@
+// Command go-licenses detects the licenses of Go module dependencies and
+// operating system packages.
+package main
+
+import (
+	"os"
+
+	"github.com/groove-x/go-licenses/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Main(os.Args[1:]))
+}
@@ -1,3 +1,5 @@
 module github.com/groove-x/go-licenses
 
-go 1.12
+go 1.24
+
+require github.com/google/licensecheck v0.3.1
@@ -0,0 +1,176 @@
+// Package apk lists the licenses of installed Alpine Linux packages.
+package apk
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/internal/rootfs"
+)
+
+const (
+	installedPath = "/lib/apk/db/installed"
+	licensesDir   = "/usr/share/licenses"
+)
+
+// Package is an installed Alpine package, as recorded in apk database.
+type Package struct {
+	Name         string
+	Version      string
+	Architecture string
+	// Origin is the name of the source package.
+	Origin string
+	// License is the declared license expression.
+	License string
+}
+
+// ReadInstalled parses an apk installed database and returns its packages,
+// sorted by name.
+func ReadInstalled(r io.Reader) ([]Package, error) {
+	pkgs := []Package{}
+	pkg := Package{}
+	flush := func() {
+		if pkg.Name != "" {
+			if pkg.Origin == "" {
+				pkg.Origin = pkg.Name
+			}
+			pkgs = append(pkgs, pkg)
+		}
+		pkg = Package{}
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		value := line[2:]
+		switch line[0] {
+		case 'P':
+			pkg.Name = value
+		case 'V':
+			pkg.Version = value
+		case 'A':
+			pkg.Architecture = value
+		case 'o':
+			pkg.Origin = value
+		case 'L':
+			pkg.License = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name
+	})
+	return pkgs, nil
+}
+
+func listPackages(root string) ([]Package, error) {
+	path, err := rootfs.Resolve(root, installedPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadInstalled(f)
+}
+
+// matchLicenseFiles matches the files of the package licenses directory, if
+// any, and returns the best match along with its file path.
+func matchLicenseFiles(root string, pkg Package,
+	templates []*matcher.Template) (matcher.MatchResult, string, error) {
+
+	best := matcher.MatchResult{}
+	bestPath := ""
+	for _, name := range []string{pkg.Name, pkg.Origin} {
+		dir, err := rootfs.Resolve(root, filepath.Join(licensesDir, name))
+		if err != nil {
+			return best, "", err
+		}
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fi := range fis {
+			if !fi.Mode().IsRegular() {
+				continue
+			}
+			path := filepath.Join(dir, fi.Name())
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return best, "", err
+			}
+			m := matcher.Match(data, templates)
+			if bestPath == "" || m.Score > best.Score {
+				best = m
+				bestPath = path
+			}
+		}
+		if bestPath != "" {
+			break
+		}
+	}
+	return best, bestPath, nil
+}
+
+// ListLicenses returns the licenses of Alpine packages installed in the
+// filesystem rooted at root, an empty root designating the running system.
+// Declared licenses naming a known template are trusted. Otherwise, if
+// matchFiles is set, the package license files are matched against
+// templates.
+func ListLicenses(root string, matchFiles bool) ([]report.License, error) {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := listPackages(root)
+	if err != nil {
+		return nil, err
+	}
+	licenses := []report.License{}
+	for _, pkg := range pkgs {
+		license := report.License{
+			Source:   "apk",
+			Package:  pkg.Name,
+			Version:  pkg.Version,
+			Declared: pkg.License,
+		}
+		if t := matcher.FindTemplate(templates, pkg.License); t != nil {
+			license.Template = t
+			license.Score = 1
+		} else if matchFiles {
+			m, path, err := matchLicenseFiles(root, pkg, templates)
+			if err != nil {
+				return nil, err
+			}
+			if path != "" {
+				license.Path = path
+				license.Score = m.Score
+				license.Template = m.Template
+				license.ExtraWords = m.ExtraWords
+				license.MissingWords = m.MissingWords
+				license.PatentGrant = m.Clauses.PatentGrant
+				license.TrademarkClause = m.Clauses.Trademark
+			}
+		}
+		licenses = append(licenses, license)
+	}
+	return licenses, nil
+}
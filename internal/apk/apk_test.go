@@ -0,0 +1,36 @@
+package apk
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadInstalled(t *testing.T) {
+	installed := `C:Q1x2=
+P:musl
+V:1.2.5-r0
+A:x86_64
+L:MIT
+o:musl
+F:lib
+R:ld-musl-x86_64.so.1
+
+P:busybox-binsh
+V:1.36.1-r29
+A:x86_64
+L:GPL-2.0-only
+o:busybox
+`
+	pkgs, err := ReadInstalled(strings.NewReader(installed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprintf("%+v", pkgs)
+	wanted := "[{Name:busybox-binsh Version:1.36.1-r29 Architecture:x86_64 " +
+		"Origin:busybox License:GPL-2.0-only} {Name:musl Version:1.2.5-r0 " +
+		"Architecture:x86_64 Origin:musl License:MIT}]"
+	if got != wanted {
+		t.Fatalf("unexpected packages:\n%s\n!=\n%s", got, wanted)
+	}
+}
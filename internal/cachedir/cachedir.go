@@ -0,0 +1,24 @@
+// Package cachedir locates the directories caching downloaded data, like
+// license files fetched from module proxies or remote API responses.
+package cachedir
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Env is the environment variable setting the cache directory. It defaults
+// to a go-licenses directory in the user cache directory.
+const Env = "GOLICENSES_CACHE_DIR"
+
+// Path returns the cache directory named name.
+func Path(name string) (string, error) {
+	if dir := os.Getenv(Env); dir != "" {
+		return filepath.Join(dir, name), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-licenses", name), nil
+}
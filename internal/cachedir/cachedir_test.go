@@ -0,0 +1,20 @@
+package cachedir
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	t.Setenv(Env, filepath.Join("tmp", "cache"))
+	dir, err := Path("proxy")
+	if err != nil || dir != filepath.Join("tmp", "cache", "proxy") {
+		t.Fatalf("unexpected cache directory: %q, %v", dir, err)
+	}
+	t.Setenv(Env, "")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join("/", "xdg"))
+	dir, err = Path("proxy")
+	if err == nil && filepath.Base(filepath.Dir(dir)) != "go-licenses" {
+		t.Fatalf("unexpected default cache directory: %q", dir)
+	}
+}
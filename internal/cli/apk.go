@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/groove-x/go-licenses/internal/apk"
+)
+
+var apkCommand = &command{
+	Name:    "apk",
+	Summary: "list the licenses of installed Alpine packages",
+	Help: `
+Lists the Alpine packages installed according to apk database along with the
+license they declare. Declared licenses naming a known template are trusted.
+
+With -files, the license files installed in /usr/share/licenses are matched
+against a set of well-known licenses for the other packages.
+
+With -root, the packages installed in the filesystem tree at specified path are
+listed instead, like an unpacked container image or a chroot.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addOutputFlags(fs, "table")
+		root := fs.String("root", "", "root directory of the filesystem to scan")
+		files := fs.Bool("files", false, "match installed license files")
+		return func(args []string) error {
+			licenses, err := apk.ListLicenses(*root, *files)
+			if err != nil {
+				return err
+			}
+			return o.writeReport(licenses)
+		}
+	},
+}
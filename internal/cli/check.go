@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var checkCommand = &command{
+	Name:    "check",
+	Args:    "IMPORTPATH...",
+	Summary: "check the licenses of Go dependencies against a policy",
+	Help: `
+Checks the licenses of the dependencies of specified packages against the
+policy of the configuration file and fails if any violates it. Licenses are
+designated by SPDX identifier, title or nickname:
+
+  {"policy": {"allow": ["MIT", "BSD-3-Clause", "Apache-2.0"],
+              "deny": ["AGPL-3.0"]}}
+
+Denied licenses are violations. If an allow list is set, licenses missing from
+it are violations too. Licenses which cannot be detected with enough confidence
+always are.
+
+Licenses can be required to be approved by the Open Source Initiative, or free
+according to the Free Software Foundation, as recorded in the SPDX license
+list:
+
+  {"policy": {"require": ["osi-approved"]}}
+
+Supported requirements are "osi-approved" and "fsf-libre". JSON reports flag
+licenses with "osi_approved" and "fsf_libre" fields. Licenses can also be
+required to permit "commercial-use" or "modifications", to catch the
+non-commercial and no-derivatives Creative Commons licenses of embedded data
+files, whose template category is flagged "(non-commercial)" or
+"(no-derivatives)".
+
+Patent and trademark clauses are detected in license files, whatever license
+they match, and flagged with "patent_grant" and "trademark_clause" fields in
+JSON reports. Licenses can be required to grant patent licenses explicitly,
+like Apache-2.0, MPL-2.0 or BSD licenses completed with a PATENTS file, with
+"patent-grant", and to hold no clause withholding trademark rights with
+"no-trademark-clause".
+
+Known violations can be suppressed until a given date with -waivers, which
+reads a JSON array of waivers:
+
+  [{"package": "github.com/foo/bar", "license": "GPL-3.0",
+    "reason": "being replaced", "expires": "2024-06-30"}]
+
+A waiver without license covers all the violations of its package. Expired
+waivers make the check fail, even if the violations they covered are gone.
+Waivers can also be listed in the "waivers" array of the configuration file.
+
+The command exits with status 3 if there are violations or expired waivers,
+and 1 if the check could not be run, like when a module cannot be scanned.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		o.addCheckFlags(fs)
+		return func(args []string) error {
+			return runCheck(args, o)
+		}
+	},
+}
+
+func runCheck(pkgs []string, o *options) error {
+	cfg, _, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	licenses, err := listGoLicenses(pkgs, o)
+	if err != nil {
+		return err
+	}
+	return checkPolicy(o, cfg, licenses)
+}
+
+// checkPolicy prints the licenses violating the configuration policy and not
+// waived, and the expired waivers. It fails with a violationsError if there
+// are any.
+func checkPolicy(o *options, cfg *config.Config, licenses []report.License) error {
+	if cfg.Policy.Empty() {
+		return fmt.Errorf("%s defines no policy", o.configPath)
+	}
+	waivers := append([]policy.Waiver{}, cfg.Waivers...)
+	if o.waiversPath != "" {
+		fileWaivers, err := policy.ReadWaivers(o.waiversPath)
+		if err != nil {
+			return err
+		}
+		waivers = append(waivers, fileWaivers...)
+	}
+	violations := cfg.Policy.Check(licenses, o.confidence)
+	violations, expired := policy.Waive(violations, waivers, time.Now())
+	err := writeViolations(os.Stdout, violations, expired)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 || len(expired) > 0 {
+		return violationsError(fmt.Sprintf(
+			"%d license policy violation(s), %d expired waiver(s)",
+			len(violations), len(expired)))
+	}
+	return nil
+}
+
+func writeViolations(out io.Writer, violations []policy.Violation,
+	expired []policy.Waiver) error {
+
+	w := tabwriter.NewWriter(out, 1, 4, 2, ' ', 0)
+	for _, v := range violations {
+		_, err := fmt.Fprintf(w, "%s\t%s\n", v.License.Package, v.Reason)
+		if err != nil {
+			return err
+		}
+	}
+	for _, waiver := range expired {
+		_, err := fmt.Fprintf(w, "%s\twaiver expired on %s\n", waiver.Package,
+			waiver.Expires)
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
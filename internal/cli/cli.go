@@ -0,0 +1,522 @@
+// Package cli implements the go-licenses command and its subcommands.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/detect"
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// command is a go-licenses subcommand.
+type command struct {
+	Name string
+	// Args describes the positional arguments in usage line.
+	Args string
+	// Summary is displayed in the command list.
+	Summary string
+	// Help is displayed by the command usage, before its flags.
+	Help string
+	// Setup registers the command flags and returns the function running
+	// the command with positional arguments, once flags are parsed.
+	Setup func(fs *flag.FlagSet, o *options) func(args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		goCommand,
+		debCommand,
+		apkCommand,
+		rpmCommand,
+		imageCommand,
+		checkCommand,
+		explainCommand,
+		whyCommand,
+		searchCommand,
+		watchCommand,
+		saveCommand,
+		reportCommand,
+		mergeCommand,
+		diffCommand,
+		commentCommand,
+		lockCommand,
+		verifyCommand,
+		historyCommand,
+		fromGoModCommand,
+		serveCommand,
+		generateGoCommand,
+		validateTemplatesCommand,
+		reuseLintCommand,
+	}
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// options holds the flags shared by subcommands. Each subcommand registers
+// the ones it needs.
+type options struct {
+	configPath   string
+	profileName  string
+	strict       bool
+	goflags      string
+	offline      bool
+	download     bool
+	proxy        bool
+	goproxy      string
+	downloadJobs int
+	retries      int
+	fetchRemote  bool
+	maxSize      int64
+	algorithm    string
+	targetsFile  string
+	// targetList holds the import paths of targetsFile, once read.
+	targetList  []string
+	stateFile   string
+	remoteCache string
+	includeSelf bool
+	includeStd  bool
+	includeTool bool
+	embedded    bool
+	native      string
+	deprecation bool
+	crosscheck  string
+	verbose     bool
+	progress    bool
+	timeout     time.Duration
+	stepTimeout time.Duration
+	partial     bool
+	// imported restricts scans to the modules imported by their packages.
+	imported     bool
+	confidence   float64
+	format       string
+	words        bool
+	versions     bool
+	waiversPath  string
+	template     string
+	tmplModel    string
+	output       string
+	signKey      string
+	attest       bool
+	reproducible bool
+	abs          bool
+	append       bool
+	groupBy      string
+	color        colorMode
+	only         string
+	exclude      string
+	minScore     float64
+	// previous holds the licenses of the last scan, by module path and
+	// version, if set. They are reused like the -state ones, and replaced by
+	// the licenses of each scan, before crosschecks and overrides.
+	previous map[string]report.License
+	// policy is set by loadConfig.
+	policy *policy.Policy
+	// observer is notified of scanned Go modules, if set.
+	observer report.Observer
+}
+
+func (o *options) addConfigFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", config.DefaultPath, "configuration file")
+	fs.Float64Var(&o.confidence, "confidence", report.DefaultConfidence,
+		"minimum score of trusted license matches")
+	addTemplatesFlag(fs)
+}
+
+// addTemplatesFlag registers the flag replacing the embedded license
+// templates.
+func addTemplatesFlag(fs *flag.FlagSet) {
+	fs.StringVar(&matcher.TemplatesDir, "templates", matcher.TemplatesDir,
+		"match licenses against the .txt templates of this directory instead of the "+
+			"embedded ones, defaults to GOLICENSES_TEMPLATES")
+}
+
+// addScanFlags registers the flags of commands scanning Go modules.
+func (o *options) addScanFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.profileName, "profile", "", "scan with named build profile")
+	fs.BoolVar(&o.strict, "strict", false, "fail on the first module error")
+	fs.StringVar(&o.goflags, "goflags", "",
+		"flags passed to go commands, in addition to GOFLAGS")
+	fs.BoolVar(&o.offline, "offline", false, "never download modules")
+	fs.BoolVar(&o.download, "download", false,
+		"download modules missing from the module cache")
+	fs.BoolVar(&o.proxy, "proxy", false,
+		"fetch license files of modules missing from the cache from GOPROXY")
+	fs.StringVar(&o.goproxy, "goproxy", "",
+		"module proxy chain replacing GOPROXY, like a corporate proxy, authenticated "+
+			"with GOLICENSES_GOPROXY_TOKEN")
+	fs.IntVar(&o.downloadJobs, "download-jobs", gomod.DefaultDownloadJobs,
+		"number of modules downloaded or fetched from proxies at once")
+	fs.IntVar(&o.retries, "retries", gomod.DefaultRetries,
+		"retry failed downloads this many times with exponential backoff, 0 to disable")
+	fs.BoolVar(&o.fetchRemote, "fetch-remote", false,
+		"fetch license texts referred to by URL in license files")
+	fs.Int64Var(&o.maxSize, "max-license-size", gomod.DefaultMaxLicenseSize,
+		"skip license files larger than this many bytes")
+	fs.StringVar(&o.algorithm, "algorithm", detect.WordsName,
+		"license matching algorithm: words, or licensecheck when built with "+
+			"-tags licensecheck")
+	fs.StringVar(&o.targetsFile, "targets-file", "",
+		"read import paths to scan from file, or standard input with -")
+	o.addRemoteCacheFlag(fs)
+	fs.StringVar(&o.stateFile, "state", "",
+		"reuse the licenses of unchanged modules recorded in JSON file, and update it")
+	fs.BoolVar(&o.includeSelf, "include-self", false,
+		"report the scanned module license too")
+	fs.BoolVar(&o.includeStd, "include-std", false,
+		"report the Go standard library license too")
+	fs.BoolVar(&o.includeTool, "include-tools", false,
+		"report modules only needed by go.mod tool directives too")
+	fs.BoolVar(&o.embedded, "embedded", false,
+		"report the licenses of files embedded with go:embed directives too")
+	fs.StringVar(&o.native, "native", "",
+		"report system libraries linked by cgo packages: names, or deb or rpm "+
+			"to look up their licenses")
+	fs.BoolVar(&o.deprecation, "deprecations", false,
+		"report module deprecation and retraction notices")
+	fs.StringVar(&o.crosscheck, "crosscheck", "",
+		"complete licenses with data from: clearlydefined, github")
+	fs.BoolVar(&o.verbose, "v", false, "log scan steps and their duration to standard error")
+	fs.BoolVar(&o.progress, "progress", false,
+		"display the module being scanned on standard error")
+	fs.DurationVar(&o.timeout, "timeout", 0, "abort the scan after this duration")
+	fs.DurationVar(&o.stepTimeout, "step-timeout", 0,
+		"abort the scan if a go command or proxy request lasts longer")
+}
+
+func (o *options) addRemoteCacheFlag(fs *flag.FlagSet) {
+	fs.StringVar(&o.remoteCache, "remote-cache", os.Getenv("GOLICENSES_REMOTE_CACHE"),
+		"share module licenses with other scans through cache at URL, "+
+			"an HTTP server or an s3:// or gs:// bucket")
+}
+
+// remote returns the remote cache set by -remote-cache, authenticated with
+// the GOLICENSES_REMOTE_CACHE_TOKEN environment variable, or nil.
+func (o *options) remote() (*gomod.RemoteCache, error) {
+	if o.remoteCache == "" || o.offline {
+		return nil, nil
+	}
+	return gomod.NewRemoteCache(o.remoteCache, os.Getenv("GOLICENSES_REMOTE_CACHE_TOKEN"))
+}
+
+// scanContext returns the context of scans, canceled on interrupt or after
+// -timeout.
+func (o *options) scanContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if o.timeout == 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+func (o *options) addCheckFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.waiversPath, "waivers", "", "file of waived policy violations")
+}
+
+func (o *options) addOutputFlags(fs *flag.FlagSet, format string) {
+	fs.StringVar(&o.format, "format", format, "output format: "+
+		strings.Join(report.Formats, ", "))
+	fs.BoolVar(&o.words, "w", false, "display words not matching license template")
+	fs.StringVar(&o.template, "template", "",
+		"render output with text/template file instead of -format")
+	fs.StringVar(&o.tmplModel, "template-model", "", "data model of -template: "+
+		strings.Join(report.TemplateModels, ", ")+", packages by default")
+	fs.StringVar(&o.output, "o", "", "write output to file instead of standard output")
+	fs.BoolVar(&o.append, "append", false, "append output to -o file")
+	fs.StringVar(&o.signKey, "sign", "",
+		"sign -o file with PEM private key file, writing a detached .sig file")
+	fs.BoolVar(&o.attest, "attest", false,
+		"with -sign, also write an in-toto attestation .intoto.json file")
+	fs.BoolVar(&o.reproducible, "reproducible", false,
+		"omit timestamps and absolute paths so output only changes with licenses")
+	fs.BoolVar(&o.abs, "abs", false,
+		"write absolute license file paths instead of module cache relative ones")
+	fs.StringVar(&o.groupBy, "group-by", "", "group packages by: "+
+		strings.Join(report.GroupByValues, ", "))
+	fs.StringVar(&o.only, "only", "",
+		"report only licenses matching comma separated patterns, or unknown")
+	fs.StringVar(&o.exclude, "exclude", "",
+		"leave out licenses matching comma separated patterns, or unknown")
+	fs.Float64Var(&o.minScore, "min-score", 0, "leave out licenses scoring below")
+	o.color = "auto"
+	fs.Var(&o.color, "color", "table coloring `mode`: "+strings.Join(colorModes, ", "))
+}
+
+// pathRoots returns the directories license file paths are written relative
+// to and resolved from: the module cache, then the current directory holding
+// main modules and vendored packages.
+func pathRoots() []string {
+	roots := []string{}
+	dir, err := gomod.ModuleCacheDir(context.Background())
+	if err == nil && dir != "" {
+		roots = append(roots, dir)
+	}
+	wd, err := os.Getwd()
+	if err == nil {
+		roots = append(roots, wd)
+	}
+	return roots
+}
+
+var colorModes = []string{"auto", "always", "never"}
+
+// colorMode is the value of the -color flag.
+type colorMode string
+
+func (c *colorMode) String() string {
+	return string(*c)
+}
+
+func (c *colorMode) Set(s string) error {
+	for _, m := range colorModes {
+		if s == m {
+			*c = colorMode(s)
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s", strings.Join(colorModes, ", "))
+}
+
+// splitList returns the non-empty elements of a comma separated list.
+func splitList(s string) []string {
+	values := []string{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// checkFlags returns an error if flags set together conflict, before
+// anything is scanned.
+func (o *options) checkFlags() error {
+	if o.signKey != "" && o.output == "" {
+		return fmt.Errorf("-sign requires an -o output file")
+	}
+	return nil
+}
+
+// useColor returns true if table output should be colored: always with
+// -color always, never with -color never, and with -color auto when writing
+// to a terminal and NO_COLOR is not set.
+func (o *options) useColor() bool {
+	switch o.color {
+	case "always":
+		return true
+	case "auto":
+		if o.output != "" || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false
+		}
+		return isTerminal(os.Stdout)
+	}
+	return false
+}
+
+func (o *options) reportOptions() report.Options {
+	opts := report.Options{
+		Confidence:    o.confidence,
+		Words:         o.words,
+		Versions:      o.versions,
+		Template:      o.template,
+		TemplateModel: o.tmplModel,
+		GroupBy:       o.groupBy,
+		Color:         o.useColor(),
+		Reproducible:  o.reproducible,
+		Filter: report.Filter{
+			Only:     splitList(o.only),
+			Exclude:  splitList(o.exclude),
+			MinScore: o.minScore,
+		},
+	}
+	if !o.abs {
+		opts.Roots = pathRoots()
+	}
+	if o.policy != nil {
+		p, confidence := o.policy, o.confidence
+		opts.Check = func(l report.License) string {
+			return p.Evaluate(l, confidence)
+		}
+	}
+	return opts
+}
+
+// loadConfig loads the configuration file and the selected profile, if any.
+func (o *options) loadConfig() (*config.Config, *config.Profile, error) {
+	cfg, err := config.Load(o.configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	o.policy = &cfg.Policy
+	var profile *config.Profile
+	if o.profileName != "" {
+		profile, err = cfg.Profile(o.profileName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return cfg, profile, nil
+}
+
+// envPrefix prefixes the environment variables setting flags.
+const envPrefix = "GOLICENSES_"
+
+// envHelp documents the environment variables setting flags.
+const envHelp = `Flags can be set with environment variables named after them, like
+GOLICENSES_CONFIDENCE=0.8 for -confidence or GOLICENSES_REMOTE_CACHE for
+-remote-cache. Command line flags take precedence. GOLICENSES_CACHE_DIR sets
+the directory caching downloaded data, a go-licenses directory in the user
+cache directory by default.`
+
+// flagEnv returns the name of the environment variable setting flag name.
+func flagEnv(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// setFlagsFromEnv sets the flags of fs defined by environment variables, see
+// flagEnv, so container-based CI jobs can configure commands without
+// wrapper scripts.
+func setFlagsFromEnv(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(flagEnv(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s value %q: %s", flagEnv(f.Name), value, setErr)
+		}
+	})
+	return err
+}
+
+// Process exit codes.
+const (
+	// exitError reports a command failure.
+	exitError = 1
+	// exitUsage reports invalid command lines and flag values.
+	exitUsage = 2
+	// exitViolations reports licenses violating the policy, so CI jobs can
+	// tell them from tool failures.
+	exitViolations = 3
+)
+
+// violationsError is returned by commands finding policy violations.
+type violationsError string
+
+func (e violationsError) Error() string {
+	return string(e)
+}
+
+// exitCode returns the process exit code of commands failing with err.
+func exitCode(err error) int {
+	var violations violationsError
+	if errors.As(err, &violations) {
+		return exitViolations
+	}
+	return exitError
+}
+
+// RunCommand runs the named subcommand with supplied arguments and returns
+// the process exit code. prog is the command line prefix displayed in usage.
+func RunCommand(prog, name string, args []string) int {
+	c := findCommand(name)
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "error: unknown command %q\n", name)
+		return exitUsage
+	}
+	fs := flag.NewFlagSet(prog, flag.ExitOnError)
+	fs.Usage = func() {
+		line := strings.TrimSpace(prog + " [flags] " + c.Args)
+		fmt.Fprintf(os.Stderr, "Usage: %s\n\n%s\n\nFlags:\n", line,
+			strings.TrimSpace(c.Help))
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n%s\n", envHelp)
+	}
+	o := &options{}
+	run := c.Setup(fs, o)
+	err := setFlagsFromEnv(fs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitUsage
+	}
+	fs.Parse(args)
+	err = o.checkFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitUsage
+	}
+	err = run(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitCode(err)
+	}
+	return 0
+}
+
+func usage() {
+	lines := []string{
+		"Usage: go-licenses COMMAND [flags] [arguments]",
+		"",
+		"go-licenses detects the licenses of Go module dependencies and operating",
+		"system packages. Commands are:",
+		"",
+	}
+	width := 0
+	for _, c := range commands {
+		if len(c.Name) > width {
+			width = len(c.Name)
+		}
+	}
+	for _, c := range commands {
+		lines = append(lines, fmt.Sprintf("  %-*s %s", width, c.Name, c.Summary))
+	}
+	lines = append(lines, "",
+		`Run "go-licenses COMMAND -h" for the command documentation.`, "", envHelp)
+	fmt.Fprintln(os.Stderr, strings.Join(lines, "\n"))
+}
+
+// Main runs the go-licenses command with supplied arguments, excluding the
+// program name, and returns the process exit code.
+func Main(args []string) int {
+	if len(args) < 1 {
+		usage()
+		return exitUsage
+	}
+	name := args[0]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage()
+		return 0
+	}
+	if findCommand(name) == nil {
+		fmt.Fprintf(os.Stderr, "error: unknown command %q\n\n", name)
+		usage()
+		return exitUsage
+	}
+	return RunCommand("go-licenses "+name, name, args[1:])
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// runTestCommand runs the named command with args and returns its exit code
+// along with what it wrote to standard output and standard error.
+func runTestCommand(t *testing.T, name string, args ...string) (int, string, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+	savedStdout, savedStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	code := RunCommand("go-licenses "+name, name, args)
+	os.Stdout, os.Stderr = savedStdout, savedStderr
+	out, err := ioutil.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, err := ioutil.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, string(out), string(errOut)
+}
+
+// writeTestFiles writes files, by slash-separated path relative to dir.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"root/var/lib/dpkg/status": `Package: foo
+Status: install ok installed
+Version: 1.0
+`,
+		"root/usr/share/doc/foo/copyright": `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+
+Files: *
+Copyright: 2020 Foo
+License: Expat
+`,
+		"allow.json":  `{"policy": {"allow": ["MIT"]}}`,
+		"deny.json":   `{"policy": {"deny": ["MIT"]}}`,
+		"empty.json":  `{}`,
+		"broken.json": `{"policy":`,
+	})
+	root := filepath.Join(dir, "root")
+
+	tests := []struct {
+		args   []string
+		wanted int
+	}{
+		{[]string{"-check", "-config", filepath.Join(dir, "allow.json")}, 0},
+		{[]string{"-check", "-config", filepath.Join(dir, "deny.json")}, exitViolations},
+		{[]string{"-check", "-config", filepath.Join(dir, "empty.json")}, exitError},
+		{[]string{"-check", "-config", filepath.Join(dir, "broken.json")}, exitError},
+		{[]string{"-check", "-config", filepath.Join(dir, "allow.json"),
+			"-waivers", filepath.Join(dir, "missing.json")}, exitError},
+		{[]string{"-sign", filepath.Join(dir, "missing.pem")}, exitUsage},
+	}
+	for _, test := range tests {
+		code, out, _ := runTestCommand(t, "deb", append(test.args, "-root", root)...)
+		if code != test.wanted {
+			t.Errorf("deb %v exited with %d instead of %d:\n%s", test.args, code,
+				test.wanted, out)
+		}
+	}
+}
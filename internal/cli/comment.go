@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/comment"
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var commentCommand = &command{
+	Name:    "comment",
+	Args:    "[OLD] NEW",
+	Summary: "summarize a JSON report as a pull request comment",
+	Help: `
+Renders a compact markdown summary of a report written with -format json, to
+be posted as a pull request comment by CI bots. With an OLD report, like the
+one of the target branch, the packages added, removed or whose license
+changed are listed, like with the diff command.
+
+The summary also lists the packages of NEW violating the policy of the
+configuration file and not waived, and the waivers of the configuration file
+and of -waivers expiring within -expiring or already expired.
+
+Rows are left out once the comment would exceed -max-size bytes, which
+defaults to the GitHub comment size limit, and their number is written
+instead.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addCheckFlags(fs)
+		expiring := fs.Duration("expiring", 30*24*time.Hour,
+			"list waivers expiring within this duration")
+		maxSize := fs.Int("max-size", comment.DefaultMaxSize,
+			"maximum comment size in bytes")
+		return func(args []string) error {
+			if len(args) < 1 || len(args) > 2 {
+				return fmt.Errorf("expect [OLD] NEW report arguments")
+			}
+			return runComment(args, o, *expiring, *maxSize)
+		}
+	},
+}
+
+func runComment(paths []string, o *options, expiring time.Duration, maxSize int) error {
+	cfg, _, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	after, err := readReports(paths[len(paths)-1:])
+	if err != nil {
+		return err
+	}
+	s := &comment.Summary{Now: time.Now()}
+	if len(paths) == 2 {
+		before, err := readReports(paths[:1])
+		if err != nil {
+			return err
+		}
+		s.Diff = report.Compare(before, after, o.confidence)
+	}
+	waivers := append([]policy.Waiver{}, cfg.Waivers...)
+	if o.waiversPath != "" {
+		fileWaivers, err := policy.ReadWaivers(o.waiversPath)
+		if err != nil {
+			return err
+		}
+		waivers = append(waivers, fileWaivers...)
+	}
+	s.Violations, _ = policy.Waive(cfg.Policy.Check(after, o.confidence), waivers, s.Now)
+	for _, w := range waivers {
+		if w.Expired(s.Now.Add(expiring)) {
+			s.Waivers = append(s.Waivers, w)
+		}
+	}
+	return comment.Write(os.Stdout, s, o.confidence, maxSize)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/groove-x/go-licenses/internal/deb"
+)
+
+var debCommand = &command{
+	Name:    "deb",
+	Summary: "list the licenses of installed Debian packages",
+	Help: `
+Lists the Debian packages installed according to dpkg database and matches
+their copyright file against a set of well-known licenses. The best match is
+displayed along with its score. The copyright file is looked up in the binary
+package documentation directory, then in the source package one. Package
+versions are listed too, along with source package names when they differ.
+
+With -root, the packages installed in the filesystem tree at specified path are
+listed instead, like an unpacked container image or a chroot. Symbolic links
+are resolved within that tree.
+
+With -check, the licenses are checked against the policy of the configuration
+file instead of being listed, like the check command does for Go dependencies.
+Violations are printed and the command exits with status 3 if there are any,
+and 1 on other errors. Violations can be waived with -waivers like with the
+check command.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addOutputFlags(fs, "table")
+		o.addCheckFlags(fs)
+		root := fs.String("root", "", "root directory of the filesystem to scan")
+		check := fs.Bool("check", false, "check licenses against the policy")
+		fs.BoolVar(&o.versions, "versions", true, "display package versions in table format")
+		return func(args []string) error {
+			cfg, _, err := o.loadConfig()
+			if err != nil {
+				return err
+			}
+			licenses, err := deb.ListLicenses(*root)
+			if err != nil {
+				return err
+			}
+			if *check {
+				return checkPolicy(o, cfg, licenses)
+			}
+			return o.writeReport(licenses)
+		}
+	},
+}
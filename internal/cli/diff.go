@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var diffCommand = &command{
+	Name:    "diff",
+	Args:    "OLD NEW",
+	Summary: "compare two JSON reports",
+	Help: `
+Compares two reports written with -format json, like the ones of a dependency
+update before and after, and lists the packages which were added (+), removed
+(-) or whose license changed (~). Packages are identified by source and name,
+so upgrades keeping the same license are not listed.
+
+With -exit-code, the command fails if there are differences, so it can gate
+dependency update changes.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		exitCode := fs.Bool("exit-code", false, "fail if reports differ")
+		return func(args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("expect OLD and NEW report arguments")
+			}
+			before, err := readReports(args[:1])
+			if err != nil {
+				return err
+			}
+			after, err := readReports(args[1:])
+			if err != nil {
+				return err
+			}
+			d := report.Compare(before, after, o.confidence)
+			err = report.WriteDiff(os.Stdout, d, o.confidence)
+			if err != nil {
+				return err
+			}
+			if *exitCode && !d.Empty() {
+				return fmt.Errorf("reports differ")
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var explainCommand = &command{
+	Name:    "explain",
+	Args:    "MODULE [IMPORTPATH...]",
+	Summary: "explain how the license of a Go module was detected",
+	Help: `
+Explains how the license of a module among the dependencies of specified
+packages was detected, so partial matches can be reviewed: the license file,
+the template it was matched with, the score breakdown, the best scoring
+templates and a side-by-side diff of the license file and template words,
+once normalized. Lines marked with "|" differ, lines marked with "<" are only
+in the license file and lines marked with ">" only in the template. Optional
+template sections missing from the license file are listed as omitted.
+Frequent English words, like "the" or "of", are not counted in scores, and
+license words missing from short templates, like public domain dedications,
+weigh less.
+
+MODULE is a module path, optionally followed by "@" and a version. Without
+import paths, the dependencies of the current module are scanned.
+
+With -full, the whole texts are displayed instead of eliding unchanged parts.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		full := fs.Bool("full", false, "display unchanged parts of the diff")
+		return func(args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("expect a MODULE argument")
+			}
+			return runExplain(args[0], args[1:], o, *full)
+		}
+	},
+}
+
+func runExplain(module string, pkgs []string, o *options, full bool) error {
+	version := ""
+	if i := strings.Index(module, "@"); i >= 0 {
+		module, version = module[:i], module[i+1:]
+	}
+	licenses, err := listGoLicenses(pkgs, o)
+	if err != nil {
+		return err
+	}
+	var found *report.License
+	for i, l := range licenses {
+		if l.Package == module && (version == "" || l.Version == version) {
+			found = &licenses[i]
+			break
+		}
+	}
+	if found == nil {
+		return fmt.Errorf("%s is not a dependency", module)
+	}
+	if found.Path == "" {
+		if found.Err != "" {
+			return fmt.Errorf("%s has no license file: %s", module, found.Err)
+		}
+		return fmt.Errorf("%s has no license file", module)
+	}
+	text, err := ioutil.ReadFile(found.Path)
+	if err != nil {
+		return err
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	return report.WriteExplanation(os.Stdout, *found, text, templates,
+		o.confidence, full)
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var fromGoModCommand = &command{
+	Name:    "fromgomod",
+	Args:    "GOMOD",
+	Summary: "list the licenses of the build list of a go.mod file",
+	Help: `
+Lists the licenses of the modules of the build list of the go.mod file GOMOD,
+resolved from it and the go.sum file next to it alone, without loading or
+building packages. It audits third-party repositories or pull requests
+without checking out their sources: the go.mod and go.sum files are enough.
+Neither file is modified.
+
+The build list holds all the modules the go command considers, so modules only
+needed by tests of dependencies are listed too, unlike with the go command
+which only lists the modules of built packages. The go.mod files of modules
+missing from the module cache are fetched from GOPROXY, and so are their
+license files, unless -download is set to download whole modules or -offline
+to only use the module cache. Local directory replacements are relative to the
+directory of GOMOD.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		o.addOutputFlags(fs, "table")
+		return func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expect a GOMOD argument")
+			}
+			licenses, err := scanGoLicenses(o, func(ctx context.Context,
+				opts *gomod.Options) ([]report.License, error) {
+
+				mods, err := gomod.ReadBuildList(ctx, args[0], opts)
+				if err != nil {
+					return nil, err
+				}
+				opts.Proxy = opts.Proxy || !opts.Download
+				return gomod.ScanModules(ctx, mods, opts)
+			})
+			if err != nil {
+				return err
+			}
+			return o.writeReport(licenses)
+		}
+	},
+}
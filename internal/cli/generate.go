@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var generateGoCommand = &command{
+	Name:    "generate-go",
+	Args:    "IMPORTPATH...",
+	Summary: "generate a Go file embedding the licenses of Go dependencies",
+	Help: `
+Scans the dependencies of specified packages like the go command and writes a
+Go source file declaring a Licenses slice with the package, version, license
+name, SPDX identifier and license text of each dependency. Applications can
+display it in an "open source licenses" screen and regenerate it with
+go generate, for instance:
+
+  //go:generate go-licenses generate-go -package thirdparty -o thirdparty/licenses.go .`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		pkg := fs.String("package", "thirdparty", "package name of the generated file")
+		output := fs.String("o", "", "output file, instead of standard output")
+		return func(args []string) error {
+			licenses, err := listGoLicenses(args, o)
+			if err != nil {
+				return err
+			}
+			if *output == "" {
+				return report.WriteGo(os.Stdout, *pkg, licenses, o.confidence)
+			}
+			err = os.MkdirAll(filepath.Dir(*output), 0755)
+			if err != nil {
+				return err
+			}
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			err = report.WriteGo(f, *pkg, licenses, o.confidence)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}
+	},
+}
@@ -0,0 +1,719 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/crosscheck"
+	"github.com/groove-x/go-licenses/internal/deb"
+	"github.com/groove-x/go-licenses/internal/detect"
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/inventory"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/internal/review"
+	"github.com/groove-x/go-licenses/internal/rpm"
+)
+
+var goCommand = &command{
+	Name:    "go",
+	Args:    "[IMPORTPATH...]",
+	Summary: "list the licenses of Go dependencies",
+	Help: `
+Lists all dependencies of specified packages or commands, excluding standard
+library packages, and prints their licenses. The license files of each module,
+like LICENSE or COPYING, are matched against well-known licenses and the best
+match is displayed with its score.
+
+Without arguments, the packages of the current module are scanned, like with
+"./...". Projects without go.mod are scanned in GOPATH mode. Packages and
+modules are loaded with the go command, which must be in PATH.
+
+Overrides, metadata, detectors and build profiles are read from the
+configuration file. Output formats, downloads, caches and the other flags are
+detailed in the "Scanning Go dependencies" section of the README.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		o.addOutputFlags(fs, "table")
+		all := fs.Bool("a", false, "display all individual packages")
+		annotate := fs.Bool("annotate-groups", false,
+			"display all individual packages with their license group")
+		checklist := fs.String("checklist", "",
+			"print the obligations checklist of named release")
+		obligations := fs.Bool("obligations", false,
+			"print the obligations implied by detected licenses")
+		inventoryPath := fs.String("reconcile", "",
+			"reconcile dependencies with CSV inventory file")
+		perBinary := fs.Bool("per-binary", false,
+			"report the licenses of each main package separately")
+		quiet := fs.Bool("q", false, "print only policy violations and unknown licenses")
+		fs.BoolVar(&o.partial, "partial", false,
+			"report the licenses matched before an interrupted scan was aborted")
+		interactive := fs.Bool("interactive", false,
+			"review results from a command prompt and record overrides and waivers")
+		return func(args []string) error {
+			if *interactive {
+				return runInteractive(args, o)
+			}
+			if *quiet {
+				return runQuiet(args, o)
+			}
+			if *perBinary {
+				return runPerBinary(args, o, *all, *annotate)
+			}
+			return runGo(args, o, *all, *annotate, *obligations, *checklist,
+				*inventoryPath)
+		}
+	},
+}
+
+func runQuiet(pkgs []string, o *options) error {
+	licenses, err := listGoLicenses(pkgs, o)
+	if err != nil {
+		return err
+	}
+	opts := o.reportOptions()
+	err = opts.Filter.Validate()
+	if err != nil {
+		return err
+	}
+	return o.writeOutput(func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 1, 4, 2, ' ', 0)
+		for _, l := range opts.Filter.Apply(licenses, o.confidence) {
+			reason := opts.Check(l)
+			if reason == "" {
+				continue
+			}
+			_, err := fmt.Fprintf(tw, "%s\t%s\n", l.Package, reason)
+			if err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	})
+}
+
+func runInteractive(pkgs []string, o *options) error {
+	licenses, err := listGoLicenses(pkgs, o)
+	if err != nil {
+		return err
+	}
+	cfg, _, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	s := &review.Session{
+		Licenses:   licenses,
+		Templates:  templates,
+		Config:     cfg,
+		ConfigPath: o.configPath,
+		Confidence: o.confidence,
+	}
+	return s.Run(os.Stdin, os.Stdout)
+}
+
+// readTargets returns the import paths listed in the file at path, or
+// standard input if path is "-", one per line. Blank lines and lines starting
+// with "#" are ignored.
+func readTargets(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	targets := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read targets: %s", err)
+	}
+	return targets, nil
+}
+
+// detectors returns the detectors compiled in and the ones declared in cfg,
+// with the priorities set by cfg. The detector named by algorithm, unless it
+// is the word matcher, takes precedence over it.
+func detectors(cfg *config.Config, algorithm string) ([]detect.Entry, error) {
+	entries := detect.Registered()
+	for _, d := range cfg.Detectors {
+		if len(d.Command) > 0 {
+			e := detect.Entry{Name: d.Name, Detector: &detect.Command{Args: d.Command}}
+			if d.Priority != nil {
+				e.Priority = *d.Priority
+			}
+			entries = append(entries, e)
+			continue
+		}
+		found := false
+		for i, e := range entries {
+			if e.Name == d.Name {
+				if d.Priority != nil {
+					entries[i].Priority = *d.Priority
+				}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown detector %q, compiled in: %s", d.Name,
+				detectorNames(entries))
+		}
+	}
+	if algorithm != "" && algorithm != detect.WordsName {
+		found := false
+		for i, e := range entries {
+			if e.Name == algorithm {
+				if e.Priority <= 0 {
+					entries[i].Priority = 1
+				}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown algorithm %q, compiled in: %s", algorithm,
+				detectorNames(append([]detect.Entry{{Name: detect.WordsName}}, entries...)))
+		}
+	}
+	detect.Sort(entries)
+	return entries, nil
+}
+
+func detectorNames(entries []detect.Entry) string {
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// targets returns the packages passed as arguments followed by those listed
+// in the -targets-file file. The file is only read once, so standard input
+// can be rescanned. Go commands are passed packages in batches, so lists of
+// any length can be scanned.
+func (o *options) targets(pkgs []string) ([]string, error) {
+	if o.targetsFile == "" {
+		return pkgs, nil
+	}
+	if o.targetList == nil {
+		targets, err := readTargets(o.targetsFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("no import path in %s", o.targetsFile)
+		}
+		o.targetList = targets
+	}
+	return append(append([]string{}, pkgs...), o.targetList...), nil
+}
+
+// listGoLicenses scans the packages passed as arguments and those listed in
+// the -targets-file file.
+func listGoLicenses(pkgs []string, o *options) ([]report.License, error) {
+	pkgs, err := o.targets(pkgs)
+	if err != nil {
+		return nil, err
+	}
+	return scanPackages(pkgs, o)
+}
+
+// scanPackages scans pkgs, ignoring -targets-file.
+func scanPackages(pkgs []string, o *options) ([]report.License, error) {
+	return scanGoLicenses(o, func(ctx context.Context, opts *gomod.Options) (
+		[]report.License, error) {
+
+		return gomod.Scan(ctx, pkgs, opts)
+	})
+}
+
+// scanGoLicenses detects the licenses of Go modules with scan, called with
+// the scan options set by o, then records them in the -state file,
+// crosschecks them and applies configuration overrides.
+func scanGoLicenses(o *options, scan func(context.Context, *gomod.Options) (
+	[]report.License, error)) ([]report.License, error) {
+
+	switch o.native {
+	case "", "names", "deb", "rpm":
+	default:
+		return nil, fmt.Errorf("unknown -native value %q, expected: %s",
+			o.native, strings.Join(nativeValues, ", "))
+	}
+	cfg, profile, err := o.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := detectors(cfg, o.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	observer := o.observer
+	if observer != nil && (len(cfg.Overrides) > 0 || len(cfg.Metadata) > 0) {
+		observer = &overridingObserver{observer, cfg, templates}
+	}
+	if o.progress {
+		observer = newProgressObserver(observer)
+	}
+	previous, err := readState(o.stateFile)
+	if err != nil {
+		return nil, err
+	}
+	for k, l := range o.previous {
+		previous[k] = l
+	}
+	remote, err := o.remote()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := o.scanContext()
+	defer cancel()
+	licenses, err := scan(ctx, &gomod.Options{
+		Profile:        profile,
+		Imported:       o.imported,
+		Observer:       observer,
+		Strict:         o.strict,
+		Offline:        o.offline,
+		Download:       o.download,
+		Proxy:          o.proxy,
+		GOPROXY:        o.goproxy,
+		ProxyToken:     os.Getenv("GOLICENSES_GOPROXY_TOKEN"),
+		DownloadJobs:   o.downloadJobs,
+		Retries:        retries(o.retries),
+		FetchRemote:    o.fetchRemote,
+		MaxLicenseSize: o.maxSize,
+		Detectors:      entries,
+		GoFlags:        o.goflags,
+		IncludeSelf:    o.includeSelf,
+		IncludeStd:     o.includeStd,
+		IncludeTools:   o.includeTool,
+		Embedded:       o.embedded,
+		Native:         o.native != "",
+		Deprecations:   o.deprecation,
+		Graph:          o.format == "spdx",
+		Logger:         o.scanLogger(),
+		StepTimeout:    o.stepTimeout,
+		Previous:       previous,
+		Remote:         remote,
+	})
+	if err != nil {
+		if ctx.Err() == nil || !o.partial || len(licenses) == 0 {
+			return nil, err
+		}
+		// Report the licenses matched so far along with the error.
+		for i := range licenses {
+			cfg.ApplyOverride(&licenses[i], templates)
+			cfg.ApplyMetadata(&licenses[i])
+		}
+		return licenses, fmt.Errorf("scan aborted, %d modules reported: %s",
+			len(licenses), err)
+	}
+	if o.stateFile != "" {
+		err = writeState(o.stateFile, licenses)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.previous != nil {
+		o.previous = stateLicenses(licenses)
+	}
+	if o.crosscheck != "" {
+		err = crosscheckLicenses(licenses, o)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.native == "deb" || o.native == "rpm" {
+		err = lookupNativeLicenses(licenses, o.native)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range licenses {
+		cfg.ApplyOverride(&licenses[i], templates)
+		cfg.ApplyMetadata(&licenses[i])
+	}
+	for _, l := range licenses {
+		for _, warning := range report.Warnings(l, o.confidence) {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", l.Package, warning)
+		}
+	}
+	for _, warning := range report.MajorVersionWarnings(licenses, o.confidence) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return licenses, nil
+}
+
+// retries converts the -retries flag, where 0 disables retries, to the
+// Retries option, where 0 selects the default.
+func retries(n int) int {
+	if n == 0 {
+		return -1
+	}
+	return n
+}
+
+// readState returns the licenses of Go modules recorded in the -state JSON
+// report at path, by module path and version. A missing file records none.
+func readState(path string) (map[string]report.License, error) {
+	previous := map[string]report.License{}
+	if path == "" {
+		return previous, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return previous, nil
+	}
+	licenses, err := readReports([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	return stateLicenses(licenses), nil
+}
+
+// stateLicenses returns the licenses of Go modules among licenses, by module
+// path and version, as reused by later scans.
+func stateLicenses(licenses []report.License) map[string]report.License {
+	state := map[string]report.License{}
+	for _, l := range licenses {
+		if l.Source == "" || l.Source == "go" {
+			state[l.Package+"@"+l.Version] = l
+		}
+	}
+	return state
+}
+
+// writeState records licenses in the -state JSON report at path, before
+// crosschecks and overrides are applied.
+func writeState(path string, licenses []report.License) error {
+	b := &bytes.Buffer{}
+	err := report.WriteJSON(b, licenses)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
+
+// overridingObserver applies configuration overrides and metadata to the
+// licenses notified to Observer.
+type overridingObserver struct {
+	report.Observer
+	cfg       *config.Config
+	templates []*matcher.Template
+}
+
+func (o *overridingObserver) Scanned(l report.License) {
+	o.cfg.ApplyOverride(&l, o.templates)
+	o.cfg.ApplyMetadata(&l)
+	o.Observer.Scanned(l)
+}
+
+// crosscheckValues are the services -crosscheck accepts.
+var crosscheckValues = []string{"clearlydefined", "github"}
+
+// crosscheckLicenses completes licenses with the comma-separated services
+// set by -crosscheck, in order.
+func crosscheckLicenses(licenses []report.License, o *options) error {
+	if o.offline {
+		return fmt.Errorf("-crosscheck cannot be used with -offline")
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	for _, service := range strings.Split(o.crosscheck, ",") {
+		switch strings.TrimSpace(service) {
+		case "clearlydefined":
+			cd, err := crosscheck.NewClearlyDefined()
+			if err == nil {
+				err = cd.Apply(licenses, templates)
+			}
+			if err != nil {
+				return err
+			}
+		case "github":
+			gh, err := crosscheck.NewGitHub()
+			if err != nil {
+				return err
+			}
+			gh.Apply(licenses)
+		default:
+			return fmt.Errorf("unknown -crosscheck service %q, expected: %s",
+				service, strings.Join(crosscheckValues, ", "))
+		}
+	}
+	return nil
+}
+
+// nativeValues are the values -native accepts.
+var nativeValues = []string{"names", "deb", "rpm"}
+
+// lookupNativeLicenses completes the licenses of the system libraries linked
+// by cgo packages with the ones of the deb or rpm packages of the running
+// system installing them, depending on source.
+func lookupNativeLicenses(licenses []report.License, source string) error {
+	libFiles := map[string][]string{}
+	files := []string{}
+	for _, l := range licenses {
+		if l.Source == "native" {
+			libFiles[l.Package] = gomod.NativeLibraryFiles("", l.Package)
+			files = append(files, libFiles[l.Package]...)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	var pkgs []report.License
+	var owners map[string]string
+	var err error
+	if source == "deb" {
+		owners, err = deb.FindOwners("", files)
+		if err == nil {
+			pkgs, err = deb.ListLicenses("")
+		}
+	} else {
+		owners, err = rpm.FindOwners("", files)
+		if err == nil {
+			pkgs, err = rpm.ListLicenses("", false)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not look up native libraries: %s", err)
+	}
+	byName := map[string]report.License{}
+	for _, pkg := range pkgs {
+		byName[pkg.Package] = pkg
+	}
+	for i, l := range licenses {
+		if l.Source != "native" {
+			continue
+		}
+		for _, file := range libFiles[l.Package] {
+			pkg, ok := byName[owners[file]]
+			if !ok {
+				continue
+			}
+			l.Origin = pkg.Package
+			l.Version = pkg.Version
+			l.Declared = pkg.Declared
+			l.Path = pkg.Path
+			l.Template = pkg.Template
+			l.Score = pkg.Score
+			l.ExtraWords = pkg.ExtraWords
+			l.MissingWords = pkg.MissingWords
+			l.MissingSections = pkg.MissingSections
+			l.PatentGrant = pkg.PatentGrant
+			l.TrademarkClause = pkg.TrademarkClause
+			l.Err = pkg.Err
+			licenses[i] = l
+			break
+		}
+	}
+	return nil
+}
+
+// streamGoLicenses scans the packages passed as arguments and writes NDJSON
+// events while modules are scanned.
+func streamGoLicenses(pkgs []string, o *options) error {
+	return o.writeOutput(func(w io.Writer) error {
+		n := report.NewNDJSONWriter(w)
+		o.observer = n
+		_, err := listGoLicenses(pkgs, o)
+		if err != nil {
+			n.Error(err)
+		}
+		werr := n.Close()
+		if err != nil {
+			return err
+		}
+		return werr
+	})
+}
+
+func runGo(pkgs []string, o *options, all, annotate, obligations bool,
+	checklist, inventoryPath string) error {
+
+	if o.format == "ndjson" && o.template == "" && checklist == "" &&
+		inventoryPath == "" && !obligations {
+		if o.crosscheck != "" {
+			return fmt.Errorf("-crosscheck is not supported with -format ndjson")
+		}
+		return streamGoLicenses(pkgs, o)
+	}
+	licenses, err := listGoLicenses(pkgs, o)
+	if err != nil {
+		if licenses != nil {
+			// Partial results of an aborted scan, with -partial.
+			werr := o.writeReport(groupGoLicenses(licenses, o, all, annotate))
+			if werr != nil {
+				return werr
+			}
+		}
+		return err
+	}
+	if obligations {
+		return o.writeOutput(func(w io.Writer) error {
+			return report.WriteObligations(w, o.format, licenses, o.confidence)
+		})
+	}
+	if checklist != "" {
+		return o.writeOutput(func(w io.Writer) error {
+			return report.WriteChecklist(w, checklist, licenses, o.confidence)
+		})
+	}
+	if inventoryPath != "" {
+		return o.reconcileInventory(inventoryPath, licenses)
+	}
+	return o.writeReport(groupGoLicenses(licenses, o, all, annotate))
+}
+
+// groupGoLicenses groups licenses by license file unless all or annotate is
+// set, or licenses are grouped with -group-by.
+func groupGoLicenses(licenses []report.License, o *options, all,
+	annotate bool) []report.License {
+
+	if annotate {
+		return gomod.AnnotateGroups(licenses)
+	} else if !all && o.groupBy == "" {
+		return gomod.GroupLicenses(licenses)
+	}
+	return licenses
+}
+
+// binaryPlaceholder is replaced with the binary name in -o paths with
+// -per-binary.
+const binaryPlaceholder = "{binary}"
+
+// runPerBinary scans each main package matched by pkgs separately. Reports
+// are written to separate files if the -o path holds the {binary}
+// placeholder, or as table sections headed by the main package import path.
+func runPerBinary(pkgs []string, o *options, all, annotate bool) error {
+	split := strings.Contains(o.output, binaryPlaceholder)
+	if !split && (o.format != "table" || o.template != "" || o.groupBy != "") {
+		return fmt.Errorf("-per-binary requires the table format, or -o " +
+			"holding " + binaryPlaceholder)
+	}
+	_, profile, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	pkgs, err = o.targets(pkgs)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := o.scanContext()
+	defer cancel()
+	mains, err := gomod.MainPackages(ctx, pkgs, &gomod.Options{
+		Profile: profile,
+		Offline: o.offline,
+		GoFlags: o.goflags,
+	})
+	if err != nil {
+		return err
+	}
+	if len(mains) == 0 {
+		return fmt.Errorf("no main package in %s", strings.Join(pkgs, " "))
+	}
+	if split {
+		seen := map[string]string{}
+		for _, main := range mains {
+			name := path.Base(main)
+			if other, ok := seen[name]; ok {
+				return fmt.Errorf("%s and %s would both be written to %s", other,
+					main, strings.Replace(o.output, binaryPlaceholder, name, -1))
+			}
+			seen[name] = main
+		}
+	}
+	// Each binary only reports the modules it imports.
+	o.imported = true
+	reports := make([][]report.License, len(mains))
+	for i, main := range mains {
+		licenses, err := scanPackages([]string{main}, o)
+		if err != nil {
+			return fmt.Errorf("could not scan %s: %s", main, err)
+		}
+		reports[i] = groupGoLicenses(licenses, o, all, annotate)
+	}
+	if split {
+		output := o.output
+		defer func() { o.output = output }()
+		for i, main := range mains {
+			o.output = strings.Replace(output, binaryPlaceholder, path.Base(main), -1)
+			err := o.writeReport(reports[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return o.writeOutput(func(w io.Writer) error {
+		for i, main := range mains {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			_, err := fmt.Fprintf(w, "# %s\n", main)
+			if err != nil {
+				return err
+			}
+			err = report.Write(w, o.format, reports[i], o.reportOptions())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// reconcileInventory reconciles the inventory at path with supplied licenses,
+// writes the differences to the output and fails if there are any.
+func (o *options) reconcileInventory(path string, licenses []report.License) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entries, err := inventory.Read(f)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %s", path, err)
+	}
+	rec := inventory.Reconcile(entries, licenses, o.confidence)
+	err = o.writeOutput(func(w io.Writer) error {
+		return inventory.Write(w, rec)
+	})
+	if err != nil {
+		return err
+	}
+	if !rec.Empty() {
+		return fmt.Errorf("inventory %s does not match dependencies", path)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setTestGOPATH makes commands scan the GOPATH mode packages of the gomod
+// test data. The module cache is moved to a temporary directory, so no go
+// command can download modules into the test data.
+func setTestGOPATH(t *testing.T) {
+	t.Helper()
+	gopath, err := filepath.Abs(filepath.Join("..", "gomod", "testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPATH", gopath)
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GO111MODULE", "off")
+}
+
+// csvPackages returns the space-separated packages of a CSV report.
+func csvPackages(report string) string {
+	pkgs := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(report), "\n")[1:] {
+		pkgs = append(pkgs, strings.Split(line, ",")[1])
+	}
+	return strings.Join(pkgs, " ")
+}
+
+func TestTargetsFile(t *testing.T) {
+	setTestGOPATH(t)
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The list is longer than the command lines of some operating systems.
+	lines := []string{"# generated"}
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, "colors/cmd/paint", "", "colors/cmd/mix")
+	}
+	targets := filepath.Join(dir, "targets.txt")
+	err = ioutil.WriteFile(targets, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.txt")
+	err = ioutil.WriteFile(empty, []byte("# nothing\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, errOut := runTestCommand(t, "go", "-a", "-format", "csv",
+		"-targets-file", targets, "colors/blue")
+	if code != 0 {
+		t.Fatalf("scan failed with %d: %s", code, errOut)
+	}
+	wanted := "colors/blue colors/cmd/mix colors/cmd/paint colors/red couleurs/red"
+	if got := csvPackages(out); got != wanted {
+		t.Fatalf("unexpected packages: %q != %q", got, wanted)
+	}
+
+	code, _, errOut = runTestCommand(t, "go", "-targets-file", empty)
+	if code != exitError || !strings.Contains(errOut, "no import path") {
+		t.Fatalf("empty targets file accepted: %d %s", code, errOut)
+	}
+}
+
+func TestPerBinary(t *testing.T) {
+	setTestGOPATH(t)
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	code, out, errOut := runTestCommand(t, "go", "-per-binary", "colors/cmd/...")
+	if code != 0 {
+		t.Fatalf("scan failed with %d: %s", code, errOut)
+	}
+	sections := strings.Split(out, "\n\n")
+	if len(sections) != 2 || !strings.HasPrefix(sections[0], "# colors/cmd/mix\n") ||
+		!strings.HasPrefix(sections[1], "# colors/cmd/paint\n") ||
+		!strings.Contains(sections[0], "couleurs/red") ||
+		strings.Contains(sections[1], "couleurs/red") {
+		t.Fatalf("unexpected sections:\n%s", out)
+	}
+
+	output := filepath.Join(dir, "licenses-{binary}.csv")
+	code, out, errOut = runTestCommand(t, "go", "-per-binary", "-a", "-format", "csv",
+		"-o", output, "colors/cmd/...")
+	if code != 0 || out != "" {
+		t.Fatalf("scan failed with %d: %s%s", code, out, errOut)
+	}
+	tests := []struct {
+		name   string
+		wanted string
+	}{
+		{"licenses-mix.csv", "colors/cmd/mix colors/red couleurs/red"},
+		{"licenses-paint.csv", "colors/cmd/paint colors/red"},
+	}
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(filepath.Join(dir, test.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := csvPackages(string(data)); got != test.wanted {
+			t.Errorf("unexpected packages in %s: %q", test.name, got)
+		}
+	}
+
+	code, _, errOut = runTestCommand(t, "go", "-per-binary", "-format", "json",
+		"colors/cmd/...")
+	if code != exitError || !strings.Contains(errOut, "requires the table format") {
+		t.Fatalf("json sections accepted: %d %s", code, errOut)
+	}
+	code, _, errOut = runTestCommand(t, "go", "-per-binary", "colors/red")
+	if code != exitError || !strings.Contains(errOut, "no main package") {
+		t.Fatalf("library accepted: %d %s", code, errOut)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	setTestGOPATH(t)
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "licenses.json")
+	err = ioutil.WriteFile(config, []byte(`{"policy": {"deny": ["AFL-3.0"]}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, errOut := runTestCommand(t, "go", "-q", "-confidence", "0.99",
+		"-config", config, "colors/cmd/mix")
+	if code != 0 {
+		t.Fatalf("scan failed with %d: %s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "colors/cmd/mix ") ||
+		!strings.HasPrefix(lines[1], "colors/red ") {
+		t.Fatalf("unexpected violations:\n%s", out)
+	}
+	// Warnings go to standard error, so standard output can be piped.
+	if strings.Contains(out, "warning") ||
+		!strings.Contains(errOut, "warning: couleurs/red: LGPL-2.1 is a deprecated") {
+		t.Fatalf("warnings not written to standard error:\n%s\n%s", out, errOut)
+	}
+}
+
+func TestGOPATHFallback(t *testing.T) {
+	license, err := ioutil.ReadFile(filepath.Join("..", "gomod", "testdata", "src",
+		"colors", "red", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"src/app/LICENSE":     string(license),
+		"src/app/cmd/main.go": "package main\n\nimport _ \"app/lib\"\n\nfunc main() {}\n",
+		"src/app/lib/lib.go":  "package lib\n",
+	})
+	// Without go.mod, GOPATH mode is used even if modules are enabled.
+	t.Setenv("GOPATH", dir)
+	t.Setenv("GO111MODULE", "")
+	t.Chdir(filepath.Join(dir, "src", "app"))
+
+	code, out, errOut := runTestCommand(t, "go", "-a", "-format", "csv")
+	if code != 0 {
+		t.Fatalf("scan failed with %d: %s", code, errOut)
+	}
+	if got := csvPackages(out); got != "app/cmd app/lib" {
+		t.Fatalf("unexpected packages: %q", got)
+	}
+	if !strings.Contains(out, "MIT License") {
+		t.Fatalf("parent license not found:\n%s", out)
+	}
+}
+
+func TestDefaultPackages(t *testing.T) {
+	license, err := ioutil.ReadFile(filepath.Join("..", "gomod", "testdata", "src",
+		"colors", "red", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"app/go.mod": "module example.com/app\n\ngo 1.24\n\n" +
+			"require example.com/dep v1.0.0\n\nreplace example.com/dep => ../dep\n",
+		"app/LICENSE":      string(license),
+		"app/main.go":      "package main\n\nimport _ \"example.com/app/lib\"\n\nfunc main() {}\n",
+		"app/lib/lib.go":   "package lib\n\nimport _ \"example.com/dep\"\n",
+		"app/tool/main.go": "package main\n\nfunc main() {}\n",
+		"dep/go.mod":       "module example.com/dep\n\ngo 1.24\n",
+		"dep/LICENSE":      string(license),
+		"dep/dep.go":       "package dep\n",
+	})
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GO111MODULE", "")
+	t.Chdir(filepath.Join(dir, "app"))
+
+	// Without import paths, the packages of the current module are scanned,
+	// reporting the same modules as "./...".
+	code, out, errOut := runTestCommand(t, "go", "-offline", "-include-self",
+		"-format", "csv")
+	if code != 0 {
+		t.Fatalf("scan failed with %d: %s", code, errOut)
+	}
+	if got := csvPackages(out); got != "example.com/app example.com/dep" {
+		t.Fatalf("unexpected packages: %q", got)
+	}
+	code, out2, errOut := runTestCommand(t, "go", "-offline", "-include-self",
+		"-format", "csv", "./...")
+	if code != 0 || out2 != out {
+		t.Fatalf("./... scan differs with %d:\n%s\n%s%s", code, out, out2, errOut)
+	}
+
+	// Binaries only report the modules they import.
+	code, out, errOut = runTestCommand(t, "go", "-offline", "-per-binary")
+	if code != 0 {
+		t.Fatalf("scan failed with %d: %s", code, errOut)
+	}
+	sections := strings.Split(out, "\n\n")
+	if len(sections) != 2 || !strings.HasPrefix(sections[0], "# example.com/app\n") ||
+		!strings.Contains(sections[0], "example.com/dep") ||
+		strings.Contains(sections[1], "example.com/dep") {
+		t.Fatalf("unexpected sections:\n%s", out)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	license, err := ioutil.ReadFile(filepath.Join("..", "gomod", "testdata", "src",
+		"colors", "red", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "go-licenses-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"src/app/LICENSE":  string(license),
+		"src/app/main.go":  "package main\n\nfunc main() {}\n",
+		"src/app/hw.go":    "//go:build hardware\n\npackage main\n\nimport _ \"app/hw\"\n",
+		"src/app/hw/hw.go": "package hw\n",
+		"licenses.json":    `{"profiles": {"robot": {"tags": ["hardware"]}}}`,
+	})
+	t.Setenv("GOPATH", dir)
+	t.Setenv("GO111MODULE", "off")
+	t.Setenv("GOFLAGS", "")
+	config := filepath.Join(dir, "licenses.json")
+
+	tests := []struct {
+		args   []string
+		wanted string
+	}{
+		{[]string{}, "app"},
+		{[]string{"-profile", "robot"}, "app app/hw"},
+	}
+	for _, test := range tests {
+		args := append([]string{"-a", "-format", "csv", "-config", config}, test.args...)
+		code, out, errOut := runTestCommand(t, "go", append(args, "app")...)
+		if code != 0 {
+			t.Fatalf("%v: scan failed with %d: %s", test.args, code, errOut)
+		}
+		if got := csvPackages(out); got != test.wanted {
+			t.Errorf("%v: unexpected packages: %q != %q", test.args, got, test.wanted)
+		}
+	}
+
+	code, _, errOut := runTestCommand(t, "go", "-config", config, "-profile", "desk", "app")
+	if code != exitError || !strings.Contains(errOut, `unknown profile "desk", known profiles: robot`) {
+		t.Fatalf("unknown profile accepted: %d %s", code, errOut)
+	}
+}
+
+func TestEnvFlags(t *testing.T) {
+	setTestGOPATH(t)
+
+	t.Setenv("GOLICENSES_FORMAT", "csv")
+	t.Setenv("GOLICENSES_A", "true")
+	code, out, errOut := runTestCommand(t, "go", "colors/red")
+	if code != 0 {
+		t.Fatalf("scan failed with %d: %s", code, errOut)
+	}
+	if !strings.HasPrefix(out, "source,package,") || csvPackages(out) != "colors/red" {
+		t.Fatalf("environment flags ignored:\n%s", out)
+	}
+
+	// Command line flags take precedence.
+	code, out, errOut = runTestCommand(t, "go", "-format", "json", "colors/red")
+	if code != 0 || !strings.HasPrefix(out, "[") {
+		t.Fatalf("environment flag took precedence with %d:\n%s%s", code, out, errOut)
+	}
+
+	t.Setenv("GOLICENSES_CONFIDENCE", "high")
+	code, _, errOut = runTestCommand(t, "go", "colors/red")
+	if code != exitUsage || !strings.Contains(errOut, `invalid GOLICENSES_CONFIDENCE value "high"`) {
+		t.Fatalf("invalid environment flag accepted: %d %s", code, errOut)
+	}
+}
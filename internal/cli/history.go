@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+var historyCommand = &command{
+	Name:    "history",
+	Args:    "MODULE OLD NEW",
+	Summary: "compare the licenses of two versions of a Go module",
+	Help: `
+Compares the licenses of versions OLD and NEW of Go module MODULE, to evaluate
+whether an upgrade relicenses it, like projects switching to source-available
+licenses: the detected licenses, the license files and, if their content
+changed, a side-by-side diff of their normalized words. Lines marked with "|"
+differ, lines marked with "<" are only in the OLD license file and lines marked
+with ">" only in the NEW one.
+
+Versions missing from the module cache are downloaded, unless -offline is
+set, or only their license files are fetched from GOPROXY with -proxy.
+Versions are module queries, like "v1.2.3" or "latest".
+
+With -full, the whole texts are displayed instead of eliding unchanged parts.
+With -exit-code, the command fails if the license changed.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		fs.BoolVar(&o.offline, "offline", false, "never download modules")
+		fs.BoolVar(&o.proxy, "proxy", false,
+			"fetch license files of modules missing from the cache from GOPROXY")
+		fs.BoolVar(&o.fetchRemote, "fetch-remote", false,
+			"fetch license texts referred to by URL in license files")
+		fs.Int64Var(&o.maxSize, "max-license-size", gomod.DefaultMaxLicenseSize,
+			"skip license files larger than this many bytes")
+		full := fs.Bool("full", false, "display unchanged parts of the diff")
+		exitCode := fs.Bool("exit-code", false, "fail if the license changed")
+		return func(args []string) error {
+			if len(args) != 3 {
+				return fmt.Errorf("expect MODULE, OLD and NEW arguments")
+			}
+			changed, err := runHistory(args[0], args[1], args[2], o, *full)
+			if err != nil {
+				return err
+			}
+			if *exitCode && changed {
+				return fmt.Errorf("license of %s changed", args[0])
+			}
+			return nil
+		}
+	},
+}
+
+// runHistory writes the comparison of the licenses of versions oldVersion
+// and newVersion of module, and returns whether the license changed.
+func runHistory(module, oldVersion, newVersion string, o *options, full bool) (
+	bool, error) {
+
+	cfg, _, err := o.loadConfig()
+	if err != nil {
+		return false, err
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return false, err
+	}
+	entries, err := detectors(cfg, o.algorithm)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := o.scanContext()
+	defer cancel()
+	mods := []*modinfo.ModulePublic{}
+	for _, version := range []string{oldVersion, newVersion} {
+		mod, err := gomod.ResolveVersion(ctx, module, version, o.offline)
+		if err != nil {
+			return false, err
+		}
+		mods = append(mods, mod)
+	}
+	licenses, err := gomod.ScanModules(ctx, mods, &gomod.Options{
+		Offline:        o.offline,
+		Download:       true,
+		Proxy:          o.proxy,
+		FetchRemote:    o.fetchRemote,
+		MaxLicenseSize: o.maxSize,
+		Detectors:      entries,
+		Logger:         o.scanLogger(),
+	})
+	if err != nil {
+		return false, err
+	}
+	byVersion := map[string]report.License{}
+	for _, l := range licenses {
+		cfg.ApplyOverride(&l, templates)
+		byVersion[l.Version] = l
+	}
+	old, new := byVersion[mods[0].Version], byVersion[mods[1].Version]
+	texts := [][]byte{}
+	for _, l := range []report.License{old, new} {
+		if l.Err != "" {
+			return false, fmt.Errorf("could not scan %s@%s: %s", l.Package, l.Version, l.Err)
+		}
+		text := []byte{}
+		if l.Path != "" {
+			text, err = ioutil.ReadFile(l.Path)
+			if err != nil {
+				return false, err
+			}
+		}
+		texts = append(texts, text)
+	}
+	err = report.WriteHistory(os.Stdout, old, new, texts[0], texts[1], o.confidence, full)
+	if err != nil {
+		return false, err
+	}
+	return report.LicenseChanged(old, new, o.confidence), nil
+}
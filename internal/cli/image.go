@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/groove-x/go-licenses/internal/image"
+)
+
+var imageCommand = &command{
+	Name:    "image",
+	Args:    "IMAGE",
+	Summary: "list the licenses of a container image content",
+	Help: `
+Lists the licenses of the operating system packages of a container image and
+of the modules linked in its Go binaries. Debian, Alpine and RPM package
+databases are supported, the latter requiring the rpm command. Go modules
+sources are looked up in the local module cache.
+
+IMAGE is either an OCI image layout directory, an image archive written by
+"docker save" or in OCI format, or an image reference exported with docker and
+pulled if necessary.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addOutputFlags(fs, "table")
+		return func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expect one image argument")
+			}
+			licenses, err := image.ListLicenses(args[0])
+			if err != nil {
+				return err
+			}
+			return o.writeReport(licenses)
+		}
+	},
+}
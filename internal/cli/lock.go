@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/groove-x/go-licenses/internal/lock"
+)
+
+var lockCommand = &command{
+	Name:    "lock",
+	Args:    "IMPORTPATH...",
+	Summary: "record the licenses of Go dependencies in a lock file",
+	Help: `
+Scans the dependencies of specified packages like the go command and records
+each module version along with its detected license and the SHA-256 of its
+license file in a lock file, to be committed and checked with verify.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		path := fs.String("lock", lock.DefaultPath, "lock file path")
+		return func(args []string) error {
+			licenses, err := listGoLicenses(args, o)
+			if err != nil {
+				return err
+			}
+			entries, err := lock.Make(licenses, o.confidence)
+			if err != nil {
+				return err
+			}
+			f, err := os.Create(*path)
+			if err != nil {
+				return err
+			}
+			err = lock.Write(f, entries)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}
+	},
+}
+
+var verifyCommand = &command{
+	Name:    "verify",
+	Args:    "IMPORTPATH...",
+	Summary: "verify the licenses of Go dependencies against a lock file",
+	Help: `
+Scans the dependencies of specified packages and compares them with the lock
+file written by the lock command. It fails if a dependency is missing from the
+lock file, or if its detected license or license file content changed, even
+after an upgrade. It catches dependencies silently relicensed by new releases.
+Run lock again to accept the changes.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		path := fs.String("lock", lock.DefaultPath, "lock file path")
+		return func(args []string) error {
+			locked, err := lock.Read(*path)
+			if err != nil {
+				return err
+			}
+			licenses, err := listGoLicenses(args, o)
+			if err != nil {
+				return err
+			}
+			current, err := lock.Make(licenses, o.confidence)
+			if err != nil {
+				return err
+			}
+			mismatches := lock.Verify(locked, current)
+			err = lock.WriteMismatches(os.Stdout, mismatches)
+			if err != nil {
+				return err
+			}
+			if len(mismatches) > 0 {
+				return fmt.Errorf("%d dependency license(s) differ from %s",
+					len(mismatches), *path)
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var mergeCommand = &command{
+	Name:    "merge",
+	Args:    "[SOURCE=]FILE...",
+	Summary: "merge JSON reports of several scanners into one",
+	Help: `
+Combines the JSON reports written by the go, deb, apk, rpm and image commands
+into a single report, written as JSON unless -format says otherwise. Each entry
+records the scanner which produced it in its "source" field. Entries lacking
+one, like those of older reports, are assigned SOURCE when the file is passed
+as SOURCE=FILE. Duplicate entries are dropped and the result is sorted by
+source and package.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addOutputFlags(fs, "json")
+		return func(args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("expect at least one report argument")
+			}
+			lists := [][]report.License{}
+			for _, arg := range args {
+				source, path := "", arg
+				if i := strings.Index(arg, "="); i >= 0 {
+					source, path = arg[:i], arg[i+1:]
+				}
+				licenses, err := readReports([]string{path})
+				if err != nil {
+					return err
+				}
+				for i := range licenses {
+					if licenses[i].Source == "" {
+						licenses[i].Source = source
+					}
+				}
+				lists = append(lists, licenses)
+			}
+			merged := report.Merge(lists...)
+			return o.writeReport(merged)
+		}
+	},
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/internal/sign"
+)
+
+// writeOutput calls write with the output file set with -o, or the standard
+// output. The file is replaced atomically once write succeeds, so readers
+// never see partial output. With -append, the output is appended to the
+// current file content.
+func (o *options) writeOutput(write func(w io.Writer) error) error {
+	if o.output == "" {
+		return write(os.Stdout)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(o.output), "."+filepath.Base(o.output))
+	if err != nil {
+		return err
+	}
+	err = o.fillOutput(f, write)
+	if err == nil {
+		err = os.Rename(f.Name(), o.output)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if o.signKey != "" {
+		return o.signOutput()
+	}
+	return nil
+}
+
+// signOutput writes the detached signature of the -o file with the -sign key
+// next to it, with a .sig extension, and its in-toto attestation with a
+// .intoto.json one if -attest is set.
+func (o *options) signOutput() error {
+	key, err := sign.ReadKey(o.signKey)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(o.output)
+	if err != nil {
+		return err
+	}
+	sig, err := sign.Sign(key, data)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(o.output+".sig",
+		[]byte(base64.StdEncoding.EncodeToString(sig)+"\n"), 0644)
+	if err != nil || !o.attest {
+		return err
+	}
+	predicateType := sign.ReportPredicate
+	if o.format == "spdx" && o.template == "" {
+		predicateType = sign.SPDXPredicate
+	}
+	envelope, err := sign.Attest(key, filepath.Base(o.output), data, predicateType)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(o.output+".intoto.json", append(b, '\n'), 0644)
+}
+
+func (o *options) fillOutput(f *os.File, write func(w io.Writer) error) error {
+	mode := os.FileMode(0644)
+	if o.append {
+		current, err := os.Open(o.output)
+		if err == nil {
+			if fi, err := current.Stat(); err == nil {
+				mode = fi.Mode()
+			}
+			_, err = io.Copy(f, current)
+			current.Close()
+		}
+		if err != nil && !os.IsNotExist(err) {
+			f.Close()
+			return err
+		}
+	}
+	err := write(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Chmod(mode)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeReport writes licenses in the selected format.
+func (o *options) writeReport(licenses []report.License) error {
+	return o.writeOutput(func(w io.Writer) error {
+		return report.Write(w, o.format, licenses, o.reportOptions())
+	})
+}
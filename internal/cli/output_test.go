@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "report.txt")
+	err = ioutil.WriteFile(output, []byte("previous\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeLines := func(lines ...string) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			for _, line := range lines {
+				if line == "FAIL" {
+					return fmt.Errorf("write failed")
+				}
+				_, err := fmt.Fprintln(w, line)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	tests := []struct {
+		name   string
+		append bool
+		write  func(w io.Writer) error
+		fails  bool
+		wanted string
+	}{
+		{"replace", false, writeLines("a", "b"), false, "a\nb\n"},
+		{"partial", false, writeLines("c", "FAIL"), true, "a\nb\n"},
+		{"append", true, writeLines("c"), false, "a\nb\nc\n"},
+		{"partial append", true, writeLines("d", "FAIL"), true, "a\nb\nc\n"},
+	}
+	for _, test := range tests {
+		o := &options{output: output, append: test.append}
+		err := o.writeOutput(test.write)
+		if (err != nil) != test.fails {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		data, err := ioutil.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.wanted {
+			t.Fatalf("%s: unexpected output: %q != %q", test.name, data, test.wanted)
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("%s: temporary files left: %d files in %s", test.name,
+				len(files), dir)
+		}
+	}
+
+	// Appending to a missing file creates it.
+	created := filepath.Join(dir, "created.txt")
+	o := &options{output: created, append: true}
+	err = o.writeOutput(writeLines("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(created); err != nil || string(data) != "a\n" {
+		t.Fatalf("unexpected appended output: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// progressObserver prints the module being scanned on a terminal line,
+// rewritten as the scan goes, or one line per module if w is not a terminal.
+// Licenses are forwarded to next, if set.
+type progressObserver struct {
+	next     report.Observer
+	w        io.Writer
+	terminal bool
+	total    int
+	scanned  int
+}
+
+func newProgressObserver(next report.Observer) *progressObserver {
+	return &progressObserver{
+		next:     next,
+		w:        os.Stderr,
+		terminal: isTerminal(os.Stderr),
+	}
+}
+
+func (p *progressObserver) Scanning(done, total int, pkg string) {
+	p.total = total
+	if p.terminal {
+		fmt.Fprintf(p.w, "\r\x1b[K[%d/%d] %s", done+1, total, pkg)
+	} else {
+		fmt.Fprintf(p.w, "[%d/%d] %s\n", done+1, total, pkg)
+	}
+	if p.next != nil {
+		p.next.Scanning(done, total, pkg)
+	}
+}
+
+func (p *progressObserver) Scanned(l report.License) {
+	p.scanned++
+	if p.terminal && p.scanned == p.total {
+		fmt.Fprint(p.w, "\r\x1b[K")
+	}
+	if p.next != nil {
+		p.next.Scanned(l)
+	}
+}
+
+// isTerminal returns true if f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// scanLogger returns the logger of scan steps enabled by -v, writing
+// structured records to standard error, or nil.
+func (o *options) scanLogger() *slog.Logger {
+	if !o.verbose {
+		return nil
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+}
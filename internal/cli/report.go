@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var reportCommand = &command{
+	Name:    "report",
+	Args:    "FILE...|IMPORTPATH...",
+	Summary: "render saved JSON reports in another format",
+	Help: `
+Reads the reports written by other commands with -format json and renders them
+in the format set with -format. Entries of several reports are concatenated.
+"-" reads a report from the standard input.
+
+Arguments which are not files are import paths whose dependencies are scanned
+like with the go command, so the "report" invocations of google/go-licenses
+keep working: with -template, templates are then executed with the data model
+of google/go-licenses by default, a list of libraries with Name, Version,
+LicenseName, LicenseURL, LicensePath and LicenseText fields, as with
+"-template-model google". Set "-template-model packages" to use the data model
+of the go command instead. Flags may follow arguments, like with
+google/go-licenses.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		o.addOutputFlags(fs, "table")
+		return func(args []string) error {
+			args, err := parseInterspersed(fs, args)
+			if err != nil {
+				return err
+			}
+			if len(args) < 1 {
+				return fmt.Errorf("expect at least one report or import path argument")
+			}
+			files, err := areReports(args)
+			if err != nil {
+				return err
+			}
+			var licenses []report.License
+			if files {
+				licenses, err = readReports(args)
+			} else {
+				if o.tmplModel == "" {
+					o.tmplModel = "google"
+				}
+				licenses, err = listGoLicenses(args, o)
+			}
+			if err != nil {
+				return err
+			}
+			return o.writeReport(licenses)
+		}
+	},
+}
+
+// parseInterspersed parses the flags of fs found among args, after
+// positional arguments, and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for len(args) > 0 {
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		if args[0] == "-" || !strings.HasPrefix(args[0], "-") {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+	}
+	return positional, nil
+}
+
+// areReports returns true if args are report files, false if they are import
+// paths, and fails if they mix both.
+func areReports(args []string) (bool, error) {
+	files := 0
+	for _, arg := range args {
+		if arg == "-" {
+			files++
+		} else if info, err := os.Stat(arg); err == nil && info.Mode().IsRegular() {
+			files++
+		}
+	}
+	if files > 0 && files < len(args) {
+		return false, fmt.Errorf("cannot mix report files and import paths")
+	}
+	return files > 0, nil
+}
+
+// readReports reads and concatenates the JSON reports at paths.
+func readReports(paths []string) ([]report.License, error) {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	licenses := []report.License{}
+	for _, path := range paths {
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			r = f
+		}
+		l, err := report.ReadJSON(r, templates)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %s", path, err)
+		}
+		licenses = append(licenses, l...)
+	}
+	for _, l := range licenses {
+		if relativePaths(l) {
+			return report.ResolvePaths(licenses, pathRoots()), nil
+		}
+	}
+	return licenses, nil
+}
+
+// relativePaths returns true if l has relative license file paths, as
+// written without -abs.
+func relativePaths(l report.License) bool {
+	for _, p := range []string{l.Path, l.Notice, l.Group} {
+		if p != "" && !filepath.IsAbs(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/reuse"
+)
+
+var reuseLintCommand = &command{
+	Name:    "reuse-lint",
+	Args:    "[DIR]",
+	Summary: "check a project against the REUSE specification",
+	Help: `
+Checks the project in directory DIR, the current directory by default, against
+the REUSE specification (https://reuse.software), the project own licensing
+rather than the one of its dependencies. Every file must declare its copyright
+and license with SPDX-FileCopyrightText and SPDX-License-Identifier tags in its
+header, in a FILE.license file next to it for binary files or files without
+comments, or in a Files paragraph of the .reuse/dep5 file. The license texts
+are stored in the LICENSES directory, named after their SPDX identifier like
+LICENSES/MIT.txt.
+
+Files ignored by git, empty files, symbolic links and license files like
+LICENSE or COPYING are not checked. Lines between REUSE-IgnoreStart and
+REUSE-IgnoreEnd are skipped.
+
+Problems are listed on standard output, and make the command fail: files
+missing copyright or license, invalid or deprecated license identifiers,
+licenses without text in LICENSES, texts of LICENSES no file uses, and texts
+matching another license than the one naming them with enough confidence.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		return func(args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("expect at most one DIR argument")
+			}
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+			return runReuseLint(dir, o)
+		}
+	},
+}
+
+func runReuseLint(dir string, o *options) error {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	r, err := reuse.Lint(dir, templates, o.confidence)
+	if err != nil {
+		return err
+	}
+	for _, p := range r.Problems {
+		fmt.Println(p)
+	}
+	if len(r.Problems) > 0 {
+		return fmt.Errorf("%d REUSE problem(s) in %d files checked", len(r.Problems),
+			len(r.Files))
+	}
+	fmt.Printf("%d files checked, project is REUSE compliant\n", len(r.Files))
+	return nil
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/groove-x/go-licenses/internal/rpm"
+)
+
+var rpmCommand = &command{
+	Name:    "rpm",
+	Summary: "list the licenses of installed RPM packages",
+	Help: `
+Lists the RPM packages installed according to rpm database along with their
+License tag. It requires the rpm command. Declared licenses naming a known
+template are trusted.
+
+With -files, the files tagged as licenses in packages are matched against a
+set of well-known licenses for the other packages.
+
+With -root, the packages installed in the filesystem tree at specified path are
+listed instead, like an unpacked container image or a chroot.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addOutputFlags(fs, "table")
+		root := fs.String("root", "", "root directory of the filesystem to scan")
+		files := fs.Bool("files", false, "match installed license files")
+		return func(args []string) error {
+			licenses, err := rpm.ListLicenses(*root, *files)
+			if err != nil {
+				return err
+			}
+			return o.writeReport(licenses)
+		}
+	},
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var saveCommand = &command{
+	Name:    "save",
+	Args:    "IMPORTPATH...",
+	Summary: "copy the license files of Go dependencies to a directory",
+	Help: `
+Copies the license and NOTICE files of the dependencies of specified packages
+into the directory set with -dir, under a subdirectory named after each module
+path. Files already present are overwritten.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		dir := fs.String("dir", "", "destination directory")
+		return func(args []string) error {
+			if *dir == "" {
+				return fmt.Errorf("destination directory must be set with -dir")
+			}
+			licenses, err := listGoLicenses(args, o)
+			if err != nil {
+				return err
+			}
+			return saveLicenses(*dir, licenses)
+		}
+	},
+}
+
+// saveLicenses copies license and NOTICE files of supplied licenses into dir.
+func saveLicenses(dir string, licenses []report.License) error {
+	for _, l := range licenses {
+		for _, path := range []string{l.Path, l.Notice} {
+			if path == "" {
+				continue
+			}
+			dst := filepath.Join(dir, filepath.FromSlash(l.Package),
+				filepath.Base(path))
+			err := copyFile(dst, path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func copyFile(dst, src string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+var searchCommand = &command{
+	Name:    "search",
+	Args:    "PATTERN [IMPORTPATH...]",
+	Summary: "list the Go dependencies under matching licenses",
+	Help: `
+Lists the modules among the dependencies of specified packages whose license
+matches PATTERN, with their version, to answer questions like "do we ship
+anything under the CDDL?". Without import paths, the dependencies of the
+current module are searched.
+
+PATTERN is an SPDX identifier, title or nickname, possibly holding wildcards
+like "BSD-*", or an SPDX expression like "CDDL-1.0 OR EPL-*", which matches the
+licenses matching any of its identifiers. It is matched case-insensitively
+against licenses detected with enough confidence, or declared ones. The
+"unknown" pattern matches the licenses not detected with enough confidence.
+
+The command fails if no module matches.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		o.addOutputFlags(fs, "table")
+		return func(args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("expect a PATTERN argument")
+			}
+			return runSearch(args[0], args[1:], o)
+		}
+	},
+}
+
+func runSearch(pattern string, pkgs []string, o *options) error {
+	patterns := report.ExpressionIDs(pattern)
+	if len(patterns) == 0 {
+		return fmt.Errorf("empty search pattern")
+	}
+	o.only = strings.Join(patterns, ",")
+	o.versions = true
+	licenses, err := listGoLicenses(pkgs, o)
+	if err != nil {
+		return err
+	}
+	opts := o.reportOptions()
+	err = opts.Filter.Validate()
+	if err != nil {
+		return err
+	}
+	if len(opts.Filter.Apply(licenses, o.confidence)) == 0 {
+		return fmt.Errorf("no module license matches %s", pattern)
+	}
+	return o.writeReport(licenses)
+}
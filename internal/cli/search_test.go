@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	setTestGOPATH(t)
+	tests := []struct {
+		args   []string
+		code   int
+		wanted string
+	}{
+		{[]string{"LGPL-*", "colors/cmd/mix"}, 0, "couleurs/red"},
+		{[]string{"mit", "colors/cmd/mix"}, 0, "colors/red"},
+		{[]string{"CDDL-1.0 OR MIT", "colors/cmd/mix"}, 0, "colors/red"},
+		{[]string{"AFL-3.0 OR LGPL-2.1", "colors/cmd/mix"}, 0,
+			"colors/cmd/mix couleurs/red"},
+		{[]string{"CDDL-*", "colors/cmd/mix"}, exitError, ""},
+		{[]string{"", "colors/cmd/mix"}, exitError, ""},
+	}
+	for _, test := range tests {
+		args := append([]string{"-format", "csv"}, test.args...)
+		code, out, errOut := runTestCommand(t, "search", args...)
+		if code != test.code {
+			t.Errorf("search %q exited with %d: %s", test.args, code, errOut)
+			continue
+		}
+		if code == 0 && csvPackages(out) != test.wanted {
+			t.Errorf("unexpected search %q result: %q != %q", test.args,
+				csvPackages(out), test.wanted)
+		}
+	}
+	code, _, errOut := runTestCommand(t, "search")
+	if code != exitError || !strings.Contains(errOut, "expect a PATTERN") {
+		t.Fatalf("missing pattern accepted: %d %s", code, errOut)
+	}
+}
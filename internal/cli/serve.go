@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/server"
+)
+
+var serveCommand = &command{
+	Name:    "serve",
+	Summary: "serve Go module license lookups over HTTP",
+	Help: `
+Serves an HTTP API looking up the licenses of Go modules, so a platform team
+can run a single scanner and share its caches with all projects.
+
+  POST /scan
+    Reports the licenses of the modules listed in the request body: a go.mod
+    file, a JSON array of "path@version" strings, or module versions one per
+    line, like "github.com/pkg/errors v0.9.1". The report is written as JSON
+    records, or in the format set by the "format" query parameter.
+
+  GET /module/{path}@{version}/license
+    Returns the JSON record of a module version license, with the license
+    file content in its "text" field.
+
+Modules missing from the module cache are downloaded with "go mod download",
+or only their license files are fetched from the GOPROXY module proxies with
+-proxy. With -offline, they are reported with an error instead. Detected
+licenses are kept in memory, so each module version is scanned once, and the
+overrides and policy of the configuration file apply to them. They can be
+shared with other servers and scans with -remote-cache, as documented by
+"go-licenses go -h".
+
+Requests are logged to standard error with -v. The server stops on
+interrupt, like Ctrl-C.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		addr := fs.String("addr", "localhost:8080", "listen on this address")
+		fs.StringVar(&o.goflags, "goflags", "",
+			"flags passed to go commands, in addition to GOFLAGS")
+		fs.BoolVar(&o.offline, "offline", false, "never download modules")
+		fs.BoolVar(&o.proxy, "proxy", false,
+			"fetch license files of modules missing from the cache from GOPROXY")
+		o.addRemoteCacheFlag(fs)
+		fs.BoolVar(&o.verbose, "v", false, "log requests and scan steps to standard error")
+		fs.DurationVar(&o.timeout, "timeout", 0, "abort requests after this duration")
+		fs.DurationVar(&o.stepTimeout, "step-timeout", 0,
+			"abort requests if a go command or proxy request lasts longer")
+		return func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("unexpected arguments: %v", args)
+			}
+			return runServe(*addr, o)
+		}
+	},
+}
+
+func runServe(addr string, o *options) error {
+	cfg, _, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	log := o.scanLogger()
+	remote, err := o.remote()
+	if err != nil {
+		return err
+	}
+	s, err := server.New(&gomod.Options{
+		Offline:     o.offline,
+		Download:    !o.proxy,
+		Proxy:       o.proxy,
+		GoFlags:     o.goflags,
+		Logger:      log,
+		StepTimeout: o.stepTimeout,
+		Remote:      remote,
+	}, cfg, o.confidence)
+	if err != nil {
+		return err
+	}
+	s.Timeout = o.timeout
+	srv := &http.Server{Addr: addr, Handler: s.Handler()}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	fmt.Fprintf(os.Stderr, "serving license lookups on %s\n", addr)
+	err = srv.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
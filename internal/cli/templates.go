@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+var validateTemplatesCommand = &command{
+	Name:    "validate-templates",
+	Args:    "[FILE...]",
+	Summary: "check license templates for metadata and matching problems",
+	Help: `
+Checks the embedded license templates, along with the template files given as
+arguments, for problems breaking license detection: a missing title, category
+or spdx-id front matter field, SPDX identifiers or titles shared by several
+templates, and license texts which do not match their own template best with a
+score of 1, like when a new template is too close to an existing one.
+
+Templates without license text, like "No License", need no spdx-id.
+Translated license texts, like the Japanese translations of the MIT and BSD
+licenses, set a language front matter field, like "language: ja", and share
+the spdx-id of the original license. Problems are listed on standard output,
+and make the command fail.
+
+With -templates, or GOLICENSES_TEMPLATES, the templates of a directory are
+checked instead of the embedded ones, like before using them to scan.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		addTemplatesFlag(fs)
+		return func(args []string) error {
+			return runValidateTemplates(args)
+		}
+	},
+}
+
+func runValidateTemplates(paths []string) error {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		t, err := matcher.ParseTemplate(string(data))
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %s", path, err)
+		}
+		t.File = filepath.Base(path)
+		templates = append(templates, t)
+	}
+	errs := matcher.Validate(templates)
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d template problem(s)", len(errs))
+	}
+	fmt.Printf("%d templates checked\n", len(templates))
+	return nil
+}
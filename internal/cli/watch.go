@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// watchedFiles are the files of the current directory whose changes trigger
+// rescans.
+var watchedFiles = []string{"go.mod", "go.sum", "go.work", "go.work.sum"}
+
+var watchCommand = &command{
+	Name:    "watch",
+	Args:    "[IMPORTPATH...]",
+	Summary: "rescan Go dependencies when go.mod or go.sum change",
+	Help: `
+Scans the dependencies of specified packages, then watches the go.mod, go.sum,
+go.work and go.work.sum files of the current directory and rescans them each
+time their content changes, like during dependency upgrade sessions. Without
+import paths, the packages of the current module are scanned.
+
+Only the changes are printed: the packages added (+), removed (-) or whose
+license changed (~) since the previous scan, like with the diff command,
+followed by those among them violating the policy of the configuration file,
+if any, and not waived by its waivers.
+
+Rescans are incremental: like with -state, modules whose version and go.sum
+hash did not change keep the license of the previous scan instead of being
+matched again.
+
+Files are read every -interval, saving a file without changing it triggers no
+rescan. Failed scans, like those of a go.mod being edited, are reported and
+the previous results kept. The command stops on interrupt, like Ctrl-C.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		o.addConfigFlags(fs)
+		o.addScanFlags(fs)
+		interval := fs.Duration("interval", 2*time.Second, "check files this often")
+		return func(args []string) error {
+			if *interval <= 0 {
+				return fmt.Errorf("-interval must be positive")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			return runWatch(ctx, os.Stdout, args, o, *interval)
+		}
+	},
+}
+
+// fileHashes returns a digest of the content of the watched files, changing
+// whenever one of them is created, removed or modified.
+func fileHashes() string {
+	h := sha256.New()
+	for _, name := range watchedFiles {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(h, "%s -\n", name)
+			continue
+		}
+		fmt.Fprintf(h, "%s %d\n", name, len(data))
+		h.Write(data)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// runWatch scans pkgs, then rescans them whenever the watched files change,
+// until ctx is done, writing the changes to w.
+func runWatch(ctx context.Context, w io.Writer, pkgs []string, o *options,
+	interval time.Duration) error {
+
+	cfg, _, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	hashes := fileHashes()
+	o.previous = map[string]report.License{}
+	licenses, err := listGoLicenses(pkgs, o)
+	if err != nil {
+		return err
+	}
+	violations := watchViolations(cfg, licenses, o.confidence)
+	fmt.Fprintf(w, "%d packages, %d policy violation(s), watching for changes\n",
+		len(licenses), len(violations))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current := fileHashes()
+		if current == hashes {
+			continue
+		}
+		hashes = current
+		fmt.Fprintf(w, "# %s: rescanning\n", time.Now().Format("15:04:05"))
+		after, err := listGoLicenses(pkgs, o)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			continue
+		}
+		err = writeWatchChanges(w, cfg, licenses, after, o.confidence)
+		if err != nil {
+			return err
+		}
+		licenses = after
+	}
+}
+
+// watchViolations returns the violations of licenses to the configuration
+// policy which are not waived, or nothing if there is no policy.
+func watchViolations(cfg *config.Config, licenses []report.License,
+	confidence float64) []policy.Violation {
+
+	if cfg.Policy.Empty() {
+		return nil
+	}
+	violations := cfg.Policy.Check(licenses, confidence)
+	violations, _ = policy.Waive(violations, cfg.Waivers, time.Now())
+	return violations
+}
+
+// writeWatchChanges writes the differences between the before and after
+// scans to w, and the violations of the packages added or changed.
+func writeWatchChanges(w io.Writer, cfg *config.Config, before,
+	after []report.License, confidence float64) error {
+
+	d := report.Compare(before, after, confidence)
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "no license change")
+		return err
+	}
+	err := report.WriteDiff(w, d, confidence)
+	if err != nil {
+		return err
+	}
+	changed := append([]report.License{}, d.Added...)
+	for _, c := range d.Changed {
+		changed = append(changed, c.New)
+	}
+	violations := watchViolations(cfg, changed, confidence)
+	if len(violations) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "%d policy violation(s):\n", len(violations))
+	return writeViolations(w, violations, nil)
+}
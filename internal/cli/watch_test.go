@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+// waitFor waits until b holds s, or fails after a while.
+func waitFor(t *testing.T, b *syncBuffer, s string) {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		if strings.Contains(b.String(), s) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%q not written:\n%s", s, b.String())
+}
+
+func TestWatch(t *testing.T) {
+	testdata, err := filepath.Abs(filepath.Join("..", "gomod", "testdata", "src", "colors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join(testdata, "red", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apache, err := ioutil.ReadFile(filepath.Join(testdata, "blue", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "go-licenses-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"gopath/src/a/a.go":    "package a\n",
+		"gopath/src/a/LICENSE": string(mit),
+		"work/go.mod":          "module example.com/work\n",
+	})
+	t.Setenv("GOPATH", filepath.Join(dir, "gopath"))
+	t.Setenv("GO111MODULE", "off")
+	t.Chdir(filepath.Join(dir, "work"))
+
+	o := &options{configPath: config.DefaultPath, confidence: 0.9}
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- runWatch(ctx, out, []string{"a"}, o, 10*time.Millisecond)
+	}()
+	waitFor(t, out, "1 packages, 0 policy violation(s), watching for changes")
+
+	// Saving go.mod unchanged triggers no rescan.
+	later := time.Now().Add(time.Minute)
+	err = os.Chtimes("go.mod", later, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if strings.Contains(out.String(), "rescanning") {
+		t.Fatalf("rescanned unchanged files:\n%s", out)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"gopath/src/a/LICENSE": string(apache),
+		"work/go.mod":          "module example.com/work\n\ngo 1.24\n",
+	})
+	waitFor(t, out, "rescanning")
+	waitFor(t, out, "Apache License 2.0")
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := o.previous["a@"]; !ok {
+		t.Fatalf("previous licenses not recorded: %+v", o.previous)
+	}
+}
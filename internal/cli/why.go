@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/gomod"
+)
+
+var whyCommand = &command{
+	Name:    "why",
+	Args:    "MODULE [IMPORTPATH...]",
+	Summary: "show why a Go module is a dependency",
+	Help: `
+Shows how specified packages depend on a module, so the packages to change
+can be found when its license breaks a policy. Without import paths, the
+packages of the current module are scanned.
+
+For each scanned package importing the module, the shortest import chain
+leading to one of its packages is printed, like with "go mod why". If no
+scanned package imports it, the shortest requirement chain of the module
+graph leading to it is printed instead, as module path and version pairs.`,
+	Setup: func(fs *flag.FlagSet, o *options) func(args []string) error {
+		fs.StringVar(&o.configPath, "config", config.DefaultPath, "configuration file")
+		fs.StringVar(&o.profileName, "profile", "", "scan with named build profile")
+		fs.StringVar(&o.goflags, "goflags", "",
+			"flags passed to go commands, in addition to GOFLAGS")
+		fs.BoolVar(&o.offline, "offline", false, "never download modules")
+		return func(args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("expect a MODULE argument")
+			}
+			return runWhy(args[0], args[1:], o)
+		}
+	},
+}
+
+func runWhy(module string, pkgs []string, o *options) error {
+	_, profile, err := o.loadConfig()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := o.scanContext()
+	defer cancel()
+	why, err := gomod.Why(ctx, module, pkgs, &gomod.Options{
+		Profile: profile,
+		Offline: o.offline,
+		GoFlags: o.goflags,
+	})
+	if err != nil {
+		return err
+	}
+	chains := why.Imports
+	if len(chains) == 0 {
+		if why.Requires == nil {
+			return fmt.Errorf("%s is not a dependency", module)
+		}
+		chains = [][]string{why.Requires}
+	}
+	lines := []string{"# " + module}
+	if len(why.Imports) == 0 {
+		lines = append(lines, "(not imported, required by)")
+	}
+	for i, chain := range chains {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, chain...)
+	}
+	_, err = fmt.Fprintln(os.Stdout, strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,210 @@
+// Package comment renders license reports as compact markdown summaries,
+// meant to be posted as pull request comments by CI bots.
+package comment
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// DefaultMaxSize is the default size limit of comments, in bytes. GitHub
+// rejects comments longer than 65536 characters, GitLab ones longer than
+// 1000000.
+const DefaultMaxSize = 65536
+
+// Summary is the content of a comment.
+type Summary struct {
+	// Diff lists the packages added, removed or whose license changed.
+	Diff *report.Diff
+	// Violations are the policy violations which are not waived.
+	Violations []policy.Violation
+	// Waivers are the waivers expiring soon, or expired.
+	Waivers []policy.Waiver
+	// Now is the date waivers expiry is computed at.
+	Now time.Time
+}
+
+// section is a table of the comment.
+type section struct {
+	title  string
+	header []string
+	rows   [][]string
+}
+
+// licenseName returns the SPDX identifier or title of the license of l
+// detected with confidence, the declared license otherwise, or "?".
+func licenseName(l report.License, confidence float64) string {
+	if l.Template != nil && l.Score >= confidence {
+		if l.Template.ID != "" {
+			return l.Template.ID
+		}
+		return l.Template.Title
+	}
+	if l.Declared != "" {
+		return l.Declared
+	}
+	return "?"
+}
+
+// cell escapes s for markdown table cells.
+func cell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+func (s *section) lines() []string {
+	return []string{
+		"#### " + s.title,
+		"",
+		"| " + strings.Join(s.header, " | ") + " |",
+		strings.Repeat("| --- ", len(s.header)) + "|",
+	}
+}
+
+func row(cells ...string) []string {
+	for i, c := range cells {
+		cells[i] = cell(c)
+	}
+	return cells
+}
+
+func (s *Summary) sections(confidence float64) []*section {
+	violations := &section{
+		title:  "Policy violations",
+		header: []string{"Package", "Version", "License", "Reason"},
+	}
+	for _, v := range s.Violations {
+		violations.rows = append(violations.rows, row(v.License.Package,
+			v.License.Version, licenseName(v.License, confidence), v.Reason))
+	}
+	added := &section{
+		title:  "Added",
+		header: []string{"Package", "Version", "License"},
+	}
+	changed := &section{
+		title:  "Changed",
+		header: []string{"Package", "Version", "License"},
+	}
+	removed := &section{
+		title:  "Removed",
+		header: []string{"Package", "Version", "License"},
+	}
+	if s.Diff != nil {
+		for _, l := range s.Diff.Added {
+			added.rows = append(added.rows, row(l.Package, l.Version,
+				licenseName(l, confidence)))
+		}
+		for _, c := range s.Diff.Changed {
+			version := c.New.Version
+			if c.Old.Version != c.New.Version {
+				version = c.Old.Version + " → " + c.New.Version
+			}
+			changed.rows = append(changed.rows, row(c.New.Package, version,
+				licenseName(c.Old, confidence)+" → "+licenseName(c.New, confidence)))
+		}
+		for _, l := range s.Diff.Removed {
+			removed.rows = append(removed.rows, row(l.Package, l.Version,
+				licenseName(l, confidence)))
+		}
+	}
+	waivers := &section{
+		title:  "Waivers expiring",
+		header: []string{"Package", "License", "Expires", "Reason"},
+	}
+	for _, w := range s.Waivers {
+		expires := w.Expires
+		if w.Expired(s.Now) {
+			expires += " (expired)"
+		}
+		license := w.License
+		if license == "" {
+			license = "all"
+		}
+		waivers.rows = append(waivers.rows, row(w.Package, license, expires, w.Reason))
+	}
+	return []*section{violations, added, changed, removed, waivers}
+}
+
+// plural returns n followed by word, with an "s" unless n is 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// Write renders s as markdown: a headline counting changes, violations and
+// expiring waivers, followed by a table for each. Rows are left out once the
+// comment would exceed maxSize bytes, and their number is written instead.
+func Write(w io.Writer, s *Summary, confidence float64, maxSize int) error {
+	sections := s.sections(confidence)
+	counts := []string{
+		plural(len(sections[1].rows), "added package"),
+		plural(len(sections[2].rows), "changed license"),
+		plural(len(sections[3].rows), "removed package"),
+		plural(len(sections[0].rows), "policy violation"),
+		plural(len(sections[4].rows), "expiring waiver"),
+	}
+	status := ":white_check_mark:"
+	if len(s.Violations) > 0 {
+		status = ":x:"
+	} else if len(sections[4].rows) > 0 {
+		status = ":warning:"
+	}
+	lines := []string{
+		"### " + status + " License report",
+		"",
+		strings.Join(counts, " · "),
+	}
+	// Keep room for the notes of omitted rows. Once rows are left out, the
+	// following sections are too, so the most important ones are complete.
+	budget := maxSize - len(strings.Join(lines, "\n")) - 128
+	trimmed := false
+	omitted := 0
+	for _, sec := range sections {
+		if len(sec.rows) == 0 {
+			continue
+		}
+		if trimmed {
+			omitted += len(sec.rows)
+			continue
+		}
+		head := append([]string{""}, sec.lines()...)
+		size := len(strings.Join(head, "\n")) + 1
+		rows := []string{}
+		for _, r := range sec.rows {
+			line := "| " + strings.Join(r, " | ") + " |"
+			if size+len(line)+1 > budget {
+				break
+			}
+			size += len(line) + 1
+			rows = append(rows, line)
+		}
+		if len(rows) < len(sec.rows) {
+			trimmed = true
+			omitted += len(sec.rows) - len(rows)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		budget -= size
+		lines = append(lines, head...)
+		lines = append(lines, rows...)
+		if len(rows) < len(sec.rows) {
+			lines = append(lines, "", fmt.Sprintf("_%s not shown._",
+				plural(len(sec.rows)-len(rows), "more row")))
+		}
+	}
+	if omitted > 0 {
+		lines = append(lines, "", fmt.Sprintf(
+			"_The comment was trimmed to fit size limits, %s left out._",
+			plural(omitted, "row")))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
@@ -0,0 +1,66 @@
+package comment
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+func TestWrite(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	before := []report.License{
+		{Source: "go", Package: "a", Version: "v1", Template: mit, Score: 1},
+		{Source: "go", Package: "b", Version: "v1", Template: mit, Score: 1},
+	}
+	after := []report.License{
+		{Source: "go", Package: "a", Version: "v2", Declared: "BUSL-1.1"},
+		{Source: "go", Package: "c|d", Version: "v1", Template: mit, Score: 1},
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	s := &Summary{
+		Diff:       report.Compare(before, after, 0.9),
+		Violations: []policy.Violation{{License: after[0], Reason: "license not allowed"}},
+		Waivers:    []policy.Waiver{{Package: "e", Expires: "2024-05-31"}},
+		Now:        now,
+	}
+	b := &bytes.Buffer{}
+	err := Write(b, s, 0.9, DefaultMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, wanted := range []string{
+		"### :x: License report",
+		"1 added package · 1 changed license · 1 removed package · 1 policy violation · 1 expiring waiver",
+		"| a | v2 | BUSL-1.1 | license not allowed |",
+		"| c\\|d | v1 | MIT |",
+		"| a | v1 → v2 | MIT → BUSL-1.1 |",
+		"| b | v1 | MIT |",
+		"| e | all | 2024-05-31 (expired) |  |",
+	} {
+		if !strings.Contains(b.String(), wanted) {
+			t.Fatalf("%q missing from comment:\n%s", wanted, b)
+		}
+	}
+
+	s = &Summary{Diff: &report.Diff{}, Now: now}
+	for i := 0; i < 1000; i++ {
+		s.Diff.Added = append(s.Diff.Added, report.License{
+			Package: fmt.Sprintf("example.com/module%d", i),
+			Version: "v1.0.0",
+		})
+	}
+	b.Reset()
+	err = Write(b, s, 0.9, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() > 2000 || !strings.Contains(b.String(), "more rows not shown") {
+		t.Fatalf("comment not trimmed, %d bytes:\n%s", b.Len(), b)
+	}
+}
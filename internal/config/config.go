@@ -0,0 +1,221 @@
+// Package config loads the go-licenses configuration file.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// DefaultPath is the configuration file read when -config is not set. It is
+// not an error for it to be missing.
+const DefaultPath = ".licenses.json"
+
+// Profile describes a build configuration to scan, typically one shipped
+// product. Only modules linked under these constraints are reported.
+type Profile struct {
+	GOOS   string   `json:"goos,omitempty"`
+	GOARCH string   `json:"goarch,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// Env returns the environment variables to pass to go commands so they
+// evaluate build constraints like the profile does.
+func (p *Profile) Env() []string {
+	env := []string{}
+	if p.GOOS != "" {
+		env = append(env, "GOOS="+p.GOOS)
+	}
+	if p.GOARCH != "" {
+		env = append(env, "GOARCH="+p.GOARCH)
+	}
+	if len(p.Tags) > 0 {
+		flags := os.Getenv("GOFLAGS")
+		if flags != "" {
+			flags += " "
+		}
+		env = append(env, "GOFLAGS="+flags+"-tags="+strings.Join(p.Tags, ","))
+	}
+	return env
+}
+
+// Override sets the license of a package, for licenses which cannot be
+// detected or were reviewed manually.
+type Override struct {
+	Package string `json:"package"`
+	// Version restricts the override to one version. Empty matches all.
+	Version string `json:"version,omitempty"`
+	// License designates a license template by SPDX identifier, title or
+	// nickname. Other values are reported as declared licenses.
+	License string `json:"license"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Metadata attaches free-form fields, like the owner team, a ticket or an
+// approval status, to a package, reported along with its license.
+type Metadata struct {
+	Package string `json:"package"`
+	// Version restricts the metadata to one version. Empty matches all.
+	Version string            `json:"version,omitempty"`
+	Fields  map[string]string `json:"fields"`
+}
+
+// Detector enables a license detector complementing the built-in word
+// matcher.
+type Detector struct {
+	Name string `json:"name"`
+	// Command is an external program run as a detector, with the module
+	// directory as last argument, see detect.Command. Without command, Name
+	// designates a detector compiled in.
+	Command []string `json:"command,omitempty"`
+	// Priority orders detectors, the word matcher having priority 0. It
+	// defaults to 0 for commands, and to their own priority for detectors
+	// compiled in.
+	Priority *int `json:"priority,omitempty"`
+}
+
+type Config struct {
+	Profiles  map[string]*Profile `json:"profiles,omitempty"`
+	Policy    policy.Policy       `json:"policy,omitempty"`
+	Overrides []Override          `json:"overrides,omitempty"`
+	// Metadata entries matching a package version are merged, later ones
+	// overwriting the fields of earlier ones.
+	Metadata []Metadata `json:"metadata,omitempty"`
+	// Waivers are waived policy violations, in addition to the ones of the
+	// check -waivers file.
+	Waivers []policy.Waiver `json:"waivers,omitempty"`
+	// Detectors lists external detector commands and sets the priority of
+	// the detectors compiled in, which are all enabled.
+	Detectors []Detector `json:"detectors,omitempty"`
+}
+
+// Override returns the override of the package version, or nil.
+func (c *Config) Override(pkg, version string) *Override {
+	for i, o := range c.Overrides {
+		if o.Package == pkg && (o.Version == "" || o.Version == version) {
+			return &c.Overrides[i]
+		}
+	}
+	return nil
+}
+
+// ApplyOverride sets the license of l if it is overridden. The license
+// template is looked up in templates.
+func (c *Config) ApplyOverride(l *report.License, templates []*matcher.Template) {
+	o := c.Override(l.Package, l.Version)
+	if o == nil {
+		return
+	}
+	l.Template = matcher.FindTemplate(templates, o.License)
+	l.Score = 0
+	l.Declared = ""
+	l.DeclaredBy = ""
+	if l.Template != nil {
+		l.Score = 1
+	} else {
+		l.Declared = o.License
+	}
+	l.ExtraWords = nil
+	l.MissingWords = nil
+	l.MissingSections = nil
+	l.Err = ""
+	l.Overridden = true
+}
+
+// ApplyMetadata attaches to l the fields of the metadata entries of its
+// package version.
+func (c *Config) ApplyMetadata(l *report.License) {
+	fields := map[string]string{}
+	for k, v := range l.Metadata {
+		fields[k] = v
+	}
+	for _, m := range c.Metadata {
+		if m.Package != l.Package || (m.Version != "" && m.Version != l.Version) {
+			continue
+		}
+		for k, v := range m.Fields {
+			fields[k] = v
+		}
+	}
+	if len(fields) > 0 {
+		l.Metadata = fields
+	}
+}
+
+// Profile returns the named profile or an error listing the known ones.
+func (c *Config) Profile(name string) (*Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		names := []string{}
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q, known profiles: %s", name,
+			strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+func hasString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads the JSON configuration file at path. An empty configuration is
+// returned if path is the default one and does not exist.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && path == DefaultPath {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	for _, o := range cfg.Overrides {
+		if o.Package == "" || o.License == "" {
+			return nil, fmt.Errorf("%s: override without package or license", path)
+		}
+	}
+	for _, m := range cfg.Metadata {
+		if m.Package == "" || len(m.Fields) == 0 {
+			return nil, fmt.Errorf("%s: metadata without package or fields", path)
+		}
+	}
+	err = policy.ValidateWaivers(cfg.Waivers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	for _, r := range cfg.Policy.Require {
+		if !hasString(policy.RequireValues, r) {
+			return nil, fmt.Errorf("%s: unknown policy requirement %q, supported "+
+				"values: %s", path, r, strings.Join(policy.RequireValues, ", "))
+		}
+	}
+	return cfg, nil
+}
+
+// Save writes cfg to path as indented JSON.
+func Save(path string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
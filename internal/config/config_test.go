@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+func TestProfileEnv(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=mod")
+	p := &Profile{GOOS: "linux", GOARCH: "arm64", Tags: []string{"hardware", "cgo"}}
+	got := strings.Join(p.Env(), " ")
+	wanted := "GOOS=linux GOARCH=arm64 GOFLAGS=-mod=mod -tags=hardware,cgo"
+	if got != wanted {
+		t.Fatalf("profile environment mismatch: %q != %q", got, wanted)
+	}
+}
+
+func TestApplyOverride(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	cfg := &Config{Overrides: []Override{
+		{Package: "a", License: "MIT"},
+		{Package: "b", Version: "v2", License: "Custom"},
+	}}
+	licenses := []report.License{
+		{Package: "a", Version: "v1", Err: "no license file"},
+		{Package: "b", Version: "v1"},
+		{Package: "b", Version: "v2", Template: mit, Score: 0.5},
+	}
+	for i := range licenses {
+		cfg.ApplyOverride(&licenses[i], []*matcher.Template{mit})
+	}
+	if l := licenses[0]; l.Template != mit || l.Score != 1 || l.Err != "" || !l.Overridden {
+		t.Errorf("unexpected override of a: %+v", l)
+	}
+	if l := licenses[1]; l.Overridden {
+		t.Errorf("unexpected override of b@v1: %+v", l)
+	}
+	if l := licenses[2]; l.Template != nil || l.Declared != "Custom" || !l.Overridden {
+		t.Errorf("unexpected override of b@v2: %+v", l)
+	}
+}
+
+func TestApplyMetadata(t *testing.T) {
+	cfg := &Config{Metadata: []Metadata{
+		{Package: "a", Fields: map[string]string{"owner": "team-a", "approval": "pending"}},
+		{Package: "a", Version: "v2", Fields: map[string]string{"approval": "approved"}},
+	}}
+	licenses := []report.License{
+		{Package: "a", Version: "v1"},
+		{Package: "a", Version: "v2"},
+		{Package: "b", Version: "v1"},
+	}
+	for i := range licenses {
+		cfg.ApplyMetadata(&licenses[i])
+	}
+	if m := licenses[0].Metadata; m["owner"] != "team-a" || m["approval"] != "pending" {
+		t.Errorf("unexpected metadata of a@v1: %v", m)
+	}
+	if m := licenses[1].Metadata; m["owner"] != "team-a" || m["approval"] != "approved" {
+		t.Errorf("unexpected metadata of a@v2: %v", m)
+	}
+	if m := licenses[2].Metadata; m != nil {
+		t.Errorf("unexpected metadata of b@v1: %v", m)
+	}
+}
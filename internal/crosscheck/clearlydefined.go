@@ -0,0 +1,184 @@
+// Package crosscheck completes detected licenses with curated license data
+// from remote services.
+package crosscheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/cachedir"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// ClearlyDefined fetches curated license definitions from the ClearlyDefined
+// API.
+type ClearlyDefined struct {
+	// BaseURL is the API root URL.
+	BaseURL string
+	Client  *http.Client
+	// CacheDir keeps fetched definitions, if set. Definitions of a version
+	// are not expected to change much, they are never refreshed.
+	CacheDir string
+	// Interval is the minimum delay between requests.
+	Interval time.Duration
+	last     time.Time
+}
+
+// NewClearlyDefined returns a client of the public ClearlyDefined API,
+// caching definitions in the user cache directory.
+func NewClearlyDefined() (*ClearlyDefined, error) {
+	dir, err := cachedir.Path("clearlydefined")
+	if err != nil {
+		return nil, err
+	}
+	return &ClearlyDefined{
+		BaseURL:  "https://api.clearlydefined.io",
+		Client:   &http.Client{Timeout: time.Minute},
+		CacheDir: dir,
+		Interval: 100 * time.Millisecond,
+	}, nil
+}
+
+// coordinates returns the ClearlyDefined coordinates of a Go module version.
+// The module path prefix is the namespace, with slashes escaped.
+func coordinates(module, version string) string {
+	namespace, name := "-", module
+	if i := strings.LastIndex(module, "/"); i >= 0 {
+		namespace, name = url.PathEscape(module[:i]), module[i+1:]
+		namespace = strings.Replace(namespace, "/", "%2F", -1)
+	}
+	return "go/golang/" + namespace + "/" + name + "/" + version
+}
+
+// fetch sends req with client and returns the response body and status code.
+// Requests are spaced by at least interval since the last one, and retried
+// once when throttled.
+func fetch(client *http.Client, req *http.Request, interval time.Duration,
+	last *time.Time) ([]byte, int, error) {
+
+	for retry := 0; ; retry++ {
+		if wait := interval - time.Since(*last); wait > 0 {
+			time.Sleep(wait)
+		}
+		*last = time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && retry == 0 {
+			delay, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+			if err != nil || delay <= 0 {
+				delay = 1
+			}
+			time.Sleep(time.Duration(delay) * time.Second)
+			continue
+		}
+		return data, resp.StatusCode, nil
+	}
+}
+
+// Declared returns the license expression declared by the ClearlyDefined
+// definition of a Go module version, or an empty string if there is none.
+func (c *ClearlyDefined) Declared(module, version string) (string, error) {
+	coords := coordinates(module, version)
+	cachePath := ""
+	if c.CacheDir != "" {
+		cachePath = filepath.Join(c.CacheDir, filepath.FromSlash(
+			strings.Replace(coords, "%2F", "!", -1)))
+		if data, err := ioutil.ReadFile(cachePath); err == nil {
+			return string(data), nil
+		}
+	}
+	req, err := http.NewRequest("GET", c.BaseURL+"/definitions/"+coords, nil)
+	if err != nil {
+		return "", err
+	}
+	data, status, err := fetch(c.Client, req, c.Interval, &c.last)
+	if err != nil {
+		return "", err
+	}
+	declared := ""
+	switch status {
+	case http.StatusOK:
+		var def struct {
+			Licensed struct {
+				Declared string `json:"declared"`
+			} `json:"licensed"`
+		}
+		err = json.Unmarshal(data, &def)
+		if err != nil {
+			return "", fmt.Errorf("could not parse %s definition: %s", coords, err)
+		}
+		declared = def.Licensed.Declared
+	case http.StatusNotFound:
+	default:
+		return "", fmt.Errorf("could not fetch %s definition: %s", coords,
+			http.StatusText(status))
+	}
+	if cachePath != "" {
+		err = os.MkdirAll(filepath.Dir(cachePath), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(cachePath, []byte(declared), 0644)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return declared, nil
+}
+
+// curated returns true if declared is a license expression, as opposed to
+// ClearlyDefined placeholders.
+func curated(declared string) bool {
+	return declared != "" && declared != "NOASSERTION" &&
+		!strings.Contains(declared, "OTHER")
+}
+
+// Apply records the license declared by ClearlyDefined definitions of Go
+// modules, with "clearlydefined" as DeclaredBy. Curated licenses are preferred
+// over fuzzy matches: the template they name, if any, replaces the matched
+// template unless it matched exactly.
+func (c *ClearlyDefined) Apply(licenses []report.License,
+	templates []*matcher.Template) error {
+
+	for i := range licenses {
+		l := &licenses[i]
+		if (l.Source != "" && l.Source != "go") || l.Version == "" {
+			continue
+		}
+		declared, err := c.Declared(l.Package, l.Version)
+		if err != nil {
+			return err
+		}
+		if !curated(declared) {
+			continue
+		}
+		l.Declared = declared
+		l.DeclaredBy = "clearlydefined"
+		if l.Template != nil && l.Score > .99 {
+			continue
+		}
+		l.Template = matcher.FindTemplate(templates, declared)
+		l.Score = 0
+		if l.Template != nil {
+			l.Score = 1
+		}
+		l.ExtraWords = nil
+		l.MissingWords = nil
+	}
+	return nil
+}
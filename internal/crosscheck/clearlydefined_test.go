@@ -0,0 +1,88 @@
+package crosscheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+func TestCoordinates(t *testing.T) {
+	got := coordinates("github.com/a/b", "v1.0.0")
+	wanted := "go/golang/github.com%2Fa/b/v1.0.0"
+	if got != wanted {
+		t.Fatalf("unexpected coordinates: %q != %q", got, wanted)
+	}
+}
+
+func TestClearlyDefinedApply(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.EscapedPath() {
+		case "/definitions/go/golang/example.com/fuzzy/v1.0.0":
+			fmt.Fprint(w, `{"licensed": {"declared": "MIT"}}`)
+		case "/definitions/go/golang/example.com/compound/v1.0.0":
+			fmt.Fprint(w, `{"licensed": {"declared": "MIT OR Apache-2.0"}}`)
+		case "/definitions/go/golang/example.com/unknown/v1.0.0":
+			fmt.Fprint(w, `{"licensed": {"declared": "NOASSERTION"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "go-licenses-clearlydefined")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	bsd := &matcher.Template{Title: "BSD 3-clause", ID: "BSD-3-Clause"}
+	templates := []*matcher.Template{mit, bsd}
+	licenses := []report.License{
+		{Source: "go", Package: "example.com/fuzzy", Version: "v1.0.0",
+			Template: bsd, Score: 0.8},
+		{Source: "go", Package: "example.com/compound", Version: "v1.0.0",
+			Template: bsd, Score: 0.95},
+		{Source: "go", Package: "example.com/unknown", Version: "v1.0.0"},
+		{Source: "go", Package: "example.com/missing", Version: "v1.0.0",
+			Template: bsd, Score: 0.95},
+		{Source: "go", Package: "example.com/main"},
+	}
+	cd := &ClearlyDefined{BaseURL: srv.URL, Client: srv.Client(), CacheDir: dir}
+	err = cd.Apply(licenses, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := licenses[0]; l.Template != mit || l.Score != 1 ||
+		l.DeclaredBy != "clearlydefined" {
+		t.Fatalf("curated license not preferred: %+v", l)
+	}
+	if l := licenses[1]; l.Template != nil || l.Declared != "MIT OR Apache-2.0" {
+		t.Fatalf("unexpected compound license: %+v", l)
+	}
+	if l := licenses[2]; l.Declared != "" {
+		t.Fatalf("unexpected placeholder license: %+v", l)
+	}
+	if l := licenses[3]; l.Template != bsd || l.Declared != "" {
+		t.Fatalf("unexpected missing definition license: %+v", l)
+	}
+	if requests != 4 {
+		t.Fatalf("unexpected request count: %d", requests)
+	}
+
+	// Definitions are cached.
+	err = cd.Apply(licenses, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 4 {
+		t.Fatalf("definitions were not cached: %d requests", requests)
+	}
+}
@@ -0,0 +1,167 @@
+package crosscheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/cachedir"
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// GitHub looks up the license of GitHub repositories with the GitHub
+// licenses API.
+type GitHub struct {
+	// BaseURL is the API root URL.
+	BaseURL string
+	Client  *http.Client
+	// Token authenticates requests, if set, raising rate limits.
+	Token string
+	// CacheDir keeps the licenses of fetched references, if set.
+	CacheDir string
+	// Interval is the minimum delay between requests.
+	Interval time.Duration
+	last     time.Time
+}
+
+// NewGitHub returns a client of the public GitHub API, authenticated with
+// the GITHUB_TOKEN environment variable if set, and caching licenses in the
+// user cache directory.
+func NewGitHub() (*GitHub, error) {
+	dir, err := cachedir.Path("github")
+	if err != nil {
+		return nil, err
+	}
+	return &GitHub{
+		BaseURL:  "https://api.github.com",
+		Client:   &http.Client{Timeout: time.Minute},
+		Token:    os.Getenv("GITHUB_TOKEN"),
+		CacheDir: dir,
+		Interval: 100 * time.Millisecond,
+	}, nil
+}
+
+// RepositoryLicense is the license detected by GitHub in a repository.
+type RepositoryLicense struct {
+	// SPDX is the SPDX identifier of the license, or empty.
+	SPDX string `json:"spdx"`
+	// URL links to the license file.
+	URL string `json:"url"`
+}
+
+// License returns the license detected by GitHub at the root of the
+// repository hosting a Go module version. It returns an empty license for
+// modules not hosted on GitHub, and repositories without license.
+func (g *GitHub) License(module, version string) (RepositoryLicense, error) {
+	license := RepositoryLicense{}
+	repo, _, ref := gomod.Repository(module, version)
+	if !strings.HasPrefix(repo, "https://github.com/") {
+		return license, nil
+	}
+	name := strings.TrimPrefix(repo, "https://github.com/")
+	if !validPath(name) || !validPath(ref) {
+		return license, fmt.Errorf("invalid GitHub repository reference %s@%s", name, ref)
+	}
+	cachePath := ""
+	if g.CacheDir != "" {
+		cachePath = filepath.Join(g.CacheDir, filepath.FromSlash(name),
+			filepath.FromSlash(ref)+".json")
+		if data, err := ioutil.ReadFile(cachePath); err == nil {
+			err = json.Unmarshal(data, &license)
+			return license, err
+		}
+	}
+	req, err := http.NewRequest("GET", g.BaseURL+"/repos/"+name+"/license?ref="+url.QueryEscape(ref), nil)
+	if err != nil {
+		return license, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	data, status, err := fetch(g.Client, req, g.Interval, &g.last)
+	if err != nil {
+		return license, err
+	}
+	switch status {
+	case http.StatusOK:
+		var result struct {
+			HTMLURL string `json:"html_url"`
+			License struct {
+				SPDXID string `json:"spdx_id"`
+			} `json:"license"`
+		}
+		err = json.Unmarshal(data, &result)
+		if err != nil {
+			return license, fmt.Errorf("could not parse %s license: %s", name, err)
+		}
+		license.URL = result.HTMLURL
+		if curated(result.License.SPDXID) {
+			license.SPDX = result.License.SPDXID
+		}
+	case http.StatusNotFound:
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		return license, fmt.Errorf("could not fetch %s license: GitHub API rate "+
+			"limit exceeded, set GITHUB_TOKEN to raise it", name)
+	default:
+		return license, fmt.Errorf("could not fetch %s license: %s", name,
+			http.StatusText(status))
+	}
+	if cachePath != "" {
+		data, err := json.Marshal(license)
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(cachePath), 0755)
+		}
+		if err == nil {
+			err = ioutil.WriteFile(cachePath, data, 0644)
+		}
+		if err != nil {
+			return license, err
+		}
+	}
+	return license, nil
+}
+
+// validPath returns true if the slash-separated path p has no empty, "." or
+// ".." element, which could escape the cache directory.
+func validPath(p string) bool {
+	for _, elem := range strings.Split(p, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply records the license GitHub detects in the repositories of Go modules
+// without license file nor declared license, with "github" as DeclaredBy.
+// It fills in modules whose license lies at the repository root, outside the
+// module subdirectory. Failed lookups, like rate limited ones, are recorded
+// as the error of their module.
+func (g *GitHub) Apply(licenses []report.License) {
+	for i := range licenses {
+		l := &licenses[i]
+		if (l.Source != "" && l.Source != "go") || l.Version == "" ||
+			l.Path != "" || l.Err != "" || l.Declared != "" {
+			continue
+		}
+		license, err := g.License(l.Package, l.Version)
+		if err != nil {
+			l.Err = err.Error()
+			continue
+		}
+		if license.SPDX == "" {
+			continue
+		}
+		l.Declared = license.SPDX
+		l.DeclaredBy = "github"
+		l.URL = license.URL
+	}
+}
@@ -0,0 +1,63 @@
+package crosscheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+func TestGitHubApply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/repos/a/b/license?ref=sub%2Fv1.0.0":
+			fmt.Fprint(w, `{"html_url": "https://github.com/a/b/blob/sub/v1.0.0/LICENSE",
+				"license": {"spdx_id": "MIT"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	licenses := []report.License{
+		{Source: "go", Package: "github.com/a/b/sub", Version: "v1.0.0"},
+		{Source: "go", Package: "github.com/a/c", Version: "v1.0.0"},
+		{Source: "go", Package: "example.com/d", Version: "v1.0.0"},
+	}
+	gh := &GitHub{BaseURL: srv.URL, Client: srv.Client(), Token: "secret"}
+	gh.Apply(licenses)
+	if l := licenses[0]; l.Declared != "MIT" || l.DeclaredBy != "github" ||
+		l.URL != "https://github.com/a/b/blob/sub/v1.0.0/LICENSE" {
+		t.Fatalf("unexpected repository license: %+v", l)
+	}
+	if licenses[1].Declared != "" || licenses[2].Declared != "" {
+		t.Fatalf("unexpected licenses: %+v", licenses[1:])
+	}
+
+	gh.Token = ""
+	limited := []report.License{licenses[1], licenses[0]}
+	limited[1].Declared = ""
+	gh.Apply(limited)
+	for _, l := range limited {
+		if !strings.Contains(l.Err, "rate limit") {
+			t.Fatalf("rate limit error not recorded: %+v", l)
+		}
+	}
+}
+
+func TestGitHubLicenseCachePath(t *testing.T) {
+	gh := &GitHub{BaseURL: "http://127.0.0.1:0", CacheDir: "cache"}
+	for _, version := range []string{"v1.0.0", "v0.0.0-20200101000000-0123456789ab"} {
+		_, err := gh.License("github.com/a/../../etc", version)
+		if err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("path escaping the cache accepted: %v", err)
+		}
+	}
+}
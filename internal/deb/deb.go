@@ -0,0 +1,239 @@
+// Package deb detects the licenses of installed Debian packages.
+package deb
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/internal/rootfs"
+)
+
+const (
+	statusPath = "/var/lib/dpkg/status"
+	infoDir    = "/var/lib/dpkg/info"
+	docDir     = "/usr/share/doc"
+)
+
+// Package is an installed Debian package, as recorded in dpkg database.
+type Package struct {
+	Name         string
+	Version      string
+	Architecture string
+	// Source and SourceVersion identify the source package. They default to
+	// the binary package ones.
+	Source        string
+	SourceVersion string
+}
+
+// ReadStatus parses a dpkg status file and returns its installed packages,
+// sorted by name.
+func ReadStatus(r io.Reader) ([]Package, error) {
+	pkgs := []Package{}
+	fields := map[string]string{}
+	flush := func() {
+		status := strings.Fields(fields["Status"])
+		if fields["Package"] != "" && len(status) == 3 && status[2] == "installed" {
+			pkg := Package{
+				Name:          fields["Package"],
+				Version:       fields["Version"],
+				Architecture:  fields["Architecture"],
+				Source:        fields["Package"],
+				SourceVersion: fields["Version"],
+			}
+			// Source field looks like "name" or "name (version)".
+			source := strings.Fields(fields["Source"])
+			if len(source) > 0 {
+				pkg.Source = source[0]
+			}
+			if len(source) > 1 {
+				pkg.SourceVersion = strings.Trim(source[1], "()")
+			}
+			pkgs = append(pkgs, pkg)
+		}
+		fields = map[string]string{}
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			// Continuation lines are only used by fields ignored here.
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		fields[line[:i]] = strings.TrimSpace(line[i+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name
+	})
+	return pkgs, nil
+}
+
+func listPackages(root string) ([]Package, error) {
+	path, err := rootfs.Resolve(root, statusPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadStatus(f)
+}
+
+// FindOwners returns the names of the packages installing the files at paths
+// in the filesystem rooted at root, by path, as recorded in the file lists of
+// dpkg database. Files installed by no package are left out. Since merged /usr
+// systems install files of /lib under /usr/lib, paths match with or without
+// their /usr prefix.
+func FindOwners(root string, paths []string) (map[string]string, error) {
+	wanted := map[string]string{}
+	for _, path := range paths {
+		wanted[path] = path
+		if strings.HasPrefix(path, "/usr/") {
+			wanted[strings.TrimPrefix(path, "/usr")] = path
+		} else {
+			wanted["/usr"+path] = path
+		}
+	}
+	dir, err := rootfs.Resolve(root, infoDir)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := filepath.Glob(filepath.Join(dir, "*.list"))
+	if err != nil {
+		return nil, err
+	}
+	owners := map[string]string{}
+	for _, list := range lists {
+		data, err := ioutil.ReadFile(list)
+		if err != nil {
+			return nil, err
+		}
+		// List files are named after the package, qualified with its
+		// architecture for multiarch ones, like "libssl3:amd64.list".
+		name := strings.TrimSuffix(filepath.Base(list), ".list")
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[:i]
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if path, ok := wanted[line]; ok && owners[path] == "" {
+				owners[path] = name
+			}
+		}
+	}
+	return owners, nil
+}
+
+// findCopyright returns the host path of the package copyright file in the
+// filesystem rooted at root, looking in the source package documentation
+// directory if the binary package one has none. It returns an empty string if
+// none is found.
+func findCopyright(root string, pkg Package) (string, error) {
+	for _, name := range []string{pkg.Name, pkg.Source} {
+		path, err := rootfs.Resolve(root, filepath.Join(docDir, name, "copyright"))
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// declaredLicense combines the SPDX expressions of a copyright file into
+// one.
+func declaredLicense(exprs []string) string {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	parts := []string{}
+	for _, expr := range exprs {
+		if strings.Contains(expr, " ") {
+			expr = "(" + expr + ")"
+		}
+		parts = append(parts, expr)
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// ListLicenses returns the licenses of Debian packages installed in the
+// filesystem rooted at root, read from their copyright files. An empty root
+// designates the running system. Licenses declared by machine-readable
+// copyright files are converted to SPDX expressions, and trusted if they
+// name a known template. Otherwise, copyright files are matched against
+// templates.
+func ListLicenses(root string) ([]report.License, error) {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := listPackages(root)
+	if err != nil {
+		return nil, err
+	}
+
+	licenses := []report.License{}
+	for _, pkg := range pkgs {
+		path, err := findCopyright(root, pkg)
+		if err != nil {
+			return nil, err
+		}
+		license := report.License{
+			Source:  "deb",
+			Package: pkg.Name,
+			Version: pkg.Version,
+			Path:    path,
+		}
+		if pkg.Source != pkg.Name {
+			license.Origin = pkg.Source
+		}
+		if license.Path == "" {
+			license.Err = "no copyright file"
+			licenses = append(licenses, license)
+			continue
+		}
+		data, err := ioutil.ReadFile(license.Path)
+		if err != nil {
+			license.Err = err.Error()
+			licenses = append(licenses, license)
+			continue
+		}
+		license.Declared = declaredLicense(ReadCopyrightLicenses(data))
+		if t := matcher.FindTemplate(templates, license.Declared); t != nil {
+			license.Template = t
+			license.Score = 1
+		} else {
+			m := matcher.Match(data, templates)
+			license.Score = m.Score
+			license.Template = m.Template
+			license.ExtraWords = m.ExtraWords
+			license.MissingWords = m.MissingWords
+			license.MissingSections = m.MissingSections
+			license.PatentGrant = m.Clauses.PatentGrant
+			license.TrademarkClause = m.Clauses.Trademark
+		}
+		licenses = append(licenses, license)
+	}
+	return licenses, nil
+}
@@ -0,0 +1,144 @@
+package deb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadStatus(t *testing.T) {
+	status := `Package: libfoo1
+Status: install ok installed
+Architecture: amd64
+Source: foo (1.2-3)
+Version: 1.2-3+b1
+Description: foo library
+ multiline description
+ .
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+
+Package: adduser
+Status: install ok installed
+Architecture: all
+Version: 3.134
+`
+	pkgs, err := ReadStatus(strings.NewReader(status))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprintf("%+v", pkgs)
+	wanted := "[{Name:adduser Version:3.134 Architecture:all Source:adduser " +
+		"SourceVersion:3.134} {Name:libfoo1 Version:1.2-3+b1 Architecture:amd64 " +
+		"Source:foo SourceVersion:1.2-3}]"
+	if got != wanted {
+		t.Fatalf("unexpected packages:\n%s\n!=\n%s", got, wanted)
+	}
+}
+
+func TestSPDXExpression(t *testing.T) {
+	tests := map[string]string{
+		"Expat":                          "MIT",
+		"GPL-2+":                         "GPL-2.0-or-later",
+		"GPL-2":                          "GPL-2.0-only",
+		"GPLv3+":                         "GPL-3.0-or-later",
+		"LGPL-2.1+":                      "LGPL-2.1-or-later",
+		"GPL":                            "GPL-1.0-or-later",
+		"BSD-3-clause":                   "BSD-3-Clause",
+		"Apache-2.0":                     "Apache-2.0",
+		"CC0-1.0":                        "CC0-1.0",
+		"MPL-2":                          "MPL-2.0",
+		"public-domain":                  "LicenseRef-public-domain",
+		"OpenLDAP-2.8":                   "OpenLDAP-2.8",
+		"GPL-1+ or Artistic":             "GPL-1.0-or-later OR Artistic",
+		"LGPL-3+, or GPL-2+":             "LGPL-3.0-or-later OR GPL-2.0-or-later",
+		"GPL-2+ with Autoconf exception": "GPL-2.0-or-later WITH Autoconf-exception",
+	}
+	for input, expected := range tests {
+		got := SPDXExpression(input)
+		if got != expected {
+			t.Errorf("%q: expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestReadCopyrightLicenses(t *testing.T) {
+	copyright := `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: foo
+
+Files: *
+Copyright: 2020 Foo
+License: GPL-2+
+
+Files: lib/*
+Copyright: 2020 Bar
+License: Expat
+
+Files: debian/*
+Copyright: 2021 Baz
+License: GPL-2+
+
+License: GPL-2+
+ This program is free software.
+
+License: Expat
+ Permission is hereby granted.
+`
+	got := ReadCopyrightLicenses([]byte(copyright))
+	expected := []string{"GPL-2.0-or-later", "MIT"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+	if got := declaredLicense(got); got != "GPL-2.0-or-later AND MIT" {
+		t.Fatalf("unexpected declared license: %q", got)
+	}
+	if got := ReadCopyrightLicenses([]byte("This is free software.\n")); got != nil {
+		t.Fatalf("expected nil for free-form file, got %q", got)
+	}
+}
+
+func TestFindOwners(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-licenses-deb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "var", "lib", "dpkg", "info")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lists := map[string]string{
+		"libssl-dev:amd64.list": "/.\n/usr\n/usr/lib/x86_64-linux-gnu/libssl.so\n",
+		"zlib1g-dev:amd64.list": "/usr/lib/x86_64-linux-gnu/pkgconfig/zlib.pc\n",
+		"libc6-dev.list":        "/lib/x86_64-linux-gnu/libm.a\n",
+	}
+	for name, content := range lists {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	owners, err := FindOwners(root, []string{
+		"/usr/lib/x86_64-linux-gnu/libssl.so",
+		"/usr/lib/x86_64-linux-gnu/pkgconfig/zlib.pc",
+		"/usr/lib/x86_64-linux-gnu/libm.a",
+		"/usr/lib/x86_64-linux-gnu/libxml2.so",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := map[string]string{
+		"/usr/lib/x86_64-linux-gnu/libssl.so":         "libssl-dev",
+		"/usr/lib/x86_64-linux-gnu/pkgconfig/zlib.pc": "zlib1g-dev",
+		"/usr/lib/x86_64-linux-gnu/libm.a":            "libc6-dev",
+	}
+	if !reflect.DeepEqual(owners, wanted) {
+		t.Fatalf("unexpected owners: %v != %v", owners, wanted)
+	}
+}
@@ -0,0 +1,158 @@
+package deb
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// debianNames maps lowercased Debian license short names, without version,
+// to SPDX identifiers.
+var debianNames = map[string]string{
+	"apache":        "Apache",
+	"artistic":      "Artistic",
+	"bsd-2-clause":  "BSD-2-Clause",
+	"bsd-3-clause":  "BSD-3-Clause",
+	"bsd-4-clause":  "BSD-4-Clause",
+	"cc-by":         "CC-BY",
+	"cc-by-nc":      "CC-BY-NC",
+	"cc-by-nc-nd":   "CC-BY-NC-ND",
+	"cc-by-nc-sa":   "CC-BY-NC-SA",
+	"cc-by-nd":      "CC-BY-ND",
+	"cc-by-sa":      "CC-BY-SA",
+	"cc0":           "CC0",
+	"cddl":          "CDDL",
+	"cpl":           "CPL",
+	"efl":           "EFL",
+	"epl":           "EPL",
+	"expat":         "MIT",
+	"isc":           "ISC",
+	"lppl":          "LPPL",
+	"mit":           "MIT",
+	"mpl":           "MPL",
+	"ofl":           "OFL",
+	"public-domain": "LicenseRef-public-domain",
+	"python":        "PSF",
+	"qpl":           "QPL",
+	"w3c":           "W3C",
+	"x11":           "X11",
+	"zlib":          "Zlib",
+	"zope":          "ZPL",
+}
+
+// gnuNames are the licenses whose SPDX identifiers end with "-only" or
+// "-or-later".
+var gnuNames = map[string]string{
+	"agpl": "AGPL",
+	"gfdl": "GFDL",
+	"gpl":  "GPL",
+	"lgpl": "LGPL",
+}
+
+// reShortName splits a Debian short name into its name, version and "+"
+// suffix, like "GPL", "2" and "+" for "GPL-2+". "GPLv2+" is accepted too.
+var reShortName = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*?)(?:-?v?(\d+(?:\.\d+)*))?(\+)?$`)
+
+// SPDXName returns the SPDX identifier of a Debian license short name, or
+// the name itself if it is unknown. Major-only versions are completed, so
+// "GPL-2+" becomes "GPL-2.0-or-later" and "Expat" becomes "MIT".
+func SPDXName(name string) string {
+	m := reShortName.FindStringSubmatch(name)
+	if m == nil {
+		return name
+	}
+	base, version, plus := strings.ToLower(m[1]), m[2], m[3] != ""
+	if id, ok := gnuNames[base]; ok {
+		if version == "" {
+			// Unversioned GPL means any version.
+			version, plus = "1", true
+		}
+		if !strings.Contains(version, ".") {
+			version += ".0"
+		}
+		if plus {
+			return id + "-" + version + "-or-later"
+		}
+		return id + "-" + version + "-only"
+	}
+	id, ok := debianNames[base]
+	if !ok {
+		return name
+	}
+	if version != "" {
+		if !strings.Contains(version, ".") {
+			version += ".0"
+		}
+		id += "-" + version
+	}
+	if plus {
+		id += "+"
+	}
+	return id
+}
+
+// SPDXExpression converts a Debian license field short name expression, like
+// "GPL-2+ or Artistic", to an SPDX expression.
+func SPDXExpression(expr string) string {
+	words := strings.Fields(strings.Replace(expr, ",", " ", -1))
+	parts := []string{}
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		switch strings.ToLower(w) {
+		case "or", "and":
+			parts = append(parts, strings.ToUpper(w))
+		case "with":
+			// "with Autoconf exception" becomes "WITH Autoconf-exception".
+			exception := strings.Join(words[i+1:], "-")
+			parts = append(parts, "WITH", exception)
+			i = len(words)
+		default:
+			parts = append(parts, SPDXName(w))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ReadCopyrightLicenses returns the distinct license short names of a
+// machine-readable copyright file, converted to SPDX expressions, in order of
+// appearance. It returns nil if the file is not machine-readable.
+func ReadCopyrightLicenses(data []byte) []string {
+	if !bytes.HasPrefix(data, []byte("Format:")) {
+		return nil
+	}
+	seen := map[string]bool{}
+	exprs := []string{}
+	files := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			files = false
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key, value := line[:i], strings.TrimSpace(line[i+1:])
+		if key == "Files" {
+			files = true
+		}
+		// Stand-alone License paragraphs only hold the text of licenses
+		// referenced by Files paragraphs.
+		if key != "License" || !files || value == "" {
+			continue
+		}
+		expr := SPDXExpression(value)
+		if !seen[expr] {
+			seen[expr] = true
+			exprs = append(exprs, expr)
+		}
+	}
+	return exprs
+}
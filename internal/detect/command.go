@@ -0,0 +1,82 @@
+package detect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+// Command is a detector running an external program, like a wrapper of
+// another license scanner, with the module directory as last argument. The
+// module path, version and license file are passed in the GOLICENSES_MODULE,
+// GOLICENSES_VERSION and GOLICENSES_LICENSE_FILE environment variables. The
+// program writes a JSON array of candidates to its standard output, like:
+//
+//	[{"license": "MIT", "score": 0.98, "path": "LICENSE"}]
+//
+// Licenses designating a template by SPDX identifier, title or nickname are
+// reported as matches of the template, others as declared licenses. Paths
+// are relative to the module directory.
+type Command struct {
+	Args []string
+}
+
+// commandCandidate is a candidate written by a Command.
+type commandCandidate struct {
+	License string  `json:"license"`
+	Score   float64 `json:"score"`
+	Path    string  `json:"path,omitempty"`
+}
+
+// Detect runs the command on mod.
+func (c *Command) Detect(ctx context.Context, mod Module,
+	templates []*matcher.Template) ([]Candidate, error) {
+
+	if len(c.Args) == 0 {
+		return nil, fmt.Errorf("detector command is empty")
+	}
+	cmd := exec.CommandContext(ctx, c.Args[0], append(c.Args[1:], mod.Dir)...)
+	cmd.Env = append(os.Environ(),
+		"GOLICENSES_MODULE="+mod.Path,
+		"GOLICENSES_VERSION="+mod.Version,
+		"GOLICENSES_LICENSE_FILE="+mod.License)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %s: %s", c.Args[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+	written := []commandCandidate{}
+	err = json.Unmarshal(stdout.Bytes(), &written)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s output: %s", c.Args[0], err)
+	}
+	candidates := []Candidate{}
+	for _, w := range written {
+		if w.License == "" {
+			continue
+		}
+		candidate := Candidate{Declared: w.License, Score: w.Score, Path: w.Path}
+		if w.Path != "" && !filepath.IsAbs(w.Path) {
+			candidate.Path = filepath.Join(mod.Dir, filepath.FromSlash(w.Path))
+		}
+		for _, t := range templates {
+			if t.MatchesName(w.License) {
+				candidate.Template, candidate.Declared = t, ""
+				break
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
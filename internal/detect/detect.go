@@ -0,0 +1,131 @@
+// Package detect defines the interface of license detectors complementing
+// the built-in word matcher, like wrappers of other license scanners, and
+// registers them.
+//
+// Detectors are compiled in by files registering them in their init function,
+// usually behind a build tag, or run as external commands declared in the
+// configuration file, see Command.
+package detect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+// Module is a module whose license is detected.
+type Module struct {
+	Path    string
+	Version string
+	// Dir is the module directory.
+	Dir string
+	// License is the path of the license file found by go-licenses, if any.
+	License string
+}
+
+// Candidate is a license found by a detector.
+type Candidate struct {
+	// Template is the license template, if the license is one of them.
+	Template *matcher.Template
+	// Declared is the license name or SPDX expression otherwise.
+	Declared string
+	// Score is the confidence of the detector, between 0 and 1.
+	Score float64
+	// Path is the license file, if known.
+	Path string
+}
+
+// Detector detects the licenses of modules.
+type Detector interface {
+	// Detect returns the license candidates of mod, if any. templates are
+	// the license templates of the scan, candidates should point to them
+	// when they designate one.
+	Detect(ctx context.Context, mod Module, templates []*matcher.Template) ([]Candidate, error)
+}
+
+// Entry is a named detector and its priority.
+type Entry struct {
+	Name string
+	// Priority orders detectors: the candidates of the detector with the
+	// highest priority returning any are kept. The built-in word matcher has
+	// priority 0, so detectors with a negative priority only complement it
+	// for licenses it could not match.
+	Priority int
+	Detector Detector
+}
+
+var (
+	mu       sync.Mutex
+	registry = map[string]Entry{}
+)
+
+// Register makes the detector available under name, with its default
+// priority. It panics if name is already registered.
+func Register(name string, priority int, d Detector) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok || name == WordsName {
+		panic(fmt.Sprintf("detector %s registered twice", name))
+	}
+	registry[name] = Entry{Name: name, Priority: priority, Detector: d}
+}
+
+// Registered returns the registered detectors, sorted with Sort.
+func Registered() []Entry {
+	mu.Lock()
+	defer mu.Unlock()
+	entries := []Entry{}
+	for _, e := range registry {
+		entries = append(entries, e)
+	}
+	Sort(entries)
+	return entries
+}
+
+// Sort sorts entries by decreasing priority, then by name.
+func Sort(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Priority != entries[j].Priority {
+			return entries[i].Priority > entries[j].Priority
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// WordsName is the name of the built-in word matcher in results.
+const WordsName = "words"
+
+// Result holds the candidates returned by a detector.
+type Result struct {
+	Entry      Entry
+	Candidates []Candidate
+}
+
+// Best returns the best scoring candidate of the highest priority result
+// holding any, and the name of its detector. Results of the built-in word
+// matcher, at priority 0, only count if they hold a template.
+func Best(results []Result) (Candidate, string, bool) {
+	sorted := append([]Result{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Entry.Priority > sorted[j].Entry.Priority
+	})
+	for _, r := range sorted {
+		found := false
+		best := Candidate{}
+		for _, c := range r.Candidates {
+			if r.Entry.Name == WordsName && c.Template == nil {
+				continue
+			}
+			if !found || c.Score > best.Score {
+				best, found = c, true
+			}
+		}
+		if found {
+			return best, r.Entry.Name, true
+		}
+	}
+	return Candidate{}, "", false
+}
@@ -0,0 +1,66 @@
+package detect
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+func TestBest(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	words := Result{Entry: Entry{Name: WordsName}}
+	low := Result{
+		Entry:      Entry{Name: "low", Priority: -1},
+		Candidates: []Candidate{{Declared: "BSD", Score: 0.5}, {Declared: "ISC", Score: 0.8}},
+	}
+	high := Result{Entry: Entry{Name: "high", Priority: 1}}
+	c, name, ok := Best([]Result{words, low, high})
+	if !ok || name != "low" || c.Declared != "ISC" {
+		t.Fatalf("unexpected best candidate of %s: %+v", name, c)
+	}
+	words.Candidates = []Candidate{{Template: mit, Score: 0.6}}
+	c, name, ok = Best([]Result{words, low, high})
+	if !ok || name != WordsName || c.Template != mit {
+		t.Fatalf("unexpected best candidate of %s: %+v", name, c)
+	}
+	high.Candidates = []Candidate{{Declared: "Apache-2.0", Score: 0.1}}
+	c, name, ok = Best([]Result{words, low, high})
+	if !ok || name != "high" || c.Declared != "Apache-2.0" {
+		t.Fatalf("unexpected best candidate of %s: %+v", name, c)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell")
+	}
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "detector")
+	err = ioutil.WriteFile(script, []byte("#!/bin/sh\n"+
+		`echo "[{\"license\": \"MIT\", \"score\": 0.9, \"path\": \"COPYING\"},`+
+		` {\"license\": \"$GOLICENSES_MODULE\", \"score\": 0.1}]"`+"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	c := &Command{Args: []string{script}}
+	candidates, err := c.Detect(context.Background(),
+		Module{Path: "example.com/a", Dir: dir}, []*matcher.Template{mit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 || candidates[0].Template != mit ||
+		candidates[0].Path != filepath.Join(dir, "COPYING") ||
+		candidates[1].Declared != "example.com/a" {
+		t.Fatalf("unexpected candidates: %+v", candidates)
+	}
+}
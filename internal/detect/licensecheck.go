@@ -0,0 +1,56 @@
+//go:build licensecheck
+// +build licensecheck
+
+package detect
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/google/licensecheck"
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+// LicensecheckName is the name of the detector delegating matching to the
+// google/licensecheck library, compiled in with the licensecheck build tag.
+const LicensecheckName = "licensecheck"
+
+func init() {
+	Register(LicensecheckName, -1, licensecheckDetector{})
+}
+
+// licensecheckDetector scans the license file of modules with
+// licensecheck.Scan.
+type licensecheckDetector struct{}
+
+// Detect scans the license file of mod. Modules without license file have no
+// candidates.
+func (licensecheckDetector) Detect(ctx context.Context, mod Module,
+	templates []*matcher.Template) ([]Candidate, error) {
+
+	if mod.License == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(mod.License)
+	if err != nil {
+		return nil, err
+	}
+	cov := licensecheck.Scan(data)
+	candidates := []Candidate{}
+	seen := map[string]bool{}
+	for _, m := range cov.Match {
+		if m.IsURL || seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		candidate := Candidate{Declared: m.ID, Score: cov.Percent / 100, Path: mod.License}
+		for _, t := range templates {
+			if t.MatchesName(m.ID) {
+				candidate.Template, candidate.Declared = t, ""
+				break
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,231 @@
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// matchPrefixPatterns returns true if the module path target, or one of its
+// path prefixes, matches one of the comma-separated glob patterns, like the
+// go command does with GOPRIVATE and GONOPROXY.
+func matchPrefixPatterns(patterns, target string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSuffix(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		n := strings.Count(pattern, "/")
+		prefix := target
+		for i := 0; i < len(target); i++ {
+			if target[i] == '/' {
+				if n == 0 {
+					prefix = target[:i]
+					break
+				}
+				n--
+			}
+		}
+		if n > 0 {
+			continue
+		}
+		if ok, _ := path.Match(pattern, prefix); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// netrcEntry is a machine entry of a .netrc file.
+type netrcEntry struct {
+	Machine  string
+	Login    string
+	Password string
+}
+
+// parseNetrc parses the machine entries of a .netrc file. Like the go
+// command, it stops at the default entry, whose credentials would be sent to
+// any host, and at macro definitions.
+func parseNetrc(data []byte) []netrcEntry {
+	entries := []netrcEntry{}
+	var e *netrcEntry
+	fields := strings.Fields(string(data))
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if e != nil {
+				entries = append(entries, *e)
+			}
+			e = &netrcEntry{}
+			if i+1 < len(fields) {
+				i++
+				e.Machine = fields[i]
+			}
+		case "default", "macdef":
+			i = len(fields)
+		case "login", "password":
+			if e != nil && i+1 < len(fields) {
+				if fields[i] == "login" {
+					e.Login = fields[i+1]
+				} else {
+					e.Password = fields[i+1]
+				}
+			}
+			i++
+		}
+	}
+	if e != nil {
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+// netrcPath returns the path of the .netrc file of the user: NETRC if set,
+// or .netrc in the home directory, _netrc on Windows.
+func netrcPath() string {
+	if p := os.Getenv("NETRC"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// credentialSet holds the headers a GOAUTH command attaches to the requests
+// of URLs starting with one of its prefixes.
+type credentialSet struct {
+	Prefixes []string
+	Header   http.Header
+}
+
+// parseCredentials parses the output of a GOAUTH command: credential sets
+// made of URL lines, a blank line, header lines and a blank line.
+func parseCredentials(data []byte) []credentialSet {
+	sets := []credentialSet{}
+	current := credentialSet{Header: http.Header{}}
+	headers := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == "" && !headers:
+			headers = len(current.Prefixes) > 0
+		case line == "":
+			sets = append(sets, current)
+			current, headers = credentialSet{Header: http.Header{}}, false
+		case !headers:
+			current.Prefixes = append(current.Prefixes, line)
+		default:
+			if i := strings.Index(line, ":"); i > 0 {
+				current.Header.Add(strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]))
+			}
+		}
+	}
+	if headers && len(current.Header) > 0 {
+		sets = append(sets, current)
+	}
+	return sets
+}
+
+// proxyAuth authenticates module proxy requests.
+type proxyAuth struct {
+	// Token is sent as a bearer token to the proxies of TokenHosts.
+	Token      string
+	TokenHosts []string
+	// Credentials are the headers of GOAUTH commands.
+	Credentials []credentialSet
+	// Netrc holds the .netrc entries, unless GOAUTH disables them.
+	Netrc []netrcEntry
+}
+
+// loadProxyAuth returns the authentication of module proxy requests set by
+// goauth, the GOAUTH value of the go command: the credentials of the .netrc
+// file, by default, and the headers printed by commands. "git dir" entries
+// are not supported, and commands are only run once, not again on
+// authentication failures. token, if set, is sent to tokenURLs and takes
+// precedence.
+func loadProxyAuth(ctx context.Context, goauth, token string, tokenURLs []string) *proxyAuth {
+	a := &proxyAuth{Token: token}
+	if token != "" {
+		for _, u := range tokenURLs {
+			if parsed, err := url.Parse(u); err == nil && parsed.Host != "" {
+				a.TokenHosts = append(a.TokenHosts, parsed.Host)
+			}
+		}
+	}
+	if strings.TrimSpace(goauth) == "" {
+		goauth = "netrc"
+	}
+	for _, method := range strings.Split(goauth, ";") {
+		args := strings.Fields(method)
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "off":
+			return &proxyAuth{Token: a.Token, TokenHosts: a.TokenHosts}
+		case "netrc":
+			if data, err := ioutil.ReadFile(netrcPath()); err == nil {
+				a.Netrc = append(a.Netrc, parseNetrc(data)...)
+			}
+		case "git":
+			// Git credential helpers are not supported.
+		default:
+			cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+			if out, err := cmd.Output(); err == nil {
+				a.Credentials = append(a.Credentials, parseCredentials(out)...)
+			}
+		}
+	}
+	return a
+}
+
+// apply sets the credentials of HTTPS requests, never sent in clear text: the
+// token for the host of req, or the headers of the longest matching GOAUTH
+// prefix, or the basic authentication of the .netrc entry of its host.
+func (a *proxyAuth) apply(req *http.Request) {
+	if a == nil || req.URL.Scheme != "https" {
+		return
+	}
+	for _, host := range a.TokenHosts {
+		if host == req.URL.Host {
+			req.Header.Set("Authorization", "Bearer "+a.Token)
+			return
+		}
+	}
+	var best *credentialSet
+	bestLen := -1
+	for i, set := range a.Credentials {
+		for _, prefix := range set.Prefixes {
+			if strings.HasPrefix(req.URL.String(), prefix) && len(prefix) > bestLen {
+				best, bestLen = &a.Credentials[i], len(prefix)
+			}
+		}
+	}
+	if best != nil {
+		for k, v := range best.Header {
+			req.Header[k] = v
+		}
+		return
+	}
+	for _, e := range a.Netrc {
+		if e.Machine == req.URL.Hostname() {
+			req.SetBasicAuth(e.Login, e.Password)
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gomod
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchPrefixPatterns(t *testing.T) {
+	tests := []struct {
+		target string
+		wanted bool
+	}{
+		{"corp.example.com", true},
+		{"corp.example.com/team/repo", true},
+		{"github.com/acme/private", true},
+		{"github.com/acme/private/v2", true},
+		{"github.com/acme", false},
+		{"github.com/acme/public", false},
+		{"golang.org/x/text", false},
+	}
+	patterns := "corp.example.com, github.com/acme/priv*/"
+	for _, test := range tests {
+		if got := matchPrefixPatterns(patterns, test.target); got != test.wanted {
+			t.Errorf("unexpected match of %s: %v", test.target, got)
+		}
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	entries := parseNetrc([]byte(`machine proxy.example.com login alice password secret
+machine other.example.com
+	login bob
+	password hunter2
+default login anonymous password guest
+machine ignored.example.com login eve password eve
+`))
+	if len(entries) != 2 || entries[0] != (netrcEntry{"proxy.example.com", "alice", "secret"}) ||
+		entries[1] != (netrcEntry{"other.example.com", "bob", "hunter2"}) {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestProxyAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	netrc := filepath.Join(dir, "netrc")
+	err = ioutil.WriteFile(netrc, []byte("machine netrc.example.com login alice password secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NETRC", netrc)
+	command := filepath.Join(dir, "auth.sh")
+	err = ioutil.WriteFile(command, []byte(`#!/bin/sh
+printf 'https://command.example.com/\nhttps://command.example.com/private/\n\n'
+printf 'Authorization: Bearer command-token\n\n'
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		goauth string
+		url    string
+		wanted string
+	}{
+		{"", "https://netrc.example.com/a/@v/v1.0.0.zip", "Basic YWxpY2U6c2VjcmV0"},
+		{"", "http://netrc.example.com/a/@v/v1.0.0.zip", ""},
+		{"", "https://proxy.golang.org/a/@v/v1.0.0.zip", ""},
+		{"off", "https://netrc.example.com/a/@v/v1.0.0.zip", ""},
+		{"netrc;" + command, "https://command.example.com/private/a", "Bearer command-token"},
+		{"netrc;" + command, "https://netrc.example.com/a", "Basic YWxpY2U6c2VjcmV0"},
+		{"off", "https://token.example.com/artifactory/api/go/a", "Bearer token"},
+		{"off", "http://token.example.com/artifactory/api/go/a", ""},
+	}
+	for _, test := range tests {
+		a := loadProxyAuth(context.Background(), test.goauth, "token",
+			[]string{"https://token.example.com/artifactory/api/go",
+				"http://token.example.com/artifactory/api/go"})
+		req, err := http.NewRequest("GET", test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.apply(req)
+		if got := req.Header.Get("Authorization"); got != test.wanted {
+			t.Errorf("unexpected authorization of %s with GOAUTH=%q: %q", test.url,
+				test.goauth, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package gomod
+
+import (
+	"context"
+	"debug/buildinfo"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// escapePath escapes a module path like the module cache does, replacing
+// uppercase letters with an exclamation mark followed by their lowercase.
+func escapePath(path string) string {
+	b := strings.Builder{}
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ModuleCacheDir returns the directory of the module cache.
+func ModuleCacheDir(ctx context.Context) (string, error) {
+	b, err := runGo(ctx, nil, "env", "GOMODCACHE")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// readBinaryModules returns the dependencies recorded in the build
+// information of the Go binary at path. Replaced modules carry their
+// replacement.
+func readBinaryModules(path string) ([]*modinfo.ModulePublic, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mods := []*modinfo.ModulePublic{}
+	for _, dep := range info.Deps {
+		mod := &modinfo.ModulePublic{
+			Path:    dep.Path,
+			Version: dep.Version,
+		}
+		if dep.Replace != nil {
+			mod.Replace = &modinfo.ModulePublic{
+				Path:    dep.Replace.Path,
+				Version: dep.Replace.Version,
+			}
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
+
+// IsBinary returns true if the file at path is a Go binary carrying build
+// information.
+func IsBinary(path string) bool {
+	_, err := buildinfo.ReadFile(path)
+	return err == nil
+}
+
+// ListBinaryLicenses returns the licenses of the modules linked in supplied
+// Go binaries, as recorded in their build information. Module sources are
+// looked up in the module cache. Modules missing from it are reported with
+// an error.
+func ListBinaryLicenses(paths []string) ([]report.License, error) {
+	seen := map[string]bool{}
+	mods := []*modinfo.ModulePublic{}
+	for _, path := range paths {
+		binMods, err := readBinaryModules(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s build information: %s",
+				path, err)
+		}
+		for _, mod := range binMods {
+			key := mod.Path + "@" + mod.Version
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			mods = append(mods, mod)
+		}
+	}
+	return ScanModules(context.Background(), mods, &Options{})
+}
@@ -0,0 +1,135 @@
+package gomod
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CheckModule returns an error if path is not a valid module path or version
+// not a valid semantic version, following the rules of the go command. Module
+// versions read from requests or files must pass it before their path and
+// version are joined into module cache or proxy paths.
+func CheckModule(path, version string) error {
+	if err := checkModulePath(path); err != nil {
+		return fmt.Errorf("invalid module path %q: %s", path, err)
+	}
+	if !validVersion(version) {
+		return fmt.Errorf("invalid version %q of module %s", version, path)
+	}
+	return nil
+}
+
+// checkModulePath checks path like module.CheckPath of golang.org/x/mod:
+// slash-separated elements made of ASCII letters, digits and "-._~", neither
+// empty nor starting or ending with a dot, the first one holding a dot and
+// no uppercase letter.
+func checkModulePath(path string) error {
+	if path == "" {
+		return fmt.Errorf("empty path")
+	}
+	elems := strings.Split(path, "/")
+	for i, elem := range elems {
+		if elem == "" {
+			return fmt.Errorf("empty path element")
+		}
+		if elem[0] == '.' || elem[len(elem)-1] == '.' {
+			return fmt.Errorf("path element %q starts or ends with a dot", elem)
+		}
+		for _, r := range elem {
+			if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+				strings.ContainsRune("-._~", r)) {
+				return fmt.Errorf("invalid character %q", r)
+			}
+		}
+		if i == 0 {
+			if !strings.Contains(elem, ".") {
+				return fmt.Errorf("missing dot in first path element")
+			}
+			if elem[0] == '-' {
+				return fmt.Errorf("leading dash in first path element")
+			}
+			if strings.ToLower(elem) != elem {
+				return fmt.Errorf("uppercase letter in first path element")
+			}
+		}
+	}
+	return nil
+}
+
+// validVersion returns true if v is a semantic version like semver.IsValid
+// of golang.org/x/mod: "v" followed by a major, minor and patch number,
+// optionally a pre-release and build metadata, or by a major and optional
+// minor number alone.
+func validVersion(v string) bool {
+	if len(v) < 2 || v[0] != 'v' {
+		return false
+	}
+	v = v[1:]
+	suffixed := false
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		if !validIdentifiers(v[i+1:], false) {
+			return false
+		}
+		v, suffixed = v[:i], true
+	}
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		if !validIdentifiers(v[i+1:], true) {
+			return false
+		}
+		v, suffixed = v[:i], true
+	}
+	nums := strings.Split(v, ".")
+	if len(nums) > 3 || suffixed && len(nums) != 3 {
+		return false
+	}
+	for _, n := range nums {
+		if !validNumber(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// validNumber returns true if s is a decimal number without leading zero.
+func validNumber(s string) bool {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validIdentifiers returns true if s holds dot-separated pre-release or build
+// identifiers. Numeric pre-release identifiers have no leading zero.
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		numeric := true
+		for _, r := range id {
+			if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+				r == '-') {
+				return false
+			}
+			numeric = numeric && '0' <= r && r <= '9'
+		}
+		if prerelease && numeric && !validNumber(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// insideDir returns true if path is dir or one of its descendants, once
+// both are cleaned.
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
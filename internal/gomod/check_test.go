@@ -0,0 +1,62 @@
+package gomod
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckModule(t *testing.T) {
+	tests := []struct {
+		path    string
+		version string
+		valid   bool
+	}{
+		{"github.com/pkg/errors", "v0.9.1", true},
+		{"example.com/a/v2", "v2.0.0-rc.1+incompatible", true},
+		{"golang.org/x/text", "v0.0.0-20170915032832-14c0d48ead0c", true},
+		{"gopkg.in/yaml.v2", "v2", true},
+		{"example.com/Foo_bar~", "v1.2", true},
+		{"x", "v1.0.0", false},
+		{"Example.com/a", "v1.0.0", false},
+		{"example.com/../a", "v1.0.0", false},
+		{"example.com//a", "v1.0.0", false},
+		{"example.com/a/", "v1.0.0", false},
+		{"/example.com/a", "v1.0.0", false},
+		{"example.com/a b", "v1.0.0", false},
+		{"example.com/a@v1", "v1.0.0", false},
+		{"example.com/a", "", false},
+		{"example.com/a", "1.0.0", false},
+		{"example.com/a", "v1.0.0/../../../root/module", false},
+		{"example.com/a", "v1.0.01", false},
+		{"example.com/a", "v1.0-pre", false},
+		{"example.com/a", "v1.0.0-01", false},
+		{"example.com/a", "v1.0.0+", false},
+		{"example.com/a", "latest", false},
+	}
+	for _, test := range tests {
+		err := CheckModule(test.path, test.version)
+		if (err == nil) != test.valid {
+			t.Errorf("%s@%s: unexpected result: %v", test.path, test.version, err)
+		}
+	}
+}
+
+func TestInsideDir(t *testing.T) {
+	dir := filepath.FromSlash("/cache/mod")
+	tests := []struct {
+		path   string
+		inside bool
+	}{
+		{"/cache/mod", true},
+		{"/cache/mod/example.com/a@v1.0.0", true},
+		{"/cache/mod/..foo", true},
+		{"/cache/mod/../../root/module", false},
+		{"/cache/modules", false},
+		{"/root", false},
+	}
+	for _, test := range tests {
+		if got := insideDir(dir, filepath.FromSlash(test.path)); got != test.inside {
+			t.Errorf("%s: unexpected result: %t", test.path, got)
+		}
+	}
+}
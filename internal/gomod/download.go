@@ -0,0 +1,149 @@
+package gomod
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+const (
+	// DefaultDownloadJobs is the default number of modules downloaded or
+	// fetched from module proxies at once.
+	DefaultDownloadJobs = 8
+	// DefaultRetries is the default number of retries of module downloads
+	// failing with transient errors.
+	DefaultRetries = 3
+)
+
+// retryDelay is the delay before the first retry of failed downloads, doubled
+// on each retry.
+var retryDelay = time.Second
+
+// downloadJobs returns the number of modules downloaded at once.
+func (o *Options) downloadJobs() int {
+	if o == nil || o.DownloadJobs <= 0 {
+		return DefaultDownloadJobs
+	}
+	return o.DownloadJobs
+}
+
+// retries returns the number of retries of failed downloads.
+func (o *Options) retries() int {
+	if o == nil || o.Retries == 0 {
+		return DefaultRetries
+	}
+	if o.Retries < 0 {
+		return 0
+	}
+	return o.Retries
+}
+
+// retry calls fn until it succeeds, fails with an error it does not deem
+// retryable, or was retried retries times, waiting retryDelay before the
+// first retry and twice as long before each next one. It returns the last
+// error of fn, or the one of ctx if it is done while waiting.
+func retry(ctx context.Context, retries int, fn func() (bool, error)) error {
+	delay := retryDelay
+	for i := 0; ; i++ {
+		retryable, err := fn()
+		if err == nil || !retryable || i >= retries || ctx.Err() != nil {
+			return err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
+
+// reTransient matches the go command errors worth retrying: network
+// failures and overloaded servers, as opposed to missing modules.
+var reTransient = regexp.MustCompile(`(?i)timeout|timed out|connection (?:reset|refused)|` +
+	`temporary failure|tls handshake|unexpected eof|too many requests|` +
+	`\b(?:429|500|502|503|504)\b`)
+
+// isTransient returns true if err, returned by a go command, looks like a
+// transient failure.
+func isTransient(err error) bool {
+	return reTransient.MatchString(err.Error())
+}
+
+// forEachModule calls fn on mods, at most jobs at once. It returns the error
+// of ctx if it is done before all modules are processed.
+func forEachModule(ctx context.Context, mods []*modinfo.ModulePublic, jobs int,
+	fn func(mod *modinfo.ModulePublic)) error {
+
+	queue := make(chan *modinfo.ModulePublic)
+	wg := sync.WaitGroup{}
+	for i := 0; i < jobs && i < len(mods); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mod := range queue {
+				fn(mod)
+			}
+		}()
+	}
+	var err error
+	for _, mod := range mods {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		queue <- mod
+	}
+	close(queue)
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// fetchModules fetches the modules of mods missing from the module cache, in
+// parallel: their license files from module proxies with opts.Proxy, or the
+// whole modules with opts.Download. Failures are recorded in the module
+// errors. Nothing is fetched with opts.Offline.
+func fetchModules(ctx context.Context, env []string, mods []*modinfo.ModulePublic,
+	opts *Options) error {
+
+	if opts == nil || opts.Offline || (!opts.Proxy && !opts.Download) {
+		return nil
+	}
+	missing := []*modinfo.ModulePublic{}
+	for _, mod := range mods {
+		if mod.Dir == "" {
+			missing = append(missing, mod)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	start := time.Now()
+	fetch := func(mod *modinfo.ModulePublic) {
+		downloadModule(ctx, env, mod, opts)
+	}
+	if opts.Proxy {
+		proxies, err := loadProxyConfig(ctx, env, opts)
+		if err != nil {
+			return err
+		}
+		cacheDir, err := proxyCacheDir()
+		if err != nil {
+			return err
+		}
+		fetch = func(mod *modinfo.ModulePublic) {
+			fetchModuleLicenses(ctx, env, proxies, cacheDir, mod, opts)
+		}
+	}
+	err := forEachModule(ctx, missing, opts.downloadJobs(), fetch)
+	opts.logger().Info("fetched missing modules", "count", len(missing),
+		"elapsed", time.Since(start))
+	return err
+}
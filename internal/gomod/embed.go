@@ -0,0 +1,141 @@
+package gomod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// embedPackage is the subset of "go list -json" package output describing
+// the files embedded with go:embed directives.
+type embedPackage struct {
+	ImportPath string
+	Dir        string
+	Module     *modinfo.ModulePublic
+	EmbedFiles []string
+}
+
+// listEmbedPackages returns the packages among pkgs and their dependencies
+// embedding files.
+func listEmbedPackages(ctx context.Context, env []string, pkgs []string) ([]embedPackage, error) {
+	b, err := runGoBatches(ctx, env, []string{"list", "-deps", "-json"}, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(b)
+	embeds := []embedPackage{}
+	for {
+		var pkg embedPackage
+		if err := dec.Decode(&pkg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("json decode: %s", err)
+		}
+		if len(pkg.EmbedFiles) > 0 && pkg.Module != nil && pkg.Module.Dir != "" {
+			embeds = append(embeds, pkg)
+		}
+	}
+	return embeds, nil
+}
+
+// embeddedLicenseFiles adds the license files of the files embedded by pkg to
+// files, mapped to the module holding them. The license of an embedded file is
+// the one of the closest directory holding it, below the module directory
+// whose license is the module one.
+func embeddedLicenseFiles(pkg embedPackage, files map[string]*modinfo.ModulePublic,
+	maxSize int64) error {
+
+	root := filepath.Clean(pkg.Module.Dir)
+	seen := map[string]bool{}
+	for _, name := range pkg.EmbedFiles {
+		dir := filepath.Dir(filepath.Join(pkg.Dir, filepath.FromSlash(name)))
+		for strings.HasPrefix(dir, root+string(filepath.Separator)) && !seen[dir] {
+			seen[dir] = true
+			path, err := findLicenseIn(dir, maxSize)
+			if err != nil {
+				return err
+			}
+			if path != "" {
+				files[path] = pkg.Module
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return nil
+}
+
+// embeddedLicenses returns the licenses of the files embedded with go:embed
+// directives by pkgs and their dependencies, like fonts, word lists or model
+// files shipped under their own license. They are reported as sub-components
+// of their module, named after the directory of their license file. Embedded
+// files without license file of their own are covered by the module license.
+func embeddedLicenses(ctx context.Context, env []string, pkgs []string,
+	opts *Options) ([]report.License, error) {
+
+	embeds, err := listEmbedPackages(ctx, env, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*modinfo.ModulePublic{}
+	for _, pkg := range embeds {
+		err := embeddedLicenseFiles(pkg, files, opts.maxLicenseSize())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", pkg.ImportPath, err)
+		}
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	licenses := []report.License{}
+	for path, mod := range files {
+		rel, err := filepath.Rel(mod.Dir, path)
+		if err != nil {
+			return nil, err
+		}
+		license := report.License{
+			Source:  "go",
+			Package: mod.Path + "/" + filepath.ToSlash(filepath.Dir(rel)),
+			Version: mod.Version,
+			Parent:  mod.Path,
+			Path:    path,
+			URL:     licenseURL(mod, filepath.ToSlash(rel)),
+		}
+		notice, err := findNotice(filepath.Dir(path), opts.maxLicenseSize())
+		if err != nil {
+			license.Err = err.Error()
+		}
+		license.Notice = notice
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			license.Err = err.Error()
+		} else {
+			m := matcher.Match(data, templates)
+			license.Score = m.Score
+			license.Template = m.Template
+			license.ExtraWords = m.ExtraWords
+			license.MissingWords = m.MissingWords
+			license.MissingSections = m.MissingSections
+			license.PatentGrant = m.Clauses.PatentGrant
+			license.TrademarkClause = m.Clauses.Trademark
+		}
+		opts.logger().Debug("matched embedded license", "module", mod.Path,
+			"path", path, "license", templateTitle(license.Template), "score", license.Score)
+		licenses = append(licenses, license)
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		return licenses[i].Path < licenses[j].Path
+	})
+	return licenses, nil
+}
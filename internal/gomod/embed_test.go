@@ -0,0 +1,71 @@
+package gomod
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+func TestEmbeddedLicenses(t *testing.T) {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := map[string]string{}
+	for _, templ := range templates {
+		texts[templ.ID] = templ.Text
+	}
+	dir, err := ioutil.TempDir("", "go-licenses-embed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod": "module example.com/main\n\ngo 1.16\n\n" +
+			"require example.com/lib v0.0.0\n\nreplace example.com/lib => ./lib\n",
+		"main.go":                  "package main\n\nimport _ \"example.com/lib\"\n\nfunc main() {}\n",
+		"lib/go.mod":               "module example.com/lib\n\ngo 1.16\n",
+		"lib/LICENSE":              texts["MIT"],
+		"lib/lib.go":               "package lib\n\nimport \"embed\"\n\n//go:embed assets\nvar assets embed.FS\n",
+		"lib/assets/fonts/a.ttf":   "font",
+		"lib/assets/fonts/LICENSE": texts["OFL-1.1"],
+		"lib/assets/words/w.txt":   "words",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	licenses, err := embeddedLicenses(context.Background(), offlineEnv, []string{"./..."},
+		&Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(licenses) != 1 {
+		t.Fatalf("unexpected embedded licenses: %+v", licenses)
+	}
+	l := licenses[0]
+	if l.Package != "example.com/lib/assets/fonts" || l.Parent != "example.com/lib" ||
+		l.Template == nil || l.Template.ID != "OFL-1.1" || l.Score < .99 {
+		t.Fatalf("unexpected embedded license: %+v", l)
+	}
+}
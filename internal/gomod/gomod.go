@@ -0,0 +1,1343 @@
+// Package gomod lists the modules linked by Go packages and detects their
+// licenses.
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/detect"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// GoError reports a failed go command.
+type GoError struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *GoError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("'go %s' failed: %s", strings.Join(e.Args, " "), e.Err)
+	}
+	return fmt.Sprintf("'go %s' failed with:\n%s", strings.Join(e.Args, " "), e.Stderr)
+}
+
+// runGo executes the go tool with supplied arguments and extra environment
+// variables, and returns its standard output. Failures are *GoError. The
+// command is killed when ctx is done, and ctx error returned.
+func runGo(ctx context.Context, env []string, args ...string) (*bytes.Buffer, error) {
+	return runGoIn(ctx, "", env, args...)
+}
+
+// maxArgsLength is the maximum length of the package or module arguments of
+// a go command, keeping command lines below the limits of operating systems,
+// like the 32 KiB of Windows ones.
+var maxArgsLength = 16 << 10
+
+// batchArgs splits args in consecutive batches whose length, separators
+// included, does not exceed max, unless a single argument does. It returns
+// one empty batch without args.
+func batchArgs(args []string, max int) [][]string {
+	batches := [][]string{}
+	start, length := 0, 0
+	for i, arg := range args {
+		if i > start && length+len(arg)+1 > max {
+			batches = append(batches, args[start:i])
+			start, length = i, 0
+		}
+		length += len(arg) + 1
+	}
+	return append(batches, args[start:])
+}
+
+// runGoBatches runs the go command with args followed by batches of pkgs,
+// the packages or modules it applies to, so long lists do not exceed command
+// line length limits, and returns the concatenated standard outputs.
+func runGoBatches(ctx context.Context, env []string, args []string,
+	pkgs []string) (*bytes.Buffer, error) {
+
+	out := &bytes.Buffer{}
+	for _, batch := range batchArgs(pkgs, maxArgsLength) {
+		b, err := runGo(ctx, env, append(append([]string{}, args...), batch...)...)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(b.Bytes())
+	}
+	return out, nil
+}
+
+// runGoIn is runGo run in directory dir, or the current one if empty.
+func runGoIn(ctx context.Context, dir string, env []string, args ...string) (*bytes.Buffer, error) {
+	ctx, cancel := stepContext(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var b bytes.Buffer
+	var berr bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &berr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("go %s: %s", strings.Join(args, " "), ctx.Err())
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		// Packages and modules are only loaded by the go command.
+		err = fmt.Errorf("go command not found in PATH, a Go toolchain is required")
+	}
+	if err != nil {
+		return nil, &GoError{Args: args, Stderr: berr.String(), Err: err}
+	}
+	return &b, nil
+}
+
+// listDependencies returns the modules of the build list by path. With
+// update, their deprecation and retraction notices are looked up too, which
+// requires network access. Modules which cannot be loaded, like those missing
+// from go.sum, are returned with their Error set instead of failing.
+func listDependencies(ctx context.Context, env []string, update bool) (map[string]*modinfo.ModulePublic, error) {
+	args := []string{"list", "-e", "-m", "-json"}
+	if update {
+		args = append(args, "-u")
+	}
+	b, err := runGo(ctx, env, append(args, "all")...)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(b)
+	mods := make(map[string]*modinfo.ModulePublic)
+	for {
+		var mod modinfo.ModulePublic
+		if err := dec.Decode(&mod); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("json decode: %s", err)
+		}
+		mods[mod.Path] = &mod
+	}
+	return mods, nil
+}
+
+func filterLinkedModule(ctx context.Context, mods map[string]*modinfo.ModulePublic,
+	env []string) ([]*modinfo.ModulePublic, error) {
+
+	modules := make([]string, 0, len(mods))
+	for _, mod := range mods {
+		modules = append(modules, mod.Path)
+	}
+	b, err := runGoBatches(ctx, env, []string{"mod", "why", "-m", "-vendor"}, modules)
+	if err != nil {
+		return nil, err
+	}
+
+	var linkedMods []*modinfo.ModulePublic
+	r := bufio.NewReader(b)
+	for {
+		line, _, err := r.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				return nil, fmt.Errorf("read: %s", err)
+			}
+		}
+		if bytes.HasPrefix(line, []byte{'#'}) {
+			path := string(bytes.TrimPrefix(line, []byte("# ")))
+			result, _, err := r.ReadLine()
+			if err != nil {
+				return nil, fmt.Errorf("invalid format: %s", err)
+			}
+			if !bytes.Contains(result, []byte("(main module does not need")) {
+				mod, ok := mods[path]
+				if !ok {
+					return nil, fmt.Errorf("not found: %s", path)
+				}
+				linkedMods = append(linkedMods, mod)
+			}
+		}
+	}
+
+	return linkedMods, nil
+}
+
+// listPackageModules returns the paths of the modules providing pkgs and
+// their dependencies, in order of appearance and possibly repeated. Extra go
+// list flags may be passed.
+func listPackageModules(ctx context.Context, env []string, pkgs []string,
+	flags ...string) ([]string, error) {
+
+	args := append([]string{"list", "-deps", "-f", "{{with .Module}}{{.Path}}{{end}}"},
+		flags...)
+	b, err := runGoBatches(ctx, env, args, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(b.String()), nil
+}
+
+// toolOnlyModules returns the paths of the modules only needed by the tools
+// declared with go.mod tool directives, and not by pkgs or their tests.
+func toolOnlyModules(ctx context.Context, env []string, pkgs []string) (map[string]bool, error) {
+	b, err := runGo(ctx, env, "mod", "edit", "-json")
+	if err != nil {
+		return nil, err
+	}
+	var gomod struct {
+		Tool []struct {
+			Path string
+		}
+	}
+	err = json.Unmarshal(b.Bytes(), &gomod)
+	if err != nil {
+		return nil, fmt.Errorf("json decode: %s", err)
+	}
+	if len(gomod.Tool) == 0 {
+		return nil, nil
+	}
+	tools := []string{}
+	for _, t := range gomod.Tool {
+		tools = append(tools, t.Path)
+	}
+	toolPaths, err := listPackageModules(ctx, env, tools)
+	if err != nil {
+		return nil, err
+	}
+	used, err := listPackageModules(ctx, env, pkgs, "-test")
+	if err != nil {
+		return nil, err
+	}
+	toolOnly := map[string]bool{}
+	for _, path := range toolPaths {
+		toolOnly[path] = true
+	}
+	for _, path := range used {
+		delete(toolOnly, path)
+	}
+	return toolOnly, nil
+}
+
+// filterBuiltModule returns the modules providing packages imported by pkgs
+// when built with supplied environment. Unlike filterLinkedModule, it honors
+// build constraints like GOOS, GOARCH and build tags.
+func filterBuiltModule(ctx context.Context, mods map[string]*modinfo.ModulePublic, env []string,
+	pkgs []string) ([]*modinfo.ModulePublic, error) {
+
+	paths, err := listPackageModules(ctx, env, pkgs)
+	if err != nil && len(unloadedModules(mods)) > 0 {
+		// The packages of modules which could not be loaded fail the listing:
+		// ignore them, the modules are reported with their error.
+		paths, err = listPackageModules(ctx, env, pkgs, "-e")
+	}
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var builtMods []*modinfo.ModulePublic
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		mod, ok := mods[path]
+		if !ok {
+			return nil, fmt.Errorf("not found: %s", path)
+		}
+		builtMods = append(builtMods, mod)
+	}
+	return builtMods, nil
+}
+
+type PkgError struct {
+	Err string
+}
+
+// PkgInfo is the subset of "go list -json" package output used in GOPATH
+// mode.
+type PkgInfo struct {
+	Name       string
+	Dir        string
+	Root       string
+	ImportPath string
+	Standard   bool
+	Error      *PkgError
+}
+
+var (
+	// reLicense matches license file names, from the most to the least
+	// likely: plain names, text files, COPYING files, other extensions, then
+	// names qualified with a license, like LICENSE-MIT or MIT-LICENSE.txt,
+	// used by modules splitting dual licenses in several files.
+	reLicense = regexp.MustCompile(`(?i)^(?:` +
+		`((?:un)?licen[sc]e)|` +
+		`((?:un)?licen[sc]e\.(?:md|markdown|txt))|` +
+		`(copy(?:ing|right)(?:\.[^.]+)?)|` +
+		`(licen[sc]e\.[^.]+)|` +
+		`(licen[sc]e[-_][a-z0-9-]+(?:\.[0-9]+)*(?:\.(?:md|markdown|txt))?)|` +
+		`([a-z0-9.]+[-_]licen[sc]e(?:\.(?:md|markdown|txt))?)` +
+		`)$`)
+)
+
+// scoreLicenseName returns a factor between 0 and 1 weighting how likely
+// supplied filename is a license file.
+func scoreLicenseName(name string) float64 {
+	m := reLicense.FindStringSubmatch(name)
+	switch {
+	case m == nil:
+		break
+	case m[1] != "":
+		return 1.0
+	case m[2] != "":
+		return 0.9
+	case m[3] != "":
+		return 0.8
+	case m[4] != "":
+		return 0.7
+	case m[5] != "":
+		return 0.6
+	case m[6] != "":
+		return 0.5
+	}
+	return 0.
+}
+
+// findLicense looks for license files in module path. It returns the path and
+// score of the best entry, an empty string if none was found.
+func findLicense(mod *modinfo.ModulePublic, maxSize int64) (string, error) {
+	return findLicenseIn(mod.Dir, maxSize)
+}
+
+// DefaultMaxLicenseSize is the default size limit of license files, in bytes.
+const DefaultMaxLicenseSize = 1 << 20
+
+// binarySniffSize is the length of the prefix of files checked for binary
+// data.
+const binarySniffSize = 8000
+
+// checkLicenseFile returns why the file at path cannot be a license text: it
+// is larger than maxSize bytes, or holds binary data. Like with git, files
+// holding NUL bytes in their first 8000 bytes are binary.
+func checkLicenseFile(path string, maxSize int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() > maxSize {
+		return fmt.Errorf("license file %s is larger than %d bytes", filepath.Base(path),
+			maxSize)
+	}
+	head := make([]byte, binarySniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if bytes.IndexByte(head[:n], 0) >= 0 {
+		return fmt.Errorf("license file %s holds binary data", filepath.Base(path))
+	}
+	return nil
+}
+
+// isRegularFile returns true if fi, an entry of directory dir, is a regular
+// file or a symbolic link to one, like the license files of monorepo modules
+// linked to the repository one. Links to directories or special files, and
+// link loops, which fail to resolve, are not.
+func isRegularFile(dir string, fi os.FileInfo) bool {
+	if fi.Mode().IsRegular() {
+		return true
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	target, err := os.Stat(filepath.Join(dir, fi.Name()))
+	return err == nil && target.Mode().IsRegular()
+}
+
+// findLicenseIn returns the path of the best license file in directory path,
+// an empty string if none was found. Among names scoring the same, like
+// LICENSE and License on case-sensitive file systems, the first in byte
+// order wins. Files larger than maxSize bytes or holding binary data are
+// skipped.
+func findLicenseIn(path string, maxSize int64) (string, error) {
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	bestScore := float64(0)
+	bestName := ""
+	for _, fi := range fis {
+		score := scoreLicenseName(fi.Name())
+		if score == 0 || score < bestScore || score == bestScore && fi.Name() > bestName {
+			continue
+		}
+		if !isRegularFile(path, fi) ||
+			checkLicenseFile(filepath.Join(path, fi.Name()), maxSize) != nil {
+			continue
+		}
+		bestScore = score
+		bestName = fi.Name()
+	}
+	if bestName != "" {
+		return filepath.Join(path, bestName), nil
+	}
+	return "", nil
+}
+
+var reNotice = regexp.MustCompile(`(?i)^notice(?:\.(?:md|markdown|txt))?$`)
+
+// findNotice returns the path of the NOTICE file in dir, an empty string if
+// there is none. Licenses like Apache 2.0 require them to be reproduced.
+// Files larger than maxSize bytes or holding binary data are skipped.
+func findNotice(dir string, maxSize int64) (string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, fi := range fis {
+		if reNotice.MatchString(fi.Name()) && isRegularFile(dir, fi) &&
+			checkLicenseFile(filepath.Join(dir, fi.Name()), maxSize) == nil {
+			return filepath.Join(dir, fi.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+// Options control how modules are scanned.
+type Options struct {
+	// Profile restricts the scan to the modules built with its constraints,
+	// if set.
+	Profile *config.Profile
+	// Imported restricts the scan to the modules providing the packages
+	// imported by the scanned ones, like Profile does. Otherwise, the modules
+	// needed by the main module are reported, whatever the scanned packages.
+	Imported bool
+	// Observer is notified of each module scanned, if set.
+	Observer report.Observer
+	// Strict aborts the scan on the first module error instead of recording
+	// it in the module License.Err.
+	Strict bool
+	// Offline forbids the go command to access the network. Modules must be
+	// in the module cache or the main module vendor directory.
+	Offline bool
+	// Download fetches the modules missing from the module cache.
+	Download bool
+	// Proxy fetches the zips of modules missing from the module cache from
+	// the GOPROXY module proxies and keeps only their license files.
+	Proxy bool
+	// GOPROXY replaces the GOPROXY module proxy chain of the go command, like
+	// a corporate proxy serving private modules.
+	GOPROXY string
+	// ProxyToken is sent as a bearer token to the proxies of GOPROXY, when
+	// fetching modules with Proxy. Other proxies are authenticated like the
+	// go command does, with the .netrc file or GOAUTH commands.
+	ProxyToken string
+	// DownloadJobs is the number of modules downloaded or fetched from
+	// proxies at once. It defaults to DefaultDownloadJobs.
+	DownloadJobs int
+	// Retries is the number of retries of module downloads and proxy
+	// requests failing with transient errors, like timeouts or overloaded
+	// servers, with exponential backoff. It defaults to DefaultRetries, and
+	// negative values disable retries.
+	Retries int
+	// GOPATH forces GOPATH mode in that GOPATH. Otherwise GOPATH mode is
+	// used when there is no main module.
+	GOPATH string
+	// GoFlags are passed to go commands in addition to the GOFLAGS
+	// environment variable, like "-mod=mod".
+	GoFlags string
+	// IncludeSelf reports the main module too, as the root component. It
+	// has no effect in GOPATH mode, where scanned packages are always
+	// reported.
+	IncludeSelf bool
+	// IncludeStd reports the Go standard library too, with the version of
+	// the go command.
+	IncludeStd bool
+	// IncludeTools reports the modules only needed by go.mod tool
+	// directives. They are left out otherwise.
+	IncludeTools bool
+	// Deprecations looks up module deprecation and retraction notices. It has
+	// no effect with Offline.
+	Deprecations bool
+	// Graph loads the module graph to list the modules required by each
+	// module in License.Requires.
+	Graph bool
+	// Embedded reports the license files found along the files embedded with
+	// go:embed directives by the scanned packages, as sub-components of
+	// their module, see License.Parent.
+	Embedded bool
+	// Native reports the system libraries linked by the cgo packages of the
+	// scan, named after their "#cgo pkg-config:" name or "-l" linker flag,
+	// without license.
+	Native bool
+	// StepTimeout bounds the duration of each go command and module proxy
+	// request, if not zero.
+	StepTimeout time.Duration
+	// Logger receives the scan steps and their duration: listing modules at
+	// info level, resolving, downloading and matching each module at debug
+	// level. Nothing is logged if it is nil.
+	Logger *slog.Logger
+	// Previous holds the licenses of an earlier scan by module path and
+	// version, like "github.com/pkg/errors@v0.9.1". Modules whose version
+	// and go.sum hash did not change are reported with their previous
+	// license instead of being downloaded and matched again.
+	Previous map[string]report.License
+	// MaxLicenseSize is the size limit of license files, in bytes. Larger
+	// files, like data files named like licenses, are not matched. It
+	// defaults to DefaultMaxLicenseSize.
+	MaxLicenseSize int64
+	// Detectors complement or replace the built-in word matcher, according
+	// to their priority.
+	Detectors []detect.Entry
+	// FetchRemote fetches the license texts referred to by URL in module
+	// license files, instead of leaving their license unknown. It has no
+	// effect with Offline.
+	FetchRemote bool
+	// Remote is a cache of module licenses shared with other scans, if set.
+	// Its licenses are reused like Previous ones, and the licenses matched
+	// by the scan are stored in it.
+	Remote *RemoteCache
+}
+
+// logger returns the logger of the scan.
+func (o *Options) logger() *slog.Logger {
+	if o == nil || o.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return o.Logger
+}
+
+// maxLicenseSize returns the size limit of license files.
+func (o *Options) maxLicenseSize() int64 {
+	if o == nil || o.MaxLicenseSize <= 0 {
+		return DefaultMaxLicenseSize
+	}
+	return o.MaxLicenseSize
+}
+
+// previous returns the license of mod reported by an earlier scan, if its
+// version and go.sum hash did not change. Modules replaced by local
+// directories, without go.sum hash, or whose scan failed are always scanned.
+func (o *Options) previous(mod *modinfo.ModulePublic) (report.License, bool) {
+	if o == nil || mod.Main {
+		return report.License{}, false
+	}
+	l, ok := o.Previous[mod.Path+"@"+mod.Version]
+	src := mod
+	if mod.Replace != nil {
+		src = mod.Replace
+	}
+	if !ok || l.Err != "" || src.Version == "" || src.Sum == "" || l.Sum != src.Sum {
+		return report.License{}, false
+	}
+	return l, true
+}
+
+type stepTimeoutKey struct{}
+
+// withStepTimeout returns a context bounding the steps of the scan run with
+// it to timeout, if not zero.
+func withStepTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, stepTimeoutKey{}, timeout)
+}
+
+// stepContext returns the context of a scan step, bounded by the step
+// timeout of ctx if any.
+func stepContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout, ok := ctx.Value(stepTimeoutKey{}).(time.Duration); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// goEnv returns the environment variables to pass to go commands, on top of
+// the process environment.
+func goEnv(opts *Options) []string {
+	var env []string
+	if opts.Profile != nil {
+		env = opts.Profile.Env()
+	}
+	if opts.GoFlags != "" {
+		// Extend the GOFLAGS set by the profile, or the inherited one.
+		flags := os.Getenv("GOFLAGS")
+		for i := len(env) - 1; i >= 0; i-- {
+			if strings.HasPrefix(env[i], "GOFLAGS=") {
+				flags = env[i][len("GOFLAGS="):]
+				env = append(env[:i], env[i+1:]...)
+				break
+			}
+		}
+		flags = strings.TrimSpace(flags + " " + opts.GoFlags)
+		env = append(env, "GOFLAGS="+flags)
+	}
+	if opts.GOPROXY != "" {
+		env = append(env, "GOPROXY="+opts.GOPROXY)
+	}
+	if opts.Offline {
+		env = append(env, offlineEnv...)
+	}
+	return env
+}
+
+// downloadModule downloads mod, or its replacement, into the module cache and
+// sets its directory. Downloads failing with transient errors are retried.
+// Failures are recorded in mod.Error.
+func downloadModule(ctx context.Context, env []string, mod *modinfo.ModulePublic, opts *Options) {
+	src := mod
+	if mod.Replace != nil {
+		src = mod.Replace
+	}
+	if src.Version == "" {
+		// Main module or local directory replacement.
+		return
+	}
+	start := time.Now()
+	log := opts.logger()
+	log.Debug("downloading module", "module", src.Path, "version", src.Version)
+	defer func() {
+		log.Debug("downloaded module", "module", src.Path, "version", src.Version,
+			"elapsed", time.Since(start), "error", errorString(mod.Error))
+	}()
+	var b *bytes.Buffer
+	err := retry(ctx, opts.retries(), func() (bool, error) {
+		var err error
+		b, err = runGo(ctx, env, "mod", "download", "-json", src.Path+"@"+src.Version)
+		return err != nil && isTransient(err), err
+	})
+	if err != nil {
+		mod.Error = &modinfo.ModuleError{Err: err.Error()}
+		return
+	}
+	var result struct {
+		Dir   string
+		Error string
+	}
+	err = json.Unmarshal(b.Bytes(), &result)
+	if err == nil && result.Error != "" {
+		err = fmt.Errorf("%s", result.Error)
+	}
+	if err != nil {
+		mod.Error = &modinfo.ModuleError{Err: "could not download: " + err.Error()}
+		return
+	}
+	mod.Dir = result.Dir
+}
+
+// offlineEnv are the environment variables preventing the go command from
+// downloading modules or checking their sums.
+var offlineEnv = []string{"GOPROXY=off", "GOSUMDB=off"}
+
+// setVendorDirs sets the directory of modules missing from the module cache
+// to their copy in the vendor directory of the main module, if any.
+func setVendorDirs(mods map[string]*modinfo.ModulePublic) {
+	vendor := ""
+	for _, mod := range mods {
+		if mod.Main && mod.Dir != "" {
+			vendor = filepath.Join(mod.Dir, "vendor")
+		}
+	}
+	if vendor == "" {
+		return
+	}
+	for _, mod := range mods {
+		if mod.Dir != "" || mod.Main {
+			continue
+		}
+		dir := filepath.Join(vendor, filepath.FromSlash(mod.Path))
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			mod.Dir = dir
+		}
+	}
+}
+
+// readGoSum returns the module hashes of the go.sum file at path, by module
+// path and version separated by "@". go.mod hashes are skipped.
+func readGoSum(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sums := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		sums[fields[0]+"@"+fields[1]] = fields[2]
+	}
+	return sums, nil
+}
+
+// setSums sets the hash of modules, or their replacement, from the go.sum
+// file of the main module, when the go command did not report it.
+func setSums(mods map[string]*modinfo.ModulePublic) error {
+	var sums map[string]string
+	for _, mod := range mods {
+		if mod.Main && mod.Dir != "" {
+			s, err := readGoSum(filepath.Join(mod.Dir, "go.sum"))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			sums = s
+		}
+	}
+	for _, mod := range mods {
+		src := mod
+		if mod.Replace != nil {
+			src = mod.Replace
+		}
+		if src.Sum == "" && src.Version != "" {
+			src.Sum = sums[src.Path+"@"+src.Version]
+		}
+	}
+	return nil
+}
+
+// ListLicenses returns the licenses of modules linked by pkgs. If profile is
+// not nil, only modules built with its constraints are considered. If gopath
+// is set, packages are scanned in GOPATH mode in that GOPATH.
+func ListLicenses(gopath string, pkgs []string, profile *config.Profile) ([]report.License, error) {
+	return Scan(context.Background(), pkgs, &Options{Profile: profile, GOPATH: gopath})
+}
+
+// MainPackages returns the import paths of the main packages matched by pkgs,
+// or the packages of the current module without pkgs.
+func MainPackages(ctx context.Context, pkgs []string, opts *Options) ([]string, error) {
+	env := goEnv(opts)
+	if opts.GOPATH != "" {
+		env = append(env, "GOPATH="+opts.GOPATH, "GO111MODULE=off")
+	}
+	if len(pkgs) == 0 {
+		pkgs = []string{"./..."}
+	}
+	args := []string{"list", "-f", `{{if eq .Name "main"}}{{.ImportPath}}{{end}}`}
+	b, err := runGoBatches(ctx, env, args, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	mains := []string{}
+	seen := map[string]bool{}
+	for _, main := range strings.Fields(b.String()) {
+		if !seen[main] {
+			seen[main] = true
+			mains = append(mains, main)
+		}
+	}
+	return mains, nil
+}
+
+// stdModule returns a module standing for the Go standard library, located
+// in GOROOT and versioned like the go command.
+func stdModule(ctx context.Context, env []string) (*modinfo.ModulePublic, error) {
+	b, err := runGo(ctx, env, "env", "GOROOT", "GOVERSION")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	mod := &modinfo.ModulePublic{Path: "std", Dir: strings.TrimSpace(lines[0])}
+	if len(lines) > 1 {
+		mod.Version = strings.TrimSpace(lines[1])
+	}
+	return mod, nil
+}
+
+// withTools returns linked modules without the modules only needed by tools,
+// unless include is set, in which case all of them are added.
+func withTools(linked []*modinfo.ModulePublic, mods map[string]*modinfo.ModulePublic,
+	tools map[string]bool, include bool) []*modinfo.ModulePublic {
+
+	kept := []*modinfo.ModulePublic{}
+	for _, mod := range linked {
+		if !tools[mod.Path] {
+			kept = append(kept, mod)
+		}
+	}
+	if !include {
+		return kept
+	}
+	paths := []string{}
+	for path := range tools {
+		if mods[path] != nil {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		kept = append(kept, mods[path])
+	}
+	return kept
+}
+
+// withoutMainModule returns mods without the main module.
+func withoutMainModule(mods []*modinfo.ModulePublic) []*modinfo.ModulePublic {
+	kept := []*modinfo.ModulePublic{}
+	for _, mod := range mods {
+		if !mod.Main {
+			kept = append(kept, mod)
+		}
+	}
+	return kept
+}
+
+// unloadedModules returns the modules of mods which the go command could not
+// load, like those missing from go.sum or from the module cache when
+// downloads are disabled, sorted by path.
+func unloadedModules(mods map[string]*modinfo.ModulePublic) []*modinfo.ModulePublic {
+	unloaded := []*modinfo.ModulePublic{}
+	for _, mod := range mods {
+		if mod.Error != nil && mod.Dir == "" && !mod.Main {
+			unloaded = append(unloaded, mod)
+		}
+	}
+	sort.Slice(unloaded, func(i, j int) bool {
+		return unloaded[i].Path < unloaded[j].Path
+	})
+	return unloaded
+}
+
+// withUnloadedModules appends the modules of mods which could not be loaded
+// to linked, if missing. Their packages cannot be loaded either, so whether
+// they are linked is unknown: they are reported with their error rather than
+// left out.
+func withUnloadedModules(linked []*modinfo.ModulePublic,
+	mods map[string]*modinfo.ModulePublic) []*modinfo.ModulePublic {
+
+	seen := map[string]bool{}
+	for _, mod := range linked {
+		seen[mod.Path] = true
+	}
+	for _, mod := range unloadedModules(mods) {
+		if !seen[mod.Path] {
+			linked = append(linked, mod)
+		}
+	}
+	return linked
+}
+
+// Scan returns the licenses of modules linked by pkgs, as configured by opts.
+// Without pkgs, the packages of the current module are scanned. If ctx is
+// done while licenses are matched, the licenses matched so far are returned
+// along with ctx error.
+func Scan(ctx context.Context, pkgs []string, opts *Options) ([]report.License, error) {
+	log := opts.logger()
+	start := time.Now()
+	ctx = withStepTimeout(ctx, opts.StepTimeout)
+	env := goEnv(opts)
+	profile := opts.Profile
+	if len(pkgs) == 0 {
+		pkgs = []string{"./..."}
+	}
+	if opts.GOPATH != "" {
+		env = append(env, "GOPATH="+opts.GOPATH, "GO111MODULE=off")
+		return scanGOPATH(ctx, env, pkgs, opts)
+	}
+	modules, err := moduleMode(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	if !modules {
+		// Pre-modules project, or GO111MODULE=off.
+		return scanGOPATH(ctx, append(env, "GO111MODULE=off"), pkgs, opts)
+	}
+	step := time.Now()
+	mods, err := listDependencies(ctx, env, opts.Deprecations && !opts.Offline)
+	if err != nil {
+		return nil, fmt.Errorf("could not list %s dependencies: %s",
+			strings.Join(pkgs, " "), err)
+	}
+	log.Info("listed modules", "count", len(mods), "elapsed", time.Since(step))
+	setVendorDirs(mods)
+	err = setSums(mods)
+	if err != nil {
+		return nil, err
+	}
+	step = time.Now()
+	var linkedMods []*modinfo.ModulePublic
+	if profile == nil && !opts.Imported {
+		linkedMods, err = filterLinkedModule(ctx, mods, env)
+	} else {
+		linkedMods, err = filterBuiltModule(ctx, mods, env, pkgs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filter linked module: %s", err)
+	}
+	linkedMods = withUnloadedModules(linkedMods, mods)
+	if !opts.IncludeSelf {
+		linkedMods = withoutMainModule(linkedMods)
+	}
+	tools, err := toolOnlyModules(ctx, env, pkgs)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tool dependencies: %s", err)
+	}
+	linkedMods = withTools(linkedMods, mods, tools, opts.IncludeTools)
+	log.Info("resolved linked modules", "count", len(linkedMods),
+		"elapsed", time.Since(step))
+	if opts.IncludeStd {
+		std, err := stdModule(ctx, env)
+		if err != nil {
+			return nil, err
+		}
+		linkedMods = append(linkedMods, std)
+	}
+	opts, err = withRemoteLicenses(ctx, linkedMods, opts, log)
+	if err != nil {
+		return nil, err
+	}
+	// Modules reported by an earlier scan need not be fetched.
+	pending := []*modinfo.ModulePublic{}
+	for _, mod := range linkedMods {
+		if _, ok := opts.previous(mod); !ok {
+			pending = append(pending, mod)
+		}
+	}
+	err = fetchModules(ctx, env, pending, opts)
+	if err != nil {
+		return nil, err
+	}
+	licenses, err := licensesOf(ctx, linkedMods, opts)
+	if err != nil {
+		return licenses, err
+	}
+	storeRemoteLicenses(ctx, licenses, linkedMods, opts, log)
+	for i := range licenses {
+		licenses[i].Tool = tools[licenses[i].Package]
+	}
+	if opts.Embedded {
+		step = time.Now()
+		embedded, err := embeddedLicenses(ctx, env, pkgs, opts)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan embedded files: %s", err)
+		}
+		licenses = append(licenses, embedded...)
+		log.Info("scanned embedded files", "count", len(embedded),
+			"elapsed", time.Since(step))
+	}
+	if opts.Native {
+		native, err := nativeLicenses(ctx, env, pkgs)
+		if err != nil {
+			return nil, fmt.Errorf("could not list native libraries: %s", err)
+		}
+		licenses = append(licenses, native...)
+	}
+	if opts.Graph {
+		step = time.Now()
+		graph, err := moduleGraph(ctx, env)
+		if err != nil {
+			return nil, fmt.Errorf("could not load module graph: %s", err)
+		}
+		setRequires(licenses, linkedMods, graph)
+		log.Info("loaded module graph", "elapsed", time.Since(step))
+	}
+	log.Info("scanned modules", "count", len(licenses), "elapsed", time.Since(start))
+	return licenses, nil
+}
+
+// errorString returns the message of a module error, or an empty string.
+func errorString(err *modinfo.ModuleError) string {
+	if err == nil {
+		return ""
+	}
+	return err.Err
+}
+
+// templateTitle returns the title of t, or an empty string if it is nil.
+func templateTitle(t *matcher.Template) string {
+	if t == nil {
+		return ""
+	}
+	return t.Title
+}
+
+// moduleGraph returns the module requirement graph, as printed by "go mod
+// graph": the requirements of each module by "path@version", or "path" for
+// the main module.
+func moduleGraph(ctx context.Context, env []string) (map[string][]string, error) {
+	b, err := runGo(ctx, env, "mod", "graph")
+	if err != nil {
+		return nil, err
+	}
+	graph := map[string][]string{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			graph[fields[0]] = append(graph[fields[0]], fields[1])
+		}
+	}
+	return graph, nil
+}
+
+// setRequires lists in licenses the modules of mods required by each of them
+// in graph, at their selected version.
+func setRequires(licenses []report.License, mods []*modinfo.ModulePublic,
+	graph map[string][]string) {
+
+	selected := map[string]string{}
+	for _, mod := range mods {
+		node := mod.Path
+		if mod.Version != "" {
+			node += "@" + mod.Version
+		}
+		selected[mod.Path] = node
+	}
+	for i, l := range licenses {
+		node, ok := selected[l.Package]
+		if !ok {
+			continue
+		}
+		requires := []string{}
+		for _, dep := range graph[node] {
+			path := dep
+			if i := strings.LastIndex(dep, "@"); i >= 0 {
+				path = dep[:i]
+			}
+			// Requirements of older versions are raised to the selected ones.
+			if _, ok := selected[path]; ok {
+				requires = append(requires, path)
+			}
+		}
+		sort.Strings(requires)
+		licenses[i].Requires = requires
+	}
+}
+
+// scanModule detects the license of mod. Matches are cached by license path
+// in matched. License files referring to another file or to a URL are
+// followed, see followStub.
+func scanModule(ctx context.Context, mod *modinfo.ModulePublic, templates []*matcher.Template,
+	matched map[string]matcher.MatchResult, opts *Options) (report.License, error) {
+
+	license := report.License{
+		Source:     "go",
+		Package:    mod.Path,
+		Version:    mod.Version,
+		Sum:        mod.Sum,
+		Deprecated: mod.Deprecated,
+		Retracted:  strings.Join(mod.Retracted, "; "),
+	}
+	if mod.Dir == "" {
+		if mod.Error != nil {
+			return license, fmt.Errorf("%s", errorHint(mod, mod.Error.Err))
+		}
+		if opts.Offline {
+			return license, fmt.Errorf("%s", errorHint(mod,
+				"module is not in the module cache and cannot be downloaded offline"))
+		}
+		return license, fmt.Errorf("module directory not found")
+	}
+	license.VCS = moduleVCS(mod)
+	path, err := findLicense(mod, opts.maxLicenseSize())
+	if err != nil {
+		return license, err
+	}
+	notice, err := findNotice(mod.Dir, opts.maxLicenseSize())
+	if err != nil {
+		return license, err
+	}
+	if mod.Replace != nil {
+		license.Sum = mod.Replace.Sum
+	}
+	license.Path = path
+	license.Notice = notice
+	if path != "" {
+		target, url, err := followStub(ctx, mod.Dir, path, opts.FetchRemote && !opts.Offline)
+		if err != nil {
+			return license, err
+		}
+		if url != "" {
+			license.URL = url
+			if target == "" {
+				opts.logger().Warn("license file refers to a URL which is not fetched",
+					"module", mod.Path, "path", path, "url", url)
+				return license, nil
+			}
+		} else if rel, err := filepath.Rel(mod.Dir, target); err == nil {
+			license.URL = licenseURL(mod, filepath.ToSlash(rel))
+		}
+		path = target
+		license.Path = path
+		m, ok := matched[path]
+		if !ok {
+			err := checkLicenseFile(path, opts.maxLicenseSize())
+			if err != nil {
+				return license, err
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return license, err
+			}
+			m = matcher.Match(data, templates)
+			matched[path] = m
+		}
+		license.Score = m.Score
+		license.Template = m.Template
+		license.ExtraWords = m.ExtraWords
+		license.MissingWords = m.MissingWords
+		license.MissingSections = m.MissingSections
+		license.PatentGrant = m.Clauses.PatentGrant
+		license.TrademarkClause = m.Clauses.Trademark
+	}
+	if !license.PatentGrant {
+		license.PatentGrant = patentsGrant(mod.Dir, opts.maxLicenseSize())
+	}
+	if len(opts.Detectors) > 0 {
+		applyDetectors(ctx, mod, &license, templates, opts)
+	}
+	return license, nil
+}
+
+// patentsGrant returns true if dir holds a PATENTS file granting patent
+// licenses explicitly, like the modules of the Go project.
+func patentsGrant(dir string, maxSize int64) bool {
+	path := filepath.Join(dir, "PATENTS")
+	if checkLicenseFile(path, maxSize) != nil {
+		return false
+	}
+	data, err := ioutil.ReadFile(path)
+	return err == nil && matcher.FindClauses(data).PatentGrant
+}
+
+// applyDetectors runs the detectors of opts on mod and replaces the license
+// matched by the word matcher with the best candidate of the highest
+// priority detector, see detect.Best. Detector failures are logged.
+func applyDetectors(ctx context.Context, mod *modinfo.ModulePublic, license *report.License,
+	templates []*matcher.Template, opts *Options) {
+
+	words := []detect.Candidate{}
+	if license.Template != nil {
+		words = append(words, detect.Candidate{Template: license.Template,
+			Score: license.Score, Path: license.Path})
+	}
+	results := []detect.Result{{
+		Entry:      detect.Entry{Name: detect.WordsName},
+		Candidates: words,
+	}}
+	m := detect.Module{Path: mod.Path, Version: mod.Version, Dir: mod.Dir,
+		License: license.Path}
+	for _, e := range opts.Detectors {
+		candidates, err := e.Detector.Detect(ctx, m, templates)
+		if err != nil {
+			opts.logger().Warn("detector failed", "detector", e.Name, "module", mod.Path,
+				"error", err)
+			continue
+		}
+		results = append(results, detect.Result{Entry: e, Candidates: candidates})
+	}
+	best, name, ok := detect.Best(results)
+	if !ok || name == detect.WordsName {
+		return
+	}
+	license.Template = best.Template
+	license.Score = best.Score
+	license.Declared = best.Declared
+	license.Detector = name
+	license.ExtraWords = nil
+	license.MissingWords = nil
+	license.MissingSections = nil
+	if best.Path != "" {
+		license.Path = best.Path
+	}
+}
+
+// licensesOf detects the licenses of supplied modules, sorted by license
+// path. Module errors are recorded in their License.Err, unless opts is set
+// Strict. opts may be nil.
+func licensesOf(ctx context.Context, mods []*modinfo.ModulePublic, opts *Options) ([]report.License, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	log := opts.logger()
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache matched licenses by path. Useful for package with a lot of
+	// subpackages like bleve.
+	matched := map[string]matcher.MatchResult{}
+
+	licenses := []report.License{}
+	for i, mod := range mods {
+		if ctx.Err() != nil {
+			return licenses, ctx.Err()
+		}
+		if opts.Observer != nil {
+			opts.Observer.Scanning(i, len(mods), mod.Path)
+		}
+		start := time.Now()
+		license, ok := opts.previous(mod)
+		if ok {
+			log.Debug("reused license", "module", mod.Path, "version", mod.Version,
+				"license", templateTitle(license.Template), "score", license.Score)
+		} else {
+			license, err = scanModule(ctx, mod, templates, matched, opts)
+			if err != nil {
+				if opts.Strict {
+					return nil, fmt.Errorf("%s: %s", mod.Path, err)
+				}
+				license.Err = err.Error()
+			}
+			log.Debug("matched license", "module", mod.Path, "version", mod.Version,
+				"path", license.Path, "license", templateTitle(license.Template),
+				"score", license.Score, "elapsed", time.Since(start), "error", license.Err)
+		}
+		license.Root = mod.Main
+		if opts.Observer != nil {
+			opts.Observer.Scanned(license)
+		}
+		licenses = append(licenses, license)
+	}
+
+	// Modules without license file share an empty path, so ties are broken
+	// by module path and version for stable output.
+	sort.Slice(licenses, func(i, j int) bool {
+		a, b := licenses[i], licenses[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Version < b.Version
+	})
+
+	return licenses, nil
+}
+
+// longestCommonPrefix returns the longest common prefix over import path
+// components of supplied licenses.
+func longestCommonPrefix(licenses []report.License) string {
+	type Node struct {
+		Name     string
+		Children map[string]*Node
+	}
+	// Build a prefix tree. Not super efficient, but easy to do.
+	root := &Node{
+		Children: map[string]*Node{},
+	}
+	for _, l := range licenses {
+		n := root
+		for _, part := range strings.Split(l.Package, "/") {
+			c := n.Children[part]
+			if c == nil {
+				c = &Node{
+					Name:     part,
+					Children: map[string]*Node{},
+				}
+				n.Children[part] = c
+			}
+			n = c
+		}
+	}
+	n := root
+	prefix := []string{}
+	for {
+		if len(n.Children) != 1 {
+			break
+		}
+		for _, c := range n.Children {
+			prefix = append(prefix, c.Name)
+			n = c
+			break
+		}
+	}
+	return strings.Join(prefix, "/")
+}
+
+// licenseGroups returns the entries of licenses sharing a license file, by
+// license path, along with their longest import path common prefix.
+func licenseGroups(licenses []report.License) (map[string][]report.License,
+	map[string]string) {
+
+	paths := map[string][]report.License{}
+	for _, l := range licenses {
+		if l.Path == "" {
+			continue
+		}
+		paths[l.Path] = append(paths[l.Path], l)
+	}
+	prefixes := map[string]string{}
+	for k, v := range paths {
+		if len(v) > 1 {
+			prefixes[k] = longestCommonPrefix(v)
+		}
+	}
+	return paths, prefixes
+}
+
+// AnnotateGroups returns the input licenses with the Group of the entries
+// sharing a license file set to their longest import path common prefix, or
+// to the license path if they have none.
+func AnnotateGroups(licenses []report.License) []report.License {
+	_, prefixes := licenseGroups(licenses)
+	annotated := []report.License{}
+	for _, l := range licenses {
+		if prefix, ok := prefixes[l.Path]; ok {
+			l.Group = prefix
+			if prefix == "" {
+				l.Group = l.Path
+			}
+		}
+		annotated = append(annotated, l)
+	}
+	return annotated
+}
+
+// GroupLicenses returns the input licenses after grouping them by license path
+// and find their longest import path common prefix. Entries with empty paths
+// are left unchanged. Entries sharing a license file without common prefix
+// are kept, annotated with the license path as group.
+func GroupLicenses(licenses []report.License) []report.License {
+	paths, prefixes := licenseGroups(licenses)
+	for k, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		l := paths[k][0]
+		l.Package = prefix
+		paths[k] = []report.License{l}
+	}
+	kept := []report.License{}
+	for _, l := range licenses {
+		if l.Path == "" {
+			kept = append(kept, l)
+			continue
+		}
+		if prefix, ok := prefixes[l.Path]; ok && prefix == "" {
+			l.Group = l.Path
+			kept = append(kept, l)
+			continue
+		}
+		if v, ok := paths[l.Path]; ok {
+			kept = append(kept, v[0])
+			delete(paths, l.Path)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,839 @@
+package gomod
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+type testResult struct {
+	Package string
+	License string
+	Score   int
+	Extra   int
+	Missing int
+	Err     string
+}
+
+func listTestLicenses(pkgs []string) ([]testResult, error) {
+	gopath, err := filepath.Abs("testdata")
+	if err != nil {
+		return nil, err
+	}
+	licenses, err := ListLicenses(gopath, pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+	res := []testResult{}
+	for _, l := range licenses {
+		r := testResult{
+			Package: l.Package,
+		}
+		if l.Template != nil {
+			r.License = l.Template.Title
+			r.Score = int(100 * l.Score)
+		}
+		if l.Err != "" {
+			r.Err = "some error"
+		}
+		r.Extra = len(l.ExtraWords)
+		r.Missing = len(l.MissingWords)
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func compareTestLicenses(pkgs []string, wanted []testResult) error {
+	stringify := func(res []testResult) string {
+		parts := []string{}
+		for _, r := range res {
+			s := fmt.Sprintf("%s \"%s\" %d%%", r.Package, r.License, r.Score)
+			if r.Err != "" {
+				s += " " + r.Err
+			}
+			if r.Extra > 0 {
+				s += fmt.Sprintf(" +%d", r.Extra)
+			}
+			if r.Missing > 0 {
+				s += fmt.Sprintf(" -%d", r.Missing)
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, "\n")
+	}
+
+	licenses, err := listTestLicenses(pkgs)
+	if err != nil {
+		return err
+	}
+	got := stringify(licenses)
+	expected := stringify(wanted)
+	if got != expected {
+		return fmt.Errorf("licenses do not match:\n%s\n!=\n%s", got, expected)
+	}
+	return nil
+}
+
+func TestNoDependencies(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/red"}, []testResult{
+		{Package: "colors/red", License: "MIT License", Score: 98, Missing: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultipleLicenses(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/blue"}, []testResult{
+		{Package: "colors/blue", License: "Apache License 2.0", Score: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNoLicense(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/green"}, []testResult{
+		{Package: "colors/green", License: "", Score: 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMainWithDependencies(t *testing.T) {
+	// It also tests license retrieval in parent directory.
+	err := compareTestLicenses([]string{"colors/cmd/paint"}, []testResult{
+		{Package: "colors/cmd/paint", License: "Academic Free License v3.0", Score: 100},
+		{Package: "colors/red", License: "MIT License", Score: 98, Missing: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMainWithAliasedDependencies(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/cmd/mix"}, []testResult{
+		{Package: "colors/cmd/mix", License: "Academic Free License v3.0", Score: 100},
+		{Package: "colors/red", License: "MIT License", Score: 98, Missing: 2},
+		{Package: "couleurs/red", License: "GNU Lesser General Public License v2.1",
+			Score: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/yellow"}, []testResult{
+		{Package: "colors/yellow", License: "Microsoft Reciprocal License", Score: 16,
+			Extra: 103, Missing: 127},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBroken(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/broken"}, []testResult{
+		{Package: "colors/broken", License: "GNU General Public License v3.0", Score: 100},
+		{Package: "colors/missing", License: "", Score: 0, Err: "some error"},
+		{Package: "colors/red", License: "MIT License", Score: 98, Missing: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBrokenDependency(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/purple"}, []testResult{
+		{Package: "colors/broken", License: "GNU General Public License v3.0", Score: 100},
+		{Package: "colors/missing", License: "", Score: 0, Err: "some error"},
+		{Package: "colors/purple", License: "", Score: 0},
+		{Package: "colors/red", License: "MIT License", Score: 98, Missing: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPackageExpression(t *testing.T) {
+	err := compareTestLicenses([]string{"colors/cmd/..."}, []testResult{
+		{Package: "colors/cmd/mix", License: "Academic Free License v3.0", Score: 100},
+		{Package: "colors/cmd/paint", License: "Academic Free License v3.0", Score: 100},
+		{Package: "colors/red", License: "MIT License", Score: 98, Missing: 2},
+		{Package: "couleurs/red", License: "GNU Lesser General Public License v2.1",
+			Score: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStandardPackages(t *testing.T) {
+	err := compareTestLicenses([]string{"encoding/json", "cmd/addr2line"}, []testResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFilterLinkedModule(t *testing.T) {
+	tests := []struct {
+		Path   string
+		Linked bool
+	}{
+		// only module aware packages are supposed to be linked
+		{Path: "path/filepath", Linked: false},
+		{Path: "crypto/tls", Linked: false},
+		{Path: "golang.org/x/net", Linked: false},
+		{Path: "github.com/groove-x/go-licenses", Linked: true},
+	}
+
+	mods := make(map[string]*modinfo.ModulePublic)
+	for _, tt := range tests {
+		mods[tt.Path] = &modinfo.ModulePublic{Path: tt.Path}
+	}
+
+	linkedMods, err := filterLinkedModule(context.Background(), mods, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		linked := false
+		for _, linkedMod := range linkedMods {
+			if linkedMod.Path == tt.Path {
+				linked = true
+			}
+		}
+		if linked != tt.Linked {
+			t.Fatalf("%s: want %t, got %t", tt.Path, tt.Linked, linked)
+		}
+	}
+}
+
+func TestGroupLicenses(t *testing.T) {
+	licenses := []report.License{
+		{Package: "a.org/x/one", Path: "/a/LICENSE"},
+		{Package: "a.org/x/two", Path: "/a/LICENSE"},
+		{Package: "b.org/y", Path: "/shared/LICENSE"},
+		{Package: "c.org/z", Path: "/shared/LICENSE"},
+		{Package: "d.org/w"},
+	}
+	stringify := func(licenses []report.License) string {
+		parts := []string{}
+		for _, l := range licenses {
+			parts = append(parts, l.Package+"["+l.Group+"]")
+		}
+		return strings.Join(parts, " ")
+	}
+	got := stringify(GroupLicenses(licenses))
+	wanted := "a.org/x[] b.org/y[/shared/LICENSE] c.org/z[/shared/LICENSE] d.org/w[]"
+	if got != wanted {
+		t.Fatalf("unexpected groups: %s != %s", got, wanted)
+	}
+	got = stringify(AnnotateGroups(licenses))
+	wanted = "a.org/x/one[a.org/x] a.org/x/two[a.org/x] " +
+		"b.org/y[/shared/LICENSE] c.org/z[/shared/LICENSE] d.org/w[]"
+	if got != wanted {
+		t.Fatalf("unexpected annotations: %s != %s", got, wanted)
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	mods := []*modinfo.ModulePublic{
+		{Path: "example.com/missing", Version: "v1.0.0"},
+		{Path: "example.com/gone", Version: "v1.0.0", Dir: "testdata/does-not-exist"},
+	}
+	licenses, err := licensesOf(context.Background(), mods, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range licenses {
+		if l.Err == "" {
+			t.Fatalf("%s: error not recorded", l.Package)
+		}
+	}
+	_, err = licensesOf(context.Background(), mods, &Options{Strict: true})
+	if err == nil || !strings.HasPrefix(err.Error(), "example.com/missing: ") {
+		t.Fatalf("unexpected strict error: %v", err)
+	}
+}
+
+func TestSetVendorDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-vendor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	vendored := filepath.Join(dir, "vendor", "example.com", "dep")
+	err = os.MkdirAll(vendored, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mods := map[string]*modinfo.ModulePublic{
+		"example.com/main":  {Path: "example.com/main", Main: true, Dir: dir},
+		"example.com/dep":   {Path: "example.com/dep"},
+		"example.com/other": {Path: "example.com/other"},
+	}
+	setVendorDirs(mods)
+	if mods["example.com/dep"].Dir != vendored {
+		t.Fatalf("unexpected vendored module directory: %q", mods["example.com/dep"].Dir)
+	}
+	if mods["example.com/other"].Dir != "" {
+		t.Fatalf("unexpected module directory: %q", mods["example.com/other"].Dir)
+	}
+}
+
+func TestWithoutMainModule(t *testing.T) {
+	mods := withoutMainModule([]*modinfo.ModulePublic{
+		{Path: "example.com/main", Main: true},
+		{Path: "example.com/dep"},
+	})
+	if len(mods) != 1 || mods[0].Path != "example.com/dep" {
+		t.Fatalf("unexpected modules: %+v", mods)
+	}
+}
+
+func TestStdModule(t *testing.T) {
+	mod, err := stdModule(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod.Path != "std" || !strings.HasPrefix(mod.Version, "go") {
+		t.Fatalf("unexpected standard library module: %+v", mod)
+	}
+	path, err := findLicense(mod, DefaultMaxLicenseSize)
+	if err != nil || path == "" {
+		t.Fatalf("standard library license not found: %q, %v", path, err)
+	}
+}
+
+func TestToolOnlyModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-tools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod": `module example.com/main
+
+go 1.24
+
+require (
+	example.com/lib v0.0.0
+	example.com/tool v0.0.0
+)
+
+replace example.com/lib => ./lib
+
+replace example.com/tool => ./tool
+
+tool example.com/tool/cmd/t
+`,
+		"main.go":            "package main\n\nimport _ \"example.com/lib\"\n\nfunc main() {}\n",
+		"lib/go.mod":         "module example.com/lib\n",
+		"lib/lib.go":         "package lib\n",
+		"tool/go.mod":        "module example.com/tool\n",
+		"tool/cmd/t/main.go": "package main\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tools, err := toolOnlyModules(context.Background(), offlineEnv, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 1 || !tools["example.com/tool"] {
+		t.Fatalf("unexpected tool modules: %v", tools)
+	}
+}
+
+func TestSetSums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-sums")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "go.sum"), []byte(
+		"example.com/dep v1.0.0 h1:dep=\n"+
+			"example.com/dep v1.0.0/go.mod h1:depmod=\n"+
+			"example.com/fork v1.1.0 h1:fork=\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mods := map[string]*modinfo.ModulePublic{
+		"example.com/main": {Path: "example.com/main", Main: true, Dir: dir},
+		"example.com/dep":  {Path: "example.com/dep", Version: "v1.0.0"},
+		"example.com/orig": {Path: "example.com/orig", Version: "v1.0.0",
+			Replace: &modinfo.ModulePublic{Path: "example.com/fork", Version: "v1.1.0"}},
+	}
+	err = setSums(mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum := mods["example.com/dep"].Sum; sum != "h1:dep=" {
+		t.Fatalf("unexpected module sum: %q", sum)
+	}
+	if sum := mods["example.com/orig"].Replace.Sum; sum != "h1:fork=" {
+		t.Fatalf("unexpected replacement sum: %q", sum)
+	}
+}
+
+func TestSetRequires(t *testing.T) {
+	mods := []*modinfo.ModulePublic{
+		{Path: "example.com/main", Main: true},
+		{Path: "example.com/a", Version: "v1.1.0"},
+		{Path: "example.com/b", Version: "v1.0.0"},
+	}
+	graph := map[string][]string{
+		"example.com/main":     {"example.com/a@v1.1.0", "example.com/b@v1.0.0"},
+		"example.com/a@v1.1.0": {"example.com/b@v0.9.0", "example.com/c@v1.0.0"},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0"},
+	}
+	licenses := []report.License{
+		{Package: "example.com/main"},
+		{Package: "example.com/a"},
+		{Package: "example.com/b"},
+	}
+	setRequires(licenses, mods, graph)
+	got := fmt.Sprint(licenses[0].Requires, licenses[1].Requires, licenses[2].Requires)
+	if got != "[example.com/a example.com/b] [example.com/b] []" {
+		t.Fatalf("unexpected requirements: %s", got)
+	}
+}
+
+func TestWhyChains(t *testing.T) {
+	packages := map[string]*goPackage{
+		"example.com/main":     {Module: "example.com/main", Root: true, Imports: []string{"example.com/main/a", "fmt"}},
+		"example.com/main/a":   {Module: "example.com/main", Imports: []string{"example.com/x/b", "example.com/y"}},
+		"example.com/y":        {Module: "example.com/y", Imports: []string{"example.com/x/b"}},
+		"example.com/x/b":      {Module: "example.com/x"},
+		"fmt":                  {},
+		"example.com/main/cmd": {Module: "example.com/main", Root: true, Imports: []string{"fmt"}},
+	}
+	got := fmt.Sprint(importChain(packages, "example.com/main", "example.com/x"),
+		importChain(packages, "example.com/main/cmd", "example.com/x"))
+	if got != "[example.com/main example.com/main/a example.com/x/b] []" {
+		t.Fatalf("unexpected import chains: %s", got)
+	}
+	graph := map[string][]string{
+		"example.com/main":     {"example.com/a@v1.0.0", "example.com/b@v1.0.0"},
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0"},
+		"example.com/b@v1.0.0": {"example.com/a@v1.0.0", "example.com/d@v1.0.0"},
+	}
+	got = fmt.Sprint(requireChain(graph, "example.com/main", "example.com/c"),
+		requireChain(graph, "example.com/main", "example.com/e"))
+	if got != "[example.com/main example.com/a@v1.0.0 example.com/c@v1.0.0] []" {
+		t.Fatalf("unexpected requirement chains: %s", got)
+	}
+}
+
+func TestLicensesOfCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mods := []*modinfo.ModulePublic{{Path: "example.com/a", Dir: "testdata"}}
+	licenses, err := licensesOf(ctx, mods, nil)
+	if err != context.Canceled || len(licenses) != 0 {
+		t.Fatalf("unexpected result of canceled scan: %v, %v", licenses, err)
+	}
+	_, err = runGo(withStepTimeout(context.Background(), time.Nanosecond), nil, "version")
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Fatalf("step timeout not enforced: %v", err)
+	}
+}
+
+func TestDownloadModuleError(t *testing.T) {
+	mod := &modinfo.ModulePublic{Path: "example.com/missing", Version: "v1.0.0"}
+	downloadModule(context.Background(), offlineEnv, mod, nil)
+	if mod.Dir != "" || mod.Error == nil {
+		t.Fatalf("download did not fail: %+v", mod)
+	}
+	licenses, err := licensesOf(context.Background(), []*modinfo.ModulePublic{mod}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(licenses[0].Err, mod.Error.Err) {
+		t.Fatalf("unexpected error: %q", licenses[0].Err)
+	}
+}
+
+func TestGoEnv(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=mod")
+	env := goEnv(&Options{
+		Profile: &config.Profile{GOOS: "linux", Tags: []string{"a"}},
+		GoFlags: "-trimpath",
+		Offline: true,
+	})
+	got := strings.Join(env, " ")
+	wanted := "GOOS=linux GOFLAGS=-mod=mod -tags=a -trimpath GOPROXY=off GOSUMDB=off"
+	if got != wanted {
+		t.Fatalf("unexpected environment: %q != %q", got, wanted)
+	}
+}
+
+func TestGoError(t *testing.T) {
+	_, err := runGo(context.Background(), nil, "no-such-command")
+	goErr, ok := err.(*GoError)
+	if !ok {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	if goErr.Args[0] != "no-such-command" || goErr.Stderr == "" {
+		t.Fatalf("unexpected error: %+v", goErr)
+	}
+
+	t.Setenv("PATH", "")
+	_, err = runGo(context.Background(), nil, "version")
+	if err == nil || !strings.Contains(err.Error(), "go command not found in PATH") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseModuleList(t *testing.T) {
+	mods, err := ParseModuleList([]byte(`
+# dependencies
+github.com/pkg/errors@v0.9.1
+golang.org/x/text v0.3.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(mods[0], ", ", mods[1])
+	if len(mods) != 2 || got != "github.com/pkg/errors v0.9.1, golang.org/x/text v0.3.0" {
+		t.Fatalf("unexpected modules: %s", got)
+	}
+	for _, list := range []string{
+		"github.com/pkg/errors\n",
+		"x v1.0.0/../../../root/module\n",
+		"github.com/pkg/errors@master\n",
+	} {
+		_, err = ParseModuleList([]byte(list))
+		if err == nil {
+			t.Fatalf("invalid module list accepted: %q", list)
+		}
+	}
+}
+
+func TestReadGoMod(t *testing.T) {
+	data := []byte(`module example.com/main
+
+require (
+	github.com/pkg/errors v0.9.1
+	golang.org/x/text v0.3.0 // indirect
+	example.com/local v1.0.0
+)
+
+replace golang.org/x/text => golang.org/x/text v0.3.2
+
+replace example.com/local => ../local
+`)
+	if !IsGoMod(data) || IsGoMod([]byte("github.com/pkg/errors v0.9.1\n")) {
+		t.Fatalf("go.mod not recognized")
+	}
+	mods, err := ReadGoMod(context.Background(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, mod := range mods {
+		got = append(got, fmt.Sprintf("%s %t", mod, mod.Indirect))
+	}
+	wanted := "github.com/pkg/errors v0.9.1 false\n" +
+		"golang.org/x/text v0.3.0 => golang.org/x/text v0.3.2 true\n" +
+		"example.com/local v1.0.0 => ../local false"
+	if strings.Join(got, "\n") != wanted {
+		t.Fatalf("unexpected modules:\n%s", strings.Join(got, "\n"))
+	}
+	licenses, err := ScanModules(context.Background(), mods[2:], &Options{Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(licenses) != 1 || licenses[0].Err != "replaced by local directory ../local" {
+		t.Fatalf("unexpected licenses: %+v", licenses)
+	}
+
+	// Module versions are never looked up outside the module cache.
+	licenses, err = ScanModules(context.Background(), []*modinfo.ModulePublic{
+		{Path: "x", Version: "v1.0.0/../../../.."},
+	}, &Options{Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(licenses) != 1 || licenses[0].Path != "" ||
+		!strings.HasPrefix(licenses[0].Err, "invalid module version") {
+		t.Fatalf("unexpected licenses: %+v", licenses)
+	}
+}
+
+func TestReadBuildList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"main/go.mod": "module example.com/main\n\ngo 1.16\n\n" +
+			"require example.com/local v1.0.0\n\nreplace example.com/local => ../local\n",
+		"local/go.mod": "module example.com/local\n\ngo 1.16\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "main", "go.mod")
+	mods, err := ReadBuildList(context.Background(), path, &Options{Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mods) != 1 || mods[0].Replace == nil ||
+		mods[0].Replace.Path != filepath.Join(dir, "local") {
+		t.Fatalf("unexpected build list: %v", mods)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || string(data) != files["main/go.mod"] {
+		t.Fatalf("go.mod modified: %q, %v", data, err)
+	}
+}
+
+func TestPreviousLicenses(t *testing.T) {
+	opts := &Options{Previous: map[string]report.License{
+		"example.com/a@v1.0.0": {Package: "example.com/a", Version: "v1.0.0",
+			Sum: "h1:a", Path: "LICENSE", Score: 1},
+		"example.com/b@v1.0.0": {Package: "example.com/b", Version: "v1.0.0",
+			Sum: "h1:b", Err: "module is not in the module cache"},
+	}}
+	mods := []*modinfo.ModulePublic{
+		{Path: "example.com/a", Version: "v1.0.0", Sum: "h1:a"},
+		{Path: "example.com/a", Version: "v1.0.0", Sum: "h1:changed"},
+		{Path: "example.com/a", Version: "v1.1.0", Sum: "h1:a"},
+		{Path: "example.com/a", Version: "v1.0.0", Replace: &modinfo.ModulePublic{Path: "../a"}},
+		{Path: "example.com/b", Version: "v1.0.0", Sum: "h1:b"},
+	}
+	got := []bool{}
+	for _, mod := range mods {
+		_, ok := opts.previous(mod)
+		got = append(got, ok)
+	}
+	if fmt.Sprint(got) != "[true false false false false]" {
+		t.Fatalf("unexpected reused licenses: %v", got)
+	}
+	licenses, err := licensesOf(context.Background(), mods[:1], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if licenses[0].Path != "LICENSE" || licenses[0].Err != "" {
+		t.Fatalf("previous license not reused: %+v", licenses[0])
+	}
+}
+
+func TestFindLicenseInLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mod := filepath.Join(dir, "mod")
+	err = os.MkdirAll(filepath.Join(mod, "licence"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"LICENSE.txt", "mod/license.md", "mod/License.md"} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte("license"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"COPYING": "COPYING",
+		"LICENCE": "licence",
+	} {
+		err = os.Symlink(target, filepath.Join(mod, link))
+		if err != nil {
+			t.Skip(err)
+		}
+	}
+	path, err := findLicenseIn(mod, DefaultMaxLicenseSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(mod, "License.md") {
+		t.Fatalf("unexpected license among case variants: %s", path)
+	}
+	err = os.Symlink(filepath.Join("..", "LICENSE.txt"), filepath.Join(mod, "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err = findLicenseIn(mod, DefaultMaxLicenseSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(mod, "LICENSE") {
+		t.Fatalf("symlinked license not found: %s", path)
+	}
+}
+
+func TestScoreLicenseName(t *testing.T) {
+	names := []string{"LICENSE", "UNLICENSE.txt", "COPYING.LESSER",
+		"LICENSE.APACHE2", "LICENCE-APACHE", "LICENSE-APACHE-2.0.txt", "LICENSE_MIT",
+		"MIT-LICENSE.txt", "NOTICE", "license-check.sh", "license.go.tmpl"}
+	scores := []string{}
+	for _, name := range names {
+		scores = append(scores, fmt.Sprintf("%s:%.1f", name, scoreLicenseName(name)))
+	}
+	wanted := "[LICENSE:1.0 UNLICENSE.txt:0.9 COPYING.LESSER:0.8 LICENSE.APACHE2:0.7 " +
+		"LICENCE-APACHE:0.6 LICENSE-APACHE-2.0.txt:0.6 LICENSE_MIT:0.6 " +
+		"MIT-LICENSE.txt:0.5 NOTICE:0.0 license-check.sh:0.0 license.go.tmpl:0.0]"
+	if fmt.Sprint(scores) != wanted {
+		t.Fatalf("unexpected scores: %v", scores)
+	}
+}
+
+func TestFindLicenseInLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string][]byte{
+		"LICENSE":    []byte(strings.Repeat("data ", 100)),
+		"LICENSE.md": {'M', 'Z', 0, 0, 1},
+		"COPYING":    []byte("license"),
+	}
+	for name, data := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	path, err := findLicenseIn(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "COPYING") {
+		t.Fatalf("large or binary license file not skipped: %s", path)
+	}
+	err = checkLicenseFile(filepath.Join(dir, "LICENSE.md"), 100)
+	if err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Fatalf("binary license file not detected: %v", err)
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	mod := &modinfo.ModulePublic{Path: "example.com/a", Version: "v1.0.0"}
+	tests := []struct {
+		err    string
+		wanted string
+	}{
+		{"missing go.sum entry for module providing package example.com/a",
+			`run "go mod download example.com/a@v1.0.0"`},
+		{"module lookup disabled by GOPROXY=off", "downloads are disabled"},
+		{"module is not in the module cache", `run "go mod download example.com/a@v1.0.0"`},
+		{"reading https://proxy.example.com/example.com/a/@v/v1.0.0.mod: 404 Not Found",
+			"does not exist upstream"},
+		{"git ls-remote: fatal: could not read Username for 'https://example.com'",
+			"authentication failed"},
+		{"verifying module: example.com/a@v1.0.0: checksum mismatch", "check go.sum"},
+	}
+	for _, test := range tests {
+		got := errorHint(mod, test.err)
+		if !strings.HasPrefix(got, test.err+" (hint: ") || !strings.Contains(got, test.wanted) {
+			t.Errorf("unexpected hint for %q: %s", test.err, got)
+		}
+	}
+	if got := errorHint(mod, "something else"); got != "something else" {
+		t.Errorf("unexpected hint for unknown error: %s", got)
+	}
+}
+
+func TestWithUnloadedModules(t *testing.T) {
+	mods := map[string]*modinfo.ModulePublic{
+		"example.com/main":   {Path: "example.com/main", Main: true},
+		"example.com/linked": {Path: "example.com/linked", Dir: "a"},
+		"example.com/unused": {Path: "example.com/unused", Dir: "b"},
+		"example.com/outdated": {Path: "example.com/outdated", Dir: "c",
+			Error: &modinfo.ModuleError{Err: "update lookup failed"}},
+		"example.com/missing": {Path: "example.com/missing",
+			Error: &modinfo.ModuleError{Err: "missing go.sum entry"}},
+		"example.com/broken": {Path: "example.com/broken",
+			Error: &modinfo.ModuleError{Err: "module lookup disabled by GOPROXY=off"}},
+	}
+	linked := withUnloadedModules([]*modinfo.ModulePublic{mods["example.com/linked"],
+		mods["example.com/missing"]}, mods)
+	paths := []string{}
+	for _, mod := range linked {
+		paths = append(paths, mod.Path)
+	}
+	wanted := "example.com/linked example.com/missing example.com/broken"
+	if strings.Join(paths, " ") != wanted {
+		t.Fatalf("unexpected modules: %v", paths)
+	}
+}
+
+func TestBatchArgs(t *testing.T) {
+	tests := []struct {
+		args   []string
+		max    int
+		wanted string
+	}{
+		{nil, 10, "[[]]"},
+		{[]string{"a", "b", "c"}, 10, "[[a b c]]"},
+		{[]string{"aaa", "bbb", "ccc"}, 8, "[[aaa bbb] [ccc]]"},
+		{[]string{"aaaaaaaaaa", "b", "c"}, 4, "[[aaaaaaaaaa] [b c]]"},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprint(batchArgs(test.args, test.max)); got != test.wanted {
+			t.Errorf("unexpected batches of %q: %s != %s", test.args, got, test.wanted)
+		}
+	}
+}
+
+func TestScanBatches(t *testing.T) {
+	defer func(max int) { maxArgsLength = max }(maxArgsLength)
+	maxArgsLength = 64
+	pkgs := []string{}
+	for i := 0; i < 20; i++ {
+		pkgs = append(pkgs, "colors/cmd/paint", "colors/cmd/mix")
+	}
+	err := compareTestLicenses(pkgs, []testResult{
+		{Package: "colors/cmd/mix", License: "Academic Free License v3.0", Score: 100},
+		{Package: "colors/cmd/paint", License: "Academic Free License v3.0", Score: 100},
+		{Package: "colors/red", License: "MIT License", Score: 98, Missing: 2},
+		{Package: "couleurs/red", License: "GNU Lesser General Public License v2.1",
+			Score: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package gomod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// moduleMode tells whether the go command runs in module mode with a main
+// module, with supplied environment.
+func moduleMode(ctx context.Context, env []string) (bool, error) {
+	b, err := runGo(ctx, env, "env", "GOMOD")
+	if err != nil {
+		return false, err
+	}
+	gomod := strings.TrimSpace(b.String())
+	return gomod != "" && gomod != os.DevNull, nil
+}
+
+// listPackages returns the non-standard packages in pkgs and their
+// dependencies, in GOPATH mode.
+func listPackages(ctx context.Context, env []string, pkgs []string) ([]*PkgInfo, error) {
+	b, err := runGoBatches(ctx, env, []string{"list", "-e", "-deps", "-json"}, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	infos := []*PkgInfo{}
+	// Packages are listed once per batch of pkgs depending on them.
+	seen := map[string]bool{}
+	dec := json.NewDecoder(b)
+	for {
+		info := &PkgInfo{}
+		err := dec.Decode(info)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("json decode: %s", err)
+		}
+		if !info.Standard && !seen[info.ImportPath] {
+			seen[info.ImportPath] = true
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// findPackageLicenseDir returns the directory of the closest license file of
+// the package, looking in its directory then in its parents up to its GOPATH
+// source root. It returns the package directory if none is found.
+func findPackageLicenseDir(info *PkgInfo, maxSize int64) (string, error) {
+	root := filepath.Join(info.Root, "src")
+	for dir := info.Dir; ; {
+		path, err := findLicenseIn(dir, maxSize)
+		if err != nil {
+			return "", err
+		}
+		if path != "" {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if info.Root == "" || dir == root || parent == dir ||
+			!strings.HasPrefix(parent, root) {
+			return info.Dir, nil
+		}
+		dir = parent
+	}
+}
+
+// scanGOPATH returns the licenses of pkgs and their dependencies in GOPATH
+// mode, one entry per package, sorted by package.
+func scanGOPATH(ctx context.Context, env []string, pkgs []string, opts *Options) ([]report.License, error) {
+	infos, err := listPackages(ctx, env, pkgs)
+	if err != nil {
+		return nil, fmt.Errorf("could not list %s dependencies: %s",
+			strings.Join(pkgs, " "), err)
+	}
+	// Packages are scanned like modules located in their license directory.
+	mods := []*modinfo.ModulePublic{}
+	for _, info := range infos {
+		mod := &modinfo.ModulePublic{Path: info.ImportPath}
+		if info.Dir == "" {
+			msg := "package not found"
+			if info.Error != nil {
+				msg = info.Error.Err
+			}
+			mod.Error = &modinfo.ModuleError{Err: msg}
+		} else {
+			mod.Dir, err = findPackageLicenseDir(info, opts.maxLicenseSize())
+			if err != nil {
+				return nil, err
+			}
+		}
+		mods = append(mods, mod)
+	}
+	if opts.IncludeStd {
+		std, err := stdModule(ctx, env)
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, std)
+	}
+	licenses, err := licensesOf(ctx, mods, opts)
+	if err != nil {
+		return licenses, err
+	}
+	sort.SliceStable(licenses, func(i, j int) bool {
+		return licenses[i].Package < licenses[j].Package
+	})
+	return licenses, nil
+}
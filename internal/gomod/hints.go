@@ -0,0 +1,49 @@
+package gomod
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// errorHints map the module errors reported by the go command and scans to
+// the action fixing them. "%s" stands for the module path and version. Hints
+// do not name flags, which not all commands scanning modules have.
+var errorHints = []struct {
+	re   *regexp.Regexp
+	hint string
+}{
+	{regexp.MustCompile(`missing go\.sum entry`),
+		`run "go mod download %s" or "go mod tidy" to add it`},
+	{regexp.MustCompile(`(?i)checksum mismatch|SECURITY ERROR`),
+		`go.sum does not match the downloaded module: check go.sum, or run "go clean -modcache" if the module cache is corrupted`},
+	{regexp.MustCompile(`(?i)terminal prompts disabled|could not read username|` +
+		`\b401\b|\b403\b|unauthorized|forbidden`),
+		`authentication failed: list private modules in GOPRIVATE and set their credentials in .netrc or GOAUTH`},
+	{regexp.MustCompile(`verifying .*(?:not found|410 Gone)|sum\.golang\.org`),
+		`the checksum database does not know the module: list private modules in GOPRIVATE or GONOSUMDB`},
+	{regexp.MustCompile(`(?i)unknown revision|invalid version|\b404\b|\b410\b|not found`),
+		`the version does not exist upstream: check the go.mod requirements, or list private modules in GOPRIVATE`},
+	{regexp.MustCompile(`GOPROXY=off|GOFLAGS=-mod=vendor|cannot be downloaded offline`),
+		`module downloads are disabled: allow them, or vendor the module`},
+	{regexp.MustCompile(`not in the module cache`),
+		`run "go mod download %s" to fetch it`},
+	{regexp.MustCompile(`(?i)no such host|dial tcp|timeout|timed out|connection refused`),
+		`the network or module proxy is unreachable: check GOPROXY and retry`},
+}
+
+// errorHint returns the error message of mod, followed by the action fixing
+// it when known.
+func errorHint(mod *modinfo.ModulePublic, msg string) string {
+	id := mod.Path
+	if mod.Version != "" {
+		id += "@" + mod.Version
+	}
+	for _, h := range errorHints {
+		if h.re.MatchString(msg) {
+			return msg + " (hint: " + strings.Replace(h.hint, "%s", id, -1) + ")"
+		}
+	}
+	return msg
+}
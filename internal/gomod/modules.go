@@ -0,0 +1,328 @@
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// ParseModuleList parses a list of module versions, one per line, written
+// like "path@version" or "path version". Blank lines and lines starting with
+// "#" are ignored. Module paths and versions are checked with CheckModule.
+func ParseModuleList(data []byte) ([]*modinfo.ModulePublic, error) {
+	mods := []*modinfo.ModulePublic{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 1 {
+			fields = strings.SplitN(line, "@", 2)
+		}
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("line %d: expected a module path and version, got %q",
+				n, line)
+		}
+		if err := CheckModule(fields[0], fields[1]); err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		mods = append(mods, &modinfo.ModulePublic{
+			Path:    fields[0],
+			Version: fields[1],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mods, nil
+}
+
+// IsGoMod returns true if data looks like a go.mod file rather than a module
+// list.
+func IsGoMod(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && fields[0] == "module" {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadGoMod returns the modules required by the go.mod file content data,
+// carrying the replacements it declares. Module paths and versions are
+// checked with CheckModule, except those of local directory replacements.
+func ReadGoMod(ctx context.Context, data []byte) ([]*modinfo.ModulePublic, error) {
+	dir, err := ioutil.TempDir("", "go-licenses-gomod")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "go.mod")
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		return nil, err
+	}
+	b, err := runGo(ctx, nil, "mod", "edit", "-json", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse go.mod: %s", err)
+	}
+	type version struct {
+		Path    string
+		Version string
+	}
+	var gomod struct {
+		Require []struct {
+			Path     string
+			Version  string
+			Indirect bool
+		}
+		Replace []struct {
+			Old version
+			New version
+		}
+	}
+	err = json.Unmarshal(b.Bytes(), &gomod)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse go.mod: %s", err)
+	}
+	mods := []*modinfo.ModulePublic{}
+	for _, req := range gomod.Require {
+		if err := CheckModule(req.Path, req.Version); err != nil {
+			return nil, fmt.Errorf("could not parse go.mod: %s", err)
+		}
+		mod := &modinfo.ModulePublic{
+			Path:     req.Path,
+			Version:  req.Version,
+			Indirect: req.Indirect,
+		}
+		for _, r := range gomod.Replace {
+			if r.Old.Path == req.Path && (r.Old.Version == "" || r.Old.Version == req.Version) {
+				if r.New.Version != "" {
+					if err := CheckModule(r.New.Path, r.New.Version); err != nil {
+						return nil, fmt.Errorf("could not parse go.mod: %s", err)
+					}
+				}
+				mod.Replace = &modinfo.ModulePublic{
+					Path:    r.New.Path,
+					Version: r.New.Version,
+				}
+			}
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
+
+// ReadBuildList returns the build list of the module whose go.mod file is at
+// path, resolved from it and the go.sum file next to it alone, without
+// loading packages: the go.mod files of required modules missing from the
+// module cache are fetched from module proxies. Neither file is modified. The
+// main module is left out.
+func ReadBuildList(ctx context.Context, path string, opts *Options) (
+	[]*modinfo.ModulePublic, error) {
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "go-licenses-gomod")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	gomod := filepath.Join(dir, "go.mod")
+	err = ioutil.WriteFile(gomod, data, 0644)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := ioutil.ReadFile(filepath.Join(filepath.Dir(path), "go.sum"))
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "go.sum"), sum, 0644)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	err = absoluteReplacements(ctx, gomod, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	env := append(goEnv(opts), "GOFLAGS=-mod=mod", "GOWORK=off")
+	b, err := runGoIn(ctx, dir, env, "list", "-e", "-m", "-json", "all")
+	if err != nil {
+		return nil, err
+	}
+	mods := []*modinfo.ModulePublic{}
+	dec := json.NewDecoder(b)
+	for {
+		mod := &modinfo.ModulePublic{}
+		err := dec.Decode(mod)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse build list: %s", err)
+		}
+		if !mod.Main {
+			mods = append(mods, mod)
+		}
+	}
+	return mods, nil
+}
+
+// absoluteReplacements rewrites the local directory replacements of the
+// go.mod file at path, relative to directory dir, as absolute paths.
+func absoluteReplacements(ctx context.Context, path, dir string) error {
+	b, err := runGo(ctx, nil, "mod", "edit", "-json", path)
+	if err != nil {
+		return fmt.Errorf("could not parse go.mod: %s", err)
+	}
+	var gomod struct {
+		Replace []struct {
+			Old struct{ Path, Version string }
+			New struct{ Path, Version string }
+		}
+	}
+	err = json.Unmarshal(b.Bytes(), &gomod)
+	if err != nil {
+		return fmt.Errorf("could not parse go.mod: %s", err)
+	}
+	for _, r := range gomod.Replace {
+		if r.New.Version != "" || filepath.IsAbs(r.New.Path) {
+			continue
+		}
+		old := r.Old.Path
+		if r.Old.Version != "" {
+			old += "@" + r.Old.Version
+		}
+		_, err := runGo(ctx, nil, "mod", "edit",
+			"-replace="+old+"="+filepath.Join(dir, r.New.Path), path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveVersion returns the version of module path designated by query,
+// like "v1.2.3" or "latest", as a module query of the go command. Only the
+// module cache is used with offline.
+func ResolveVersion(ctx context.Context, path, query string, offline bool) (
+	*modinfo.ModulePublic, error) {
+
+	env := []string{"GOFLAGS=-mod=mod"}
+	if offline {
+		env = append(env, offlineEnv...)
+	}
+	b, err := runGo(ctx, env, "list", "-m", "-json", path+"@"+query)
+	if err != nil {
+		return nil, err
+	}
+	mod := &modinfo.ModulePublic{}
+	err = json.Unmarshal(b.Bytes(), mod)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse module %s@%s: %s", path, query, err)
+	}
+	if mod.Error != nil {
+		return nil, fmt.Errorf("%s@%s: %s", path, query, mod.Error.Err)
+	}
+	return mod, nil
+}
+
+// ScanModules returns the licenses of supplied module versions, sorted by
+// module path. Module sources are looked up in the module cache. Modules
+// missing from it are downloaded with opts.Download, or their license files
+// fetched from module proxies with opts.Proxy, unless opts.Offline is set.
+// Others are reported with an error, like modules replaced by local
+// directories. If ctx is done while licenses are matched, the licenses
+// matched so far are returned along with ctx error.
+func ScanModules(ctx context.Context, mods []*modinfo.ModulePublic, opts *Options) (
+	[]report.License, error) {
+
+	log := opts.logger()
+	ctx = withStepTimeout(ctx, opts.StepTimeout)
+	env := goEnv(opts)
+	cacheDir, err := ModuleCacheDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := []*modinfo.ModulePublic{}
+	missing := []report.License{}
+	for _, mod := range mods {
+		src := mod
+		if mod.Replace != nil {
+			src = mod.Replace
+		}
+		if src.Version == "" {
+			// Local directory replacements are not in the cache.
+			missing = append(missing, report.License{
+				Source:  "go",
+				Package: mod.Path,
+				Version: mod.Version,
+				Err:     "replaced by local directory " + src.Path,
+			})
+			continue
+		}
+		dir := filepath.Join(cacheDir, escapePath(src.Path)+"@"+escapePath(src.Version))
+		if !insideDir(cacheDir, dir) {
+			missing = append(missing, report.License{
+				Source:  "go",
+				Package: mod.Path,
+				Version: mod.Version,
+				Err:     fmt.Sprintf("invalid module version %s@%s", src.Path, src.Version),
+			})
+			continue
+		}
+		if _, err := os.Stat(dir); err == nil {
+			mod.Dir = dir
+		}
+		found = append(found, mod)
+	}
+	opts, err = withRemoteLicenses(ctx, found, opts, log)
+	if err != nil {
+		return nil, err
+	}
+	pending := []*modinfo.ModulePublic{}
+	for _, mod := range found {
+		if _, ok := opts.previous(mod); !ok {
+			pending = append(pending, mod)
+		}
+	}
+	err = fetchModules(ctx, env, pending, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, mod := range pending {
+		if mod.Dir == "" && mod.Error == nil && !opts.Offline {
+			mod.Error = &modinfo.ModuleError{Err: "module is not in the module cache"}
+		}
+	}
+	licenses, err := licensesOf(ctx, found, opts)
+	if err != nil {
+		return licenses, err
+	}
+	storeRemoteLicenses(ctx, licenses, found, opts, log)
+	licenses = append(licenses, missing...)
+	sort.SliceStable(licenses, func(i, j int) bool {
+		return licenses[i].Package < licenses[j].Package
+	})
+	return licenses, nil
+}
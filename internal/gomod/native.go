@@ -0,0 +1,149 @@
+package gomod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// cgoPackage is the subset of "go list -json" package output describing the
+// system libraries linked with cgo.
+type cgoPackage struct {
+	ImportPath   string
+	Module       *modinfo.ModulePublic
+	CgoPkgConfig []string
+	CgoLDFLAGS   []string
+}
+
+// linkedLibraries returns the names of the system libraries linked by the
+// package: its "#cgo pkg-config:" names, and "lib" followed by the names of
+// its "-l" linker flags.
+func (p *cgoPackage) linkedLibraries() []string {
+	libs := append([]string{}, p.CgoPkgConfig...)
+	for i, flag := range p.CgoLDFLAGS {
+		name := ""
+		if flag == "-l" && i+1 < len(p.CgoLDFLAGS) {
+			name = p.CgoLDFLAGS[i+1]
+		} else if strings.HasPrefix(flag, "-l") {
+			name = flag[len("-l"):]
+		}
+		if name != "" {
+			libs = append(libs, "lib"+name)
+		}
+	}
+	return libs
+}
+
+// nativeLibraries returns the system libraries linked by the cgo packages of
+// pkgs and their dependencies, mapped to the sorted paths of the modules of
+// the packages linking them, "std" for the standard library.
+func nativeLibraries(ctx context.Context, env []string, pkgs []string) (
+	map[string][]string, error) {
+
+	b, err := runGoBatches(ctx, env, []string{"list", "-deps", "-json"}, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(b)
+	linkers := map[string]map[string]bool{}
+	for {
+		var pkg cgoPackage
+		if err := dec.Decode(&pkg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("json decode: %s", err)
+		}
+		module := "std"
+		if pkg.Module != nil {
+			module = pkg.Module.Path
+		}
+		for _, lib := range pkg.linkedLibraries() {
+			if linkers[lib] == nil {
+				linkers[lib] = map[string]bool{}
+			}
+			linkers[lib][module] = true
+		}
+	}
+	libs := map[string][]string{}
+	for lib, modules := range linkers {
+		for module := range modules {
+			libs[lib] = append(libs[lib], module)
+		}
+		sort.Strings(libs[lib])
+	}
+	return libs, nil
+}
+
+// nativeLicenses returns the system libraries linked by the cgo packages of
+// pkgs and their dependencies, from the "native" source. Their license is
+// unknown: the go command does not tell how libraries are installed.
+func nativeLicenses(ctx context.Context, env []string, pkgs []string) (
+	[]report.License, error) {
+
+	libs, err := nativeLibraries(ctx, env, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	licenses := []report.License{}
+	for lib, modules := range libs {
+		licenses = append(licenses, report.License{
+			Source:   "native",
+			Package:  lib,
+			LinkedBy: modules,
+		})
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		return licenses[i].Package < licenses[j].Package
+	})
+	return licenses, nil
+}
+
+var (
+	// libraryDirs and pkgConfigDirs are the usual locations of system
+	// libraries and pkg-config files, multiarch ones included.
+	libraryDirs = []string{"/lib", "/lib64", "/usr/lib", "/usr/lib64",
+		"/usr/lib/*-linux-*", "/lib/*-linux-*", "/usr/local/lib"}
+	pkgConfigDirs = []string{"/usr/lib/pkgconfig", "/usr/lib64/pkgconfig",
+		"/usr/lib/*-linux-*/pkgconfig", "/usr/share/pkgconfig",
+		"/usr/local/lib/pkgconfig"}
+)
+
+// NativeLibraryFiles returns the files of the system rooted at root which
+// provide the library name, as named in native licenses: its pkg-config file
+// and, for names starting with "lib", its shared or static library. Paths
+// are absolute within root. An empty root designates the running system.
+func NativeLibraryFiles(root, name string) []string {
+	patterns := []string{}
+	for _, dir := range pkgConfigDirs {
+		patterns = append(patterns, filepath.Join(dir, name+".pc"))
+	}
+	if strings.HasPrefix(name, "lib") {
+		for _, dir := range libraryDirs {
+			patterns = append(patterns, filepath.Join(dir, name+".so"),
+				filepath.Join(dir, name+".a"))
+		}
+	}
+	files := []string{}
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(filepath.Join(root, pattern))
+		for _, m := range matches {
+			if root != "" {
+				rel, err := filepath.Rel(root, m)
+				if err != nil {
+					continue
+				}
+				m = "/" + filepath.ToSlash(rel)
+			}
+			files = append(files, m)
+		}
+	}
+	return files
+}
@@ -0,0 +1,55 @@
+package gomod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLinkedLibraries(t *testing.T) {
+	pkg := cgoPackage{
+		CgoPkgConfig: []string{"zlib", "libpng"},
+		CgoLDFLAGS:   []string{"-L/opt/lib", "-lm", "-l", "ssl", "-Wl,-rpath"},
+	}
+	got := pkg.linkedLibraries()
+	wanted := []string{"zlib", "libpng", "libm", "libssl"}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("unexpected libraries: %v != %v", got, wanted)
+	}
+}
+
+func TestNativeLibraryFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-licenses-native")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for _, name := range []string{
+		"usr/lib/x86_64-linux-gnu/libssl.so",
+		"usr/lib/x86_64-linux-gnu/pkgconfig/libssl.pc",
+		"usr/share/pkgconfig/zlib.pc",
+		"usr/lib/zlib.so",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, wanted := range map[string][]string{
+		"libssl": {"/usr/lib/x86_64-linux-gnu/pkgconfig/libssl.pc",
+			"/usr/lib/x86_64-linux-gnu/libssl.so"},
+		// Only names starting with "lib" name library files.
+		"zlib":   {"/usr/share/pkgconfig/zlib.pc"},
+		"libxml": {},
+	} {
+		got := NativeLibraryFiles(root, name)
+		if !reflect.DeepEqual(got, wanted) {
+			t.Errorf("unexpected %s files: %v != %v", name, got, wanted)
+		}
+	}
+}
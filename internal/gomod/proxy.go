@@ -0,0 +1,342 @@
+package gomod
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/cachedir"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// proxyClient fetches module zips. Timeouts turn unreachable proxies into
+// module errors instead of hanging scans.
+var proxyClient = &http.Client{Timeout: 2 * time.Minute}
+
+// proxyEntry is an element of the GOPROXY fallback chain.
+type proxyEntry struct {
+	// URL is the proxy URL, or the "direct" or "off" keyword.
+	URL string
+	// FallbackOnError is set for entries followed by a pipe, which fall back
+	// to the next entry on any error. Entries followed by a comma only fall
+	// back on "not found" answers.
+	FallbackOnError bool
+}
+
+// parseGoproxy parses a GOPROXY value into its fallback chain.
+func parseGoproxy(value string) []proxyEntry {
+	chain := []proxyEntry{}
+	for value != "" {
+		u, sep := value, byte(0)
+		if i := strings.IndexAny(value, ",|"); i >= 0 {
+			u, sep, value = value[:i], value[i], value[i+1:]
+		} else {
+			value = ""
+		}
+		if u = strings.TrimSpace(u); u != "" {
+			chain = append(chain, proxyEntry{
+				URL:             strings.TrimSuffix(u, "/"),
+				FallbackOnError: sep == '|',
+			})
+		}
+	}
+	return chain
+}
+
+// proxyConfig is the module proxy configuration of the go command.
+type proxyConfig struct {
+	Chain []proxyEntry
+	// NoProxy holds the GONOPROXY patterns, which default to GOPRIVATE, of
+	// the modules fetched directly instead of from proxies.
+	NoProxy string
+	Auth    *proxyAuth
+}
+
+// loadProxyConfig returns the module proxy configuration of the go command
+// run with env. opts.ProxyToken authenticates the requests to the proxies
+// set by opts.GOPROXY.
+func loadProxyConfig(ctx context.Context, env []string, opts *Options) (*proxyConfig, error) {
+	b, err := runGo(ctx, env, "env", "GOPROXY", "GONOPROXY", "GOAUTH")
+	if err != nil {
+		return nil, err
+	}
+	values := strings.Split(b.String(), "\n")
+	for len(values) < 3 {
+		values = append(values, "")
+	}
+	c := &proxyConfig{
+		Chain:   parseGoproxy(strings.TrimSpace(values[0])),
+		NoProxy: strings.TrimSpace(values[1]),
+	}
+	tokenURLs := []string{}
+	for _, e := range parseGoproxy(opts.GOPROXY) {
+		tokenURLs = append(tokenURLs, e.URL)
+	}
+	c.Auth = loadProxyAuth(ctx, strings.TrimSpace(values[2]), opts.ProxyToken, tokenURLs)
+	return c, nil
+}
+
+// errDirect is returned by fetchZip when the GOPROXY chain falls back to
+// fetching modules directly from their version control repository.
+var errDirect = errors.New("direct fetch")
+
+// proxyCacheDir returns the directory holding the files extracted from
+// module zips.
+func proxyCacheDir() (string, error) {
+	return cachedir.Path("proxy")
+}
+
+// isRetryableStatus returns true for the HTTP status codes of overloaded or
+// failing proxies, whose requests are worth retrying.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusInternalServerError ||
+		code == http.StatusBadGateway || code == http.StatusServiceUnavailable ||
+		code == http.StatusGatewayTimeout
+}
+
+// fetchZip returns the content of the zip of module path at version from
+// the first proxy of the GOPROXY chain serving it, following the fallback
+// rules of the go command. Requests failing with network errors or retryable
+// status codes are retried up to retries times before falling back. It
+// returns errDirect when the chain reaches "direct".
+func fetchZip(ctx context.Context, proxies *proxyConfig, retries int, path, version string) (
+	[]byte, error) {
+
+	if len(proxies.Chain) == 0 {
+		return nil, fmt.Errorf("no module proxy set in GOPROXY")
+	}
+	var lastErr error
+	for _, proxy := range proxies.Chain {
+		switch proxy.URL {
+		case "direct":
+			return nil, errDirect
+		case "off":
+			return nil, fmt.Errorf("module lookup disabled by GOPROXY=off")
+		}
+		url := proxy.URL + "/" + escapePath(path) + "/@v/" + escapePath(version) + ".zip"
+		var data []byte
+		notFound := false
+		lastErr = retry(ctx, retries, func() (bool, error) {
+			body, resp, err := fetchURL(ctx, url, proxies.Auth, maxZipSize)
+			if err != nil {
+				return true, err
+			}
+			if resp.StatusCode != http.StatusOK {
+				notFound = resp.StatusCode == http.StatusNotFound ||
+					resp.StatusCode == http.StatusGone
+				return isRetryableStatus(resp.StatusCode), fmt.Errorf("%s: %s", url, resp.Status)
+			}
+			data = body
+			return false, nil
+		})
+		if lastErr == nil {
+			return data, nil
+		}
+		if ctx.Err() != nil || (!notFound && !proxy.FallbackOnError) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// maxZipSize is the maximum size of module zips, like the go command limits
+// them.
+const maxZipSize = 500 << 20
+
+// fetchURL returns the body of the response to a GET request of url, within
+// the step timeout of ctx, authenticated with auth if set. Bodies larger than
+// limit bytes are refused.
+func fetchURL(ctx context.Context, url string, auth *proxyAuth, limit int64) (
+	[]byte, *http.Response, error) {
+
+	ctx, cancel := stepContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	auth.apply(req)
+	resp, err := proxyClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		err = fmt.Errorf("%s is larger than %d bytes", url, limit)
+	}
+	return data, resp, err
+}
+
+// hashZip returns the "h1:" hash of the module zip data, as recorded in
+// go.sum files, like dirhash.HashZip of golang.org/x/mod: the SHA-256 of the
+// sorted list of the SHA-256 of each file with its name.
+func hashZip(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	files := map[string]*zip.File{}
+	names := []string{}
+	for _, f := range zr.File {
+		if strings.Contains(f.Name, "\n") {
+			return "", fmt.Errorf("file name %q holds a newline", f.Name)
+		}
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	summary := sha256.New()
+	for _, name := range names {
+		r, err := files[name].Open()
+		if err != nil {
+			return "", err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, r)
+		r.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", h.Sum(nil), name)
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+// extractLicenseFiles writes the license and notice files at the root of the
+// module zip data to dir.
+func extractLicenseFiles(data []byte, path, version, dir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	prefix := path + "@" + version + "/"
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, prefix) {
+			continue
+		}
+		name := f.Name[len(prefix):]
+		if strings.Contains(name, "/") || f.FileInfo().IsDir() ||
+			(scoreLicenseName(name) == 0 && !reNotice.MatchString(name)) {
+			continue
+		}
+		if f.UncompressedSize64 > DefaultMaxLicenseSize {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(filepath.Join(dir, name), io.LimitReader(r, DefaultMaxLicenseSize))
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// directEnv returns a copy of env making the go command fetch modules
+// directly from their version control repository.
+func directEnv(env []string) []string {
+	return append(append([]string{}, env...), "GOPROXY=direct")
+}
+
+// fetchModuleLicenses sets the directory of mod, or its replacement, to a
+// directory holding only the license files of its zip fetched from proxies.
+// Extracted files are kept in a cache. Modules the GOPROXY chain fetches
+// directly, and the private ones matching GONOPROXY, are downloaded with the
+// go command. Failures are recorded in mod.Error.
+func fetchModuleLicenses(ctx context.Context, env []string, proxies *proxyConfig, cacheDir string,
+	mod *modinfo.ModulePublic, opts *Options) {
+
+	src := mod
+	if mod.Replace != nil {
+		src = mod.Replace
+	}
+	if src.Version == "" {
+		return
+	}
+	if matchPrefixPatterns(proxies.NoProxy, src.Path) {
+		downloadModule(ctx, directEnv(env), mod, opts)
+		return
+	}
+	start := time.Now()
+	log := opts.logger()
+	defer func() {
+		log.Debug("fetched module licenses", "module", src.Path, "version", src.Version,
+			"dir", mod.Dir, "elapsed", time.Since(start), "error", errorString(mod.Error))
+	}()
+	dir := filepath.Join(cacheDir, filepath.FromSlash(escapePath(src.Path)+"@"+
+		escapePath(src.Version)))
+	if _, err := os.Stat(dir); err == nil {
+		mod.Dir = dir
+		return
+	}
+	err := func() error {
+		data, err := fetchZip(ctx, proxies, opts.retries(), src.Path, src.Version)
+		if err != nil {
+			return err
+		}
+		if src.Sum != "" {
+			sum, err := hashZip(data)
+			if err != nil {
+				return err
+			}
+			if sum != src.Sum {
+				return fmt.Errorf("zip hash %s does not match go.sum hash %s", sum,
+					src.Sum)
+			}
+		}
+		err = os.MkdirAll(filepath.Dir(dir), 0755)
+		if err != nil {
+			return err
+		}
+		tmp, err := ioutil.TempDir(filepath.Dir(dir), path.Base(dir)+".tmp")
+		if err != nil {
+			return err
+		}
+		err = extractLicenseFiles(data, src.Path, src.Version, tmp)
+		if err == nil {
+			err = os.Rename(tmp, dir)
+		}
+		if err != nil {
+			os.RemoveAll(tmp)
+			if _, serr := os.Stat(dir); serr == nil {
+				// Modules sharing a replacement are fetched concurrently.
+				return nil
+			}
+		}
+		return err
+	}()
+	if err == errDirect {
+		downloadModule(ctx, directEnv(env), mod, opts)
+		return
+	}
+	if err != nil {
+		mod.Error = &modinfo.ModuleError{Err: "could not fetch from proxy: " + err.Error()}
+		return
+	}
+	mod.Dir = dir
+}
@@ -0,0 +1,215 @@
+package gomod
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+func TestFetchModuleLicenses(t *testing.T) {
+	b := &bytes.Buffer{}
+	zw := zip.NewWriter(b)
+	for _, name := range []string{"LICENSE", "NOTICE", "main.go", "sub/LICENSE"} {
+		w, err := zw.Create("example.com/Foo@v1.0.0/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(name))
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/!foo/@v/v1.0.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b.Bytes())
+	}))
+	defer server.Close()
+
+	cacheDir, err := ioutil.TempDir("", "go-licenses-proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	mod := &modinfo.ModulePublic{Path: "example.com/Foo", Version: "v1.0.0"}
+	proxies := &proxyConfig{Chain: []proxyEntry{{URL: server.URL}}}
+	fetchModuleLicenses(context.Background(), nil, proxies, cacheDir, mod, nil)
+	if mod.Error != nil {
+		t.Fatal(mod.Error.Err)
+	}
+	fis, err := ioutil.ReadDir(mod.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	if len(names) != 2 || names[0] != "LICENSE" || names[1] != "NOTICE" {
+		t.Fatalf("unexpected extracted files: %v", names)
+	}
+	if filepath.Dir(mod.Dir) != filepath.Join(cacheDir, "example.com") {
+		t.Fatalf("unexpected directory: %s", mod.Dir)
+	}
+
+	sum, err := hashZip(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != "h1:siDFMkbD9f37lzPSm5omAjEWfGwcW7X3oMPBH6v7Wkk=" {
+		t.Fatalf("unexpected zip hash: %s", sum)
+	}
+	tampered := &modinfo.ModulePublic{Path: "example.com/Foo", Version: "v1.0.0",
+		Sum: "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}
+	fetchModuleLicenses(context.Background(), nil, proxies, t.TempDir(), tampered, nil)
+	if tampered.Error == nil || !strings.Contains(tampered.Error.Err, "does not match go.sum") {
+		t.Fatalf("fetching a module not matching go.sum succeeded: %+v", tampered)
+	}
+	verified := &modinfo.ModulePublic{Path: "example.com/Foo", Version: "v1.0.0", Sum: sum}
+	fetchModuleLicenses(context.Background(), nil, proxies, t.TempDir(), verified, nil)
+	if verified.Error != nil {
+		t.Fatal(verified.Error.Err)
+	}
+
+	missing := &modinfo.ModulePublic{Path: "example.com/bar", Version: "v1.0.0"}
+	fetchModuleLicenses(context.Background(), nil, proxies, cacheDir, missing, nil)
+	if missing.Error == nil || missing.Dir != "" {
+		t.Fatalf("fetching a missing module succeeded: %+v", missing)
+	}
+}
+
+func TestParseGoproxy(t *testing.T) {
+	got := parseGoproxy("https://a.example.com/,https://b.example.com|direct , off")
+	wanted := []proxyEntry{
+		{URL: "https://a.example.com"},
+		{URL: "https://b.example.com", FallbackOnError: true},
+		{URL: "direct"},
+		{URL: "off"},
+	}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("unexpected chain: %+v", got)
+	}
+}
+
+func TestFetchZipFallback(t *testing.T) {
+	defer func(d time.Duration) { retryDelay = d }(retryDelay)
+	retryDelay = time.Millisecond
+	mu := sync.Mutex{}
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests[r.URL.Path]++
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/down/"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.HasPrefix(r.URL.Path, "/flaky/") && requests[r.URL.Path] < 3:
+			w.WriteHeader(http.StatusBadGateway)
+		case strings.HasPrefix(r.URL.Path, "/up/") || strings.HasPrefix(r.URL.Path, "/flaky/"):
+			w.Write([]byte("zip"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		goproxy  string
+		retries  int
+		wanted   string
+		requests int
+	}{
+		{"/missing,/up", 3, "zip", 2},
+		{"/flaky", 3, "zip", 3},
+		{"/flaky", 1, "502 Bad Gateway", 2},
+		{"/down,/up", 1, "503 Service Unavailable", 2},
+		{"/down|/up", 1, "zip", 3},
+		{"/missing,direct", 0, errDirect.Error(), 1},
+		{"/missing,off", 0, "disabled", 1},
+	}
+	for _, test := range tests {
+		requests = map[string]int{}
+		proxies := &proxyConfig{Chain: parseGoproxy(strings.NewReplacer("/", server.URL+"/",
+			",/", ","+server.URL+"/", "|/", "|"+server.URL+"/").Replace(test.goproxy))}
+		data, err := fetchZip(context.Background(), proxies, test.retries, "example.com/a", "v1.0.0")
+		got := string(data)
+		if err != nil {
+			got = err.Error()
+		}
+		if !strings.Contains(got, test.wanted) {
+			t.Errorf("unexpected result with %s and %d retries: %s", test.goproxy,
+				test.retries, got)
+		}
+		n := 0
+		for _, count := range requests {
+			n += count
+		}
+		if n != test.requests {
+			t.Errorf("unexpected number of requests with %s: %d", test.goproxy, n)
+		}
+	}
+}
+
+func TestFetchURLLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	data, _, err := fetchURL(context.Background(), server.URL, nil, 10)
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("unexpected result: %q, %v", data, err)
+	}
+	_, _, err = fetchURL(context.Background(), server.URL, nil, 9)
+	if err == nil || !strings.Contains(err.Error(), "larger than 9 bytes") {
+		t.Fatalf("fetching a body over the limit succeeded: %v", err)
+	}
+}
+
+func TestForEachModule(t *testing.T) {
+	mods := []*modinfo.ModulePublic{}
+	for i := 0; i < 20; i++ {
+		mods = append(mods, &modinfo.ModulePublic{Path: "example.com/a"})
+	}
+	mu := sync.Mutex{}
+	running, max := 0, 0
+	err := forEachModule(context.Background(), mods, 4, func(mod *modinfo.ModulePublic) {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		mod.Dir = "done"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mod := range mods {
+		if mod.Dir != "done" {
+			t.Fatalf("module not processed")
+		}
+	}
+	if max < 2 || max > 4 {
+		t.Fatalf("unexpected number of concurrent jobs: %d", max)
+	}
+}
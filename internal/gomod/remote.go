@@ -0,0 +1,277 @@
+package gomod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/cachedir"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// RemoteCache stores the licenses of module versions on an HTTP server or in
+// a storage bucket shared by scans, like those of a fleet of CI jobs. Entries
+// are read with GET and written with PUT requests, under
+// "{URL}/v1/{module}/@v/{version}.json".
+type RemoteCache struct {
+	// URL is the root of the cache.
+	URL    string
+	Client *http.Client
+	// Token authenticates HTTPS requests as a bearer token, if set. It is
+	// never sent in clear text.
+	Token string
+	// Dir keeps the license and notice files of cached entries, so reports
+	// can read them.
+	Dir string
+
+	// s3 signs the requests of S3 buckets, if set.
+	s3 *s3Credentials
+}
+
+// NewRemoteCache returns a cache at url authenticated with token, if set.
+// url is either an HTTP server URL, an S3 bucket URL like
+// "s3://bucket/prefix", authenticated with the AWS credentials of the
+// environment instead of token, or a Google Cloud Storage bucket URL like
+// "gs://bucket/prefix", accessed with its XML API and token as OAuth access
+// token. token is refused over plain HTTP. License files of cached entries are
+// kept in the user cache directory.
+func NewRemoteCache(url, token string) (*RemoteCache, error) {
+	var s3 *s3Credentials
+	switch {
+	case strings.HasPrefix(url, "s3://"):
+		base, creds, err := s3Bucket(url)
+		if err != nil {
+			return nil, err
+		}
+		url, token, s3 = base, "", creds
+	case strings.HasPrefix(url, "gs://"):
+		if strings.Trim(strings.TrimPrefix(url, "gs://"), "/") == "" {
+			return nil, fmt.Errorf("missing bucket in %s", url)
+		}
+		url = "https://storage.googleapis.com/" + strings.TrimPrefix(url, "gs://")
+	case strings.HasPrefix(url, "https://"):
+	case strings.HasPrefix(url, "http://"):
+		if token != "" {
+			return nil, fmt.Errorf("refusing to send the remote cache token in clear "+
+				"text to %s, use an https URL", url)
+		}
+	default:
+		return nil, fmt.Errorf("remote cache URL must be an https, http, s3 or gs URL: %s",
+			url)
+	}
+	dir, err := cachedir.Path("remote")
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteCache{
+		URL:    strings.TrimSuffix(url, "/"),
+		Client: &http.Client{Timeout: time.Minute},
+		Token:  token,
+		Dir:    dir,
+		s3:     s3,
+	}, nil
+}
+
+// remoteEntry is the content of remote cache entries. License files are
+// stored along with the record, since its paths are only valid on the
+// machine which scanned the module.
+type remoteEntry struct {
+	Record     report.Record `json:"record"`
+	Text       string        `json:"text,omitempty"`
+	NoticeText string        `json:"notice_text,omitempty"`
+}
+
+func (c *RemoteCache) entryURL(path, version string) string {
+	return c.URL + "/v1/" + escapePath(path) + "/@v/" + escapePath(version) + ".json"
+}
+
+func (c *RemoteCache) do(ctx context.Context, method, url string, body []byte) (
+	[]byte, *http.Response, error) {
+
+	ctx, cancel := stepContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.s3 != nil:
+		c.s3.sign(req, body, time.Now())
+	case c.Token != "" && req.URL.Scheme == "https":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, resp, err
+}
+
+// Get returns the cached license of module path at version, and whether it
+// was found. License files are written to c.Dir.
+func (c *RemoteCache) Get(ctx context.Context, path, version string,
+	templates []*matcher.Template) (report.License, bool, error) {
+
+	url := c.entryURL(path, version)
+	data, resp, err := c.do(ctx, "GET", url, nil)
+	if err != nil {
+		return report.License{}, false, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return report.License{}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return report.License{}, false, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	entry := remoteEntry{}
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return report.License{}, false, fmt.Errorf("could not parse %s: %s", url, err)
+	}
+	l := entry.Record.ToLicense(templates)
+	if l.Package != path || l.Version != version {
+		return report.License{}, false, fmt.Errorf("%s holds %s@%s", url, l.Package,
+			l.Version)
+	}
+	dir := filepath.Join(c.Dir, filepath.FromSlash(escapePath(path)+"@"+escapePath(version)))
+	l.Path, err = c.writeFile(dir, l.Path, entry.Text)
+	if err == nil {
+		l.Notice, err = c.writeFile(dir, l.Notice, entry.NoticeText)
+	}
+	if err != nil {
+		return report.License{}, false, err
+	}
+	return l, true, nil
+}
+
+// writeFile writes text to the file of dir named like original, and returns
+// its path, or nothing without original.
+func (c *RemoteCache) writeFile(dir, original, text string) (string, error) {
+	if original == "" {
+		return "", nil
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filepath.Base(original))
+	return path, ioutil.WriteFile(path, []byte(text), 0644)
+}
+
+// Put stores the license l of a module version, with its license files.
+func (c *RemoteCache) Put(ctx context.Context, l report.License) error {
+	entry := remoteEntry{Record: report.NewRecord(l)}
+	for _, f := range []struct {
+		path       string
+		name, text *string
+	}{
+		{l.Path, &entry.Record.Path, &entry.Text},
+		{l.Notice, &entry.Record.Notice, &entry.NoticeText},
+	} {
+		if f.path == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(f.path)
+		if err != nil {
+			return err
+		}
+		*f.text = string(data)
+		// Only the file names are meaningful to other machines.
+		*f.name = filepath.Base(f.path)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	url := c.entryURL(l.Package, l.Version)
+	_, resp, err := c.do(ctx, "PUT", url, data)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("PUT %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// withRemoteLicenses returns opts completed with the licenses of mods found
+// in its remote cache, as previous licenses. Cache failures are logged and
+// the modules scanned.
+func withRemoteLicenses(ctx context.Context, mods []*modinfo.ModulePublic, opts *Options,
+	log *slog.Logger) (*Options, error) {
+
+	if opts.Remote == nil {
+		return opts, nil
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	o := *opts
+	o.Previous = map[string]report.License{}
+	for k, l := range opts.Previous {
+		o.Previous[k] = l
+	}
+	for _, mod := range mods {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		key := mod.Path + "@" + mod.Version
+		if _, ok := o.previous(mod); ok || mod.Main || mod.Version == "" {
+			continue
+		}
+		l, ok, err := o.Remote.Get(ctx, mod.Path, mod.Version, templates)
+		if err != nil {
+			log.Warn("could not read remote cache", "module", mod.Path,
+				"version", mod.Version, "error", err)
+			continue
+		}
+		if ok {
+			o.Previous[key] = l
+		}
+	}
+	return &o, nil
+}
+
+// storeRemoteLicenses stores the licenses of mods which were matched, not
+// reused, in the remote cache of opts. Cache failures are logged.
+func storeRemoteLicenses(ctx context.Context, licenses []report.License,
+	mods []*modinfo.ModulePublic, opts *Options, log *slog.Logger) {
+
+	if opts.Remote == nil {
+		return
+	}
+	byKey := map[string]*modinfo.ModulePublic{}
+	for _, mod := range mods {
+		byKey[mod.Path+"@"+mod.Version] = mod
+	}
+	for _, l := range licenses {
+		mod := byKey[l.Package+"@"+l.Version]
+		if mod == nil || mod.Main || l.Err != "" || l.Sum == "" {
+			continue
+		}
+		if _, ok := opts.previous(mod); ok {
+			continue
+		}
+		err := opts.Remote.Put(ctx, l)
+		if err != nil {
+			log.Warn("could not write remote cache", "module", l.Package,
+				"version", l.Version, "error", err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gomod
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+func TestRemoteCache(t *testing.T) {
+	mu := sync.Mutex{}
+	entries := map[string][]byte{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			data, _ := ioutil.ReadAll(r.Body)
+			entries[r.URL.Path] = data
+		case "GET":
+			data, ok := entries[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "go-licenses-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	licensePath := filepath.Join(dir, "LICENSE.txt")
+	err = ioutil.WriteFile(licensePath, []byte("license text"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewRemoteCache("http://cache.example.com", "secret")
+	if err == nil || !strings.Contains(err.Error(), "clear text") {
+		t.Fatalf("token accepted over plain HTTP: %v", err)
+	}
+	c, err := NewRemoteCache("gs://bucket/licenses", "secret")
+	if err != nil || c.URL != "https://storage.googleapis.com/bucket/licenses" ||
+		c.Token != "secret" {
+		t.Fatalf("unexpected GCS cache: %+v, %v", c, err)
+	}
+	c, err = NewRemoteCache(server.URL+"/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Client = server.Client()
+	c.Dir = filepath.Join(dir, "remote")
+	ctx := context.Background()
+	license := report.License{
+		Source:   "go",
+		Package:  "example.com/Foo",
+		Version:  "v1.0.0",
+		Sum:      "h1:foo",
+		Path:     licensePath,
+		Template: templates[0],
+		Score:    1,
+	}
+	err = c.Put(ctx, license)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entries["/v1/example.com/!foo/@v/v1.0.0.json"]; !ok {
+		t.Fatalf("entry not stored: %v", entries)
+	}
+
+	mods := []*modinfo.ModulePublic{
+		{Path: "example.com/Foo", Version: "v1.0.0", Sum: "h1:foo"},
+		{Path: "example.com/bar", Version: "v1.0.0", Sum: "h1:bar"},
+	}
+	opts, err := withRemoteLicenses(ctx, mods, &Options{Remote: c},
+		(*Options)(nil).logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, ok := opts.previous(mods[0])
+	if !ok || l.Template.Title != templates[0].Title || l.Path != filepath.Join(c.Dir,
+		"example.com", "!foo@v1.0.0", "LICENSE.txt") {
+		t.Fatalf("unexpected cached license: %+v", l)
+	}
+	data, err := ioutil.ReadFile(l.Path)
+	if err != nil || string(data) != "license text" {
+		t.Fatalf("license file not written: %q, %v", data, err)
+	}
+	if _, ok := opts.previous(mods[1]); ok {
+		t.Fatalf("license of uncached module reused")
+	}
+
+	c.Token = "wrong"
+	_, _, err = c.Get(ctx, "example.com/Foo", "v1.0.0", templates)
+	if err == nil {
+		t.Fatalf("failed request not reported")
+	}
+}
+
+func TestS3RemoteCache(t *testing.T) {
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=key/") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+
+	c, err := NewRemoteCache("s3://bucket/licenses", "ignored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok, err := c.Get(context.Background(), "example.com/Foo", "v1.0.0", nil)
+	if err != nil || ok {
+		t.Fatalf("unexpected entry: %t, %v", ok, err)
+	}
+	if strings.Join(paths, " ") != "GET /bucket/licenses/v1/example.com/%21foo/%40v/v1.0.0.json" {
+		t.Fatalf("unexpected requests: %v", paths)
+	}
+}
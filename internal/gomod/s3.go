@@ -0,0 +1,138 @@
+package gomod
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Credentials sign the requests of an S3 bucket with AWS Signature
+// Version 4.
+type s3Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+}
+
+// s3Bucket returns the base URL of the objects of the bucket and prefix of
+// s3 URL u, like "s3://bucket/prefix", and the credentials signing its
+// requests. They are read from the environment variables of the AWS command
+// line: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+// AWS_REGION or AWS_DEFAULT_REGION, and AWS_ENDPOINT_URL_S3 or
+// AWS_ENDPOINT_URL for S3 compatible services, addressed with path-style
+// URLs.
+func s3Bucket(u string) (string, *s3Credentials, error) {
+	bucket := strings.TrimPrefix(u, "s3://")
+	prefix := ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, prefix = bucket[:i], strings.Trim(bucket[i:], "/")
+	}
+	if bucket == "" {
+		return "", nil, fmt.Errorf("missing bucket in %s", u)
+	}
+	creds := &s3Credentials{
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		Region:       os.Getenv("AWS_REGION"),
+	}
+	if creds.AccessKey == "" || creds.SecretKey == "" {
+		return "", nil, fmt.Errorf("%s requires AWS_ACCESS_KEY_ID and "+
+			"AWS_SECRET_ACCESS_KEY to be set", u)
+	}
+	if creds.Region == "" {
+		creds.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if creds.Region == "" {
+		creds.Region = "us-east-1"
+	}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	base := "https://" + bucket + ".s3." + creds.Region + ".amazonaws.com"
+	if endpoint != "" {
+		base = strings.TrimSuffix(endpoint, "/") + "/" + bucket
+	}
+	if prefix != "" {
+		base += "/" + prefix
+	}
+	return base, creds, nil
+}
+
+// s3Encode encodes s like AWS Signature Version 4 does: all bytes but
+// unreserved characters are percent-encoded, and slashes too unless path.
+func s3Encode(s string, path bool) string {
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			strings.IndexByte("-._~", c) >= 0 || path && c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sign sets the headers authenticating req, whose body is body, at time now.
+// The host, the Content-Type and Range headers and the x-amz-* headers are
+// signed.
+func (c *s3Credentials) sign(req *http.Request, body []byte, now time.Time) {
+	date := now.UTC().Format("20060102T150405Z")
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+	// The request is sent with the path it is signed with.
+	path := s3Encode(req.URL.Path, true)
+	req.URL.RawPath = path
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+		}
+	}
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonical := strings.Builder{}
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+	request := strings.Join([]string{req.Method, path, req.URL.RawQuery,
+		canonical.String(), signed, payloadHash}, "\n")
+	requestSum := sha256.Sum256([]byte(request))
+	scope := date[:8] + "/" + c.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" +
+		hex.EncodeToString(requestSum[:])
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), date[:8])
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.AccessKey+"/"+
+		scope+", SignedHeaders="+signed+", Signature="+
+		hex.EncodeToString(hmacSHA256(key, toSign)))
+}
@@ -0,0 +1,141 @@
+package gomod
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/cachedir"
+)
+
+// maxStubSize bounds the size of license files checked for references to
+// the actual license. Longer files are license texts.
+const maxStubSize = 512
+
+var (
+	// reStubFile matches references to another license file of the module,
+	// like the "SEE LICENSE IN LICENSE.txt" of npm packages.
+	reStubFile = regexp.MustCompile(`(?i)^\s*(?:see|refer to)\s+(?:the\s+)?licen[sc]e\s+` +
+		`(?:in|at)\s+(?:the\s+)?(?:file\s+)?["'` + "`" + `]?([^\s"'` + "`" + `<>]+?)["'` + "`" + `]?\.?\s*$`)
+	// reStubURL matches URLs in license files.
+	reStubURL = regexp.MustCompile(`https?://[^\s"'<>()]+`)
+)
+
+// stubReference returns the file or URL referenced by the license file whose
+// content is data, if it is a short stub pointing to the actual license
+// instead of holding it. Stubs mentioning several URLs are not references.
+func stubReference(data []byte) (string, string) {
+	if len(data) > maxStubSize {
+		return "", ""
+	}
+	text := strings.TrimSpace(string(data))
+	if m := reStubFile.FindStringSubmatch(text); m != nil && !reStubURL.MatchString(m[1]) {
+		return m[1], ""
+	}
+	urls := reStubURL.FindAllString(text, -1)
+	if len(urls) != 1 || len(strings.Fields(text)) > 20 {
+		return "", ""
+	}
+	return "", strings.TrimRight(urls[0], ".,;:")
+}
+
+// rawURL returns the URL of the raw content of files displayed by url on
+// GitHub, url itself otherwise.
+func rawURL(url string) string {
+	const prefix = "https://github.com/"
+	if !strings.HasPrefix(url, prefix) {
+		return url
+	}
+	parts := strings.SplitN(strings.TrimPrefix(url, prefix), "/", 4)
+	if len(parts) < 4 || parts[2] != "blob" {
+		return url
+	}
+	return "https://raw.githubusercontent.com/" + parts[0] + "/" + parts[1] + "/" + parts[3]
+}
+
+// stubCacheDir returns the directory holding the license texts fetched from
+// the URLs of license stubs.
+func stubCacheDir() (string, error) {
+	return cachedir.Path("stubs")
+}
+
+// fetchStub downloads the license text at url into the user cache directory
+// and returns its path. Texts are fetched once per URL.
+func fetchStub(ctx context.Context, url string) (string, error) {
+	dir, err := stubCacheDir()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(url))
+	path := filepath.Join(dir, hex.EncodeToString(hash[:16])+".txt")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	data, resp, err := fetchURL(ctx, rawURL(url), nil, DefaultMaxLicenseSize)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not fetch %s: %s", url, resp.Status)
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return "", fmt.Errorf("%s is a web page, not a license text", url)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(path, data, 0644)
+}
+
+// followStub returns the path of the license text referenced by the license
+// file at path, in module directory dir, along with the URL of remote texts.
+// Files of the module are followed, once and without leaving dir. Remote
+// texts are fetched with fetch, otherwise the returned path is empty. The
+// path itself is returned if it is not a stub.
+func followStub(ctx context.Context, dir, path string, fetch bool) (string, string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", "", err
+	}
+	if fi.Size() > maxStubSize {
+		return path, "", nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	file, url := stubReference(data)
+	switch {
+	case file != "":
+		target := filepath.Join(filepath.Dir(path), filepath.FromSlash(file))
+		rel, err := filepath.Rel(dir, target)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", "", fmt.Errorf("license file %s refers to %s, outside the module",
+				filepath.Base(path), file)
+		}
+		fi, err := os.Stat(target)
+		if err != nil || !fi.Mode().IsRegular() {
+			return "", "", fmt.Errorf("license file %s refers to missing file %s",
+				filepath.Base(path), file)
+		}
+		return target, "", nil
+	case url != "":
+		if !fetch {
+			return "", url, nil
+		}
+		target, err := fetchStub(ctx, url)
+		if err != nil {
+			return "", url, err
+		}
+		return target, url, nil
+	}
+	return path, "", nil
+}
@@ -0,0 +1,93 @@
+package gomod
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStubReference(t *testing.T) {
+	tests := []struct {
+		text string
+		file string
+		url  string
+	}{
+		{"SEE LICENSE IN LICENSE.txt", "LICENSE.txt", ""},
+		{"See the license in `docs/LICENSE`.\n", "docs/LICENSE", ""},
+		{"Licensed under the MIT license, see https://opensource.org/licenses/MIT.",
+			"", "https://opensource.org/licenses/MIT"},
+		{"https://a.example.com/LICENSE https://b.example.com/LICENSE", "", ""},
+		{"Permission is hereby granted, free of charge, to any person.", "", ""},
+	}
+	for _, test := range tests {
+		file, url := stubReference([]byte(test.text))
+		if file != test.file || url != test.url {
+			t.Errorf("%q: got %q %q, wanted %q %q", test.text, file, url, test.file, test.url)
+		}
+	}
+}
+
+func TestFollowStub(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/o/r/main/LICENSE" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("MIT License"))
+	}))
+	defer server.Close()
+
+	mod := filepath.Join(dir, "mod")
+	err = os.MkdirAll(filepath.Join(mod, "docs"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"LICENSE":          "SEE LICENSE IN docs/LICENSE.txt",
+		"docs/LICENSE.txt": "MIT License",
+		"COPYING":          "SEE LICENSE IN ../LICENSE",
+		"LICENSE.md":       "See " + server.URL + "/o/r/main/LICENSE",
+	}
+	for name, text := range files {
+		err = ioutil.WriteFile(filepath.Join(mod, name), []byte(text), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	path, _, err := followStub(ctx, mod, filepath.Join(mod, "LICENSE"), false)
+	if err != nil || path != filepath.Join(mod, "docs", "LICENSE.txt") {
+		t.Fatalf("local reference not followed: %q, %v", path, err)
+	}
+	_, _, err = followStub(ctx, mod, filepath.Join(mod, "COPYING"), false)
+	if err == nil {
+		t.Fatalf("reference outside the module followed")
+	}
+	path, url, err := followStub(ctx, mod, filepath.Join(mod, "LICENSE.md"), false)
+	if err != nil || path != "" || url != server.URL+"/o/r/main/LICENSE" {
+		t.Fatalf("unexpected remote reference: %q %q, %v", path, url, err)
+	}
+	path, _, err = followStub(ctx, mod, filepath.Join(mod, "LICENSE.md"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || string(data) != "MIT License" {
+		t.Fatalf("unexpected fetched license: %q, %v", data, err)
+	}
+	if rawURL("https://github.com/o/r/blob/main/LICENSE") !=
+		"https://raw.githubusercontent.com/o/r/main/LICENSE" {
+		t.Fatalf("unexpected raw URL")
+	}
+}
@@ -0,0 +1,137 @@
+package gomod
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// rePseudoVersion matches pseudo-versions and captures their commit hash.
+var rePseudoVersion = regexp.MustCompile(`\d{14}-([0-9a-f]{12})(?:\+incompatible)?$`)
+
+// reMajorSuffix matches major version suffixes of module paths.
+var reMajorSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// repoURL returns the repository URL of module path and the module
+// subdirectory in it, for well-known hosts.
+func repoURL(modPath string) (string, string) {
+	parts := strings.Split(modPath, "/")
+	switch parts[0] {
+	case "github.com", "gitlab.com", "bitbucket.org":
+		if len(parts) < 3 {
+			return "", ""
+		}
+		sub := parts[3:]
+		if len(sub) > 0 && reMajorSuffix.MatchString(sub[len(sub)-1]) {
+			// Major version suffixes are usually not directories.
+			sub = sub[:len(sub)-1]
+		}
+		return "https://" + strings.Join(parts[:3], "/"), strings.Join(sub, "/")
+	case "golang.org":
+		if len(parts) < 3 || parts[1] != "x" {
+			return "", ""
+		}
+		return "https://github.com/golang/" + parts[2], strings.Join(parts[3:], "/")
+	}
+	return "", ""
+}
+
+// Repository returns the repository URL of module path at version, the module
+// subdirectory in it and the git reference of the version: a commit hash for
+// pseudo-versions, a tag otherwise. The repository URL is empty for modules
+// not hosted on well-known hosts.
+func Repository(modPath, version string) (string, string, string) {
+	repo, sub := repoURL(modPath)
+	if repo == "" {
+		return "", "", ""
+	}
+	if m := rePseudoVersion.FindStringSubmatch(version); m != nil {
+		return repo, sub, m[1]
+	}
+	ref := strings.TrimSuffix(version, "+incompatible")
+	if sub != "" {
+		ref = sub + "/" + ref
+	}
+	return repo, sub, ref
+}
+
+// blobURL returns the URL displaying file at ref in repository repo.
+func blobURL(repo, ref, file string) string {
+	switch {
+	case strings.HasPrefix(repo, "https://gitlab.com/"):
+		return repo + "/-/blob/" + ref + "/" + file
+	case strings.HasPrefix(repo, "https://bitbucket.org/"):
+		return repo + "/src/" + ref + "/" + file
+	}
+	return repo + "/blob/" + ref + "/" + file
+}
+
+// readOrigin returns the origin of src, mod or its replacement, recorded in
+// the module cache holding mod, or nil. "go list -m" does not report origins,
+// but the go command stores them in the .info files of downloaded versions.
+func readOrigin(mod, src *modinfo.ModulePublic) *modinfo.Origin {
+	suffix := string(filepath.Separator) + escapePath(src.Path) + "@" + escapePath(src.Version)
+	if src.Version == "" || !strings.HasSuffix(mod.Dir, suffix) {
+		return nil
+	}
+	cacheDir := strings.TrimSuffix(mod.Dir, suffix)
+	data, err := ioutil.ReadFile(filepath.Join(cacheDir, "cache", "download",
+		escapePath(src.Path), "@v", escapePath(src.Version)+".info"))
+	if err != nil {
+		return nil
+	}
+	info := struct {
+		Origin *modinfo.Origin
+	}{}
+	if json.Unmarshal(data, &info) != nil {
+		return nil
+	}
+	return info.Origin
+}
+
+// moduleVCS returns the upstream revision of mod, or its replacement, as
+// recorded in its origin by the go command, or nil. Origins missing from mod
+// are read from the module cache.
+func moduleVCS(mod *modinfo.ModulePublic) *report.VCS {
+	src := mod
+	if mod.Replace != nil {
+		src = mod.Replace
+	}
+	if src.Origin == nil {
+		src.Origin = readOrigin(mod, src)
+	}
+	o := src.Origin
+	if o == nil || o.VCS == "" || o.URL == "" {
+		return nil
+	}
+	return &report.VCS{Type: o.VCS, URL: o.URL, Subdir: o.Subdir, Ref: o.Ref, Hash: o.Hash}
+}
+
+// licenseURL returns a URL to the license file named name at the root of
+// mod, or its replacement, at its version. Files of modules hosted on
+// GitHub, GitLab or Bitbucket are linked at their tag or commit, others are
+// linked to their pkg.go.dev licenses tab. It returns an empty string for
+// modules without version, like the main module.
+func licenseURL(mod *modinfo.ModulePublic, name string) string {
+	src := mod
+	if mod.Replace != nil {
+		src = mod.Replace
+	}
+	if src.Version == "" || name == "" {
+		return ""
+	}
+	repo, sub, ref := Repository(src.Path, src.Version)
+	if o := src.Origin; o != nil && o.Hash != "" && strings.HasPrefix(o.URL, "https://") {
+		repo, sub, ref = strings.TrimSuffix(o.URL, ".git"), o.Subdir, o.Hash
+	}
+	if repo == "" {
+		return "https://pkg.go.dev/" + src.Path + "@" + src.Version + "?tab=licenses"
+	}
+	return blobURL(repo, ref, path.Join(sub, name))
+}
@@ -0,0 +1,77 @@
+package gomod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+func TestLicenseURL(t *testing.T) {
+	tests := []struct {
+		Mod  modinfo.ModulePublic
+		Name string
+		URL  string
+	}{
+		{modinfo.ModulePublic{Path: "github.com/a/b", Version: "v1.2.0"}, "LICENSE",
+			"https://github.com/a/b/blob/v1.2.0/LICENSE"},
+		{modinfo.ModulePublic{Path: "github.com/a/b/v2", Version: "v2.0.0+incompatible"},
+			"LICENSE", "https://github.com/a/b/blob/v2.0.0/LICENSE"},
+		{modinfo.ModulePublic{Path: "github.com/a/b/c", Version: "v0.1.0"}, "COPYING",
+			"https://github.com/a/b/blob/c/v0.1.0/c/COPYING"},
+		{modinfo.ModulePublic{Path: "gitlab.com/a/b",
+			Version: "v0.0.0-20200102030405-0123456789ab"}, "LICENSE",
+			"https://gitlab.com/a/b/-/blob/0123456789ab/LICENSE"},
+		{modinfo.ModulePublic{Path: "golang.org/x/text", Version: "v0.3.0"}, "LICENSE",
+			"https://github.com/golang/text/blob/v0.3.0/LICENSE"},
+		{modinfo.ModulePublic{Path: "example.com/a", Version: "v1.0.0"}, "LICENSE",
+			"https://pkg.go.dev/example.com/a@v1.0.0?tab=licenses"},
+		{modinfo.ModulePublic{Path: "example.com/a", Version: "v1.0.0",
+			Origin: &modinfo.Origin{VCS: "git", URL: "https://git.example.com/a.git",
+				Hash: "abcdef"}}, "LICENSE",
+			"https://git.example.com/a/blob/abcdef/LICENSE"},
+		{modinfo.ModulePublic{Path: "example.com/main", Main: true}, "LICENSE", ""},
+	}
+	for _, test := range tests {
+		url := licenseURL(&test.Mod, test.Name)
+		if url != test.URL {
+			t.Errorf("unexpected %s URL: %q != %q", test.Mod.Path, url, test.URL)
+		}
+	}
+}
+
+func TestModuleVCS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	info := filepath.Join(dir, "cache", "download", "example.com", "!a", "@v", "v1.0.0.info")
+	err = os.MkdirAll(filepath.Dir(info), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(info, []byte(`{"Version":"v1.0.0","Origin":{"VCS":"git",`+
+		`"URL":"https://git.example.com/a","Subdir":"sub","Ref":"refs/tags/sub/v1.0.0",`+
+		`"Hash":"abcdef"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := &modinfo.ModulePublic{Path: "example.com/A", Version: "v1.0.0",
+		Dir: filepath.Join(dir, "example.com", "!a@v1.0.0")}
+	vcs := moduleVCS(mod)
+	if vcs == nil || vcs.DownloadLocation() != "git+https://git.example.com/a@abcdef#sub" ||
+		vcs.Ref != "refs/tags/sub/v1.0.0" {
+		t.Fatalf("unexpected VCS: %+v", vcs)
+	}
+	if mod.Origin == nil {
+		t.Fatalf("origin not recorded in module")
+	}
+	mod = &modinfo.ModulePublic{Path: "example.com/b", Version: "v1.0.0",
+		Dir: filepath.Join(dir, "example.com", "b@v1.0.0")}
+	if vcs := moduleVCS(mod); vcs != nil {
+		t.Fatalf("unexpected VCS without origin: %+v", vcs)
+	}
+}
@@ -0,0 +1,173 @@
+package gomod
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Reason describes how a module is reached from scanned packages.
+type Reason struct {
+	// Imports lists the shortest import chains from scanned packages to a
+	// package of the module, one per scanned package reaching it.
+	Imports [][]string
+	// Requires is the shortest requirement chain from the main module to the
+	// module, as "path@version" nodes of the module graph. It is only set
+	// when no scanned package imports the module.
+	Requires []string
+}
+
+// goPackage is a package listed by listPackageGraph.
+type goPackage struct {
+	Module  string
+	Root    bool
+	Imports []string
+}
+
+// listPackageGraph returns the packages matched by pkgs and their
+// dependencies, by import path.
+func listPackageGraph(ctx context.Context, env []string, pkgs []string) (map[string]*goPackage, error) {
+	args := []string{"list", "-deps", "-f",
+		`{{.ImportPath}}	{{with .Module}}{{.Path}}{{end}}	{{not .DepOnly}}	{{join .Imports " "}}`}
+	b, err := runGoBatches(ctx, env, args, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	graph := map[string]*goPackage{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		// Packages matched by a batch of pkgs may be dependencies of another.
+		root := fields[2] == "true"
+		if pkg, ok := graph[fields[0]]; ok {
+			root = root || pkg.Root
+		}
+		graph[fields[0]] = &goPackage{
+			Module:  fields[1],
+			Root:    root,
+			Imports: strings.Fields(fields[3]),
+		}
+	}
+	return graph, nil
+}
+
+// inModule returns true if the package at path belongs to module. Packages
+// listed in GOPATH mode have no module and are matched by import path.
+func inModule(path string, pkg *goPackage, module string) bool {
+	if pkg.Module != "" {
+		return pkg.Module == module
+	}
+	return path == module || strings.HasPrefix(path, module+"/")
+}
+
+// importChain returns the shortest import chain from root to a package of
+// module, or nil.
+func importChain(graph map[string]*goPackage, root, module string) []string {
+	parents := map[string]string{root: ""}
+	queue := []string{root}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		pkg := graph[path]
+		if pkg == nil {
+			continue
+		}
+		if inModule(path, pkg, module) {
+			chain := []string{}
+			for ; path != ""; path = parents[path] {
+				chain = append([]string{path}, chain...)
+			}
+			return chain
+		}
+		for _, imp := range pkg.Imports {
+			if _, ok := parents[imp]; !ok {
+				parents[imp] = path
+				queue = append(queue, imp)
+			}
+		}
+	}
+	return nil
+}
+
+// requireChain returns the shortest chain of the module graph from its first
+// node, the main module, to any version of module, or nil.
+func requireChain(graph map[string][]string, main, module string) []string {
+	parents := map[string]string{main: ""}
+	queue := []string{main}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if strings.SplitN(node, "@", 2)[0] == module && node != main {
+			chain := []string{}
+			for ; node != ""; node = parents[node] {
+				chain = append([]string{node}, chain...)
+			}
+			return chain
+		}
+		for _, req := range graph[node] {
+			if _, ok := parents[req]; !ok {
+				parents[req] = node
+				queue = append(queue, req)
+			}
+		}
+	}
+	return nil
+}
+
+// Why returns how module is reached from the packages matched
+// by pkgs, or the packages of the current module if pkgs is empty: the
+// import chains leading to its packages or, if it is not imported, the
+// requirements leading to it.
+func Why(ctx context.Context, module string, pkgs []string, opts *Options) (*Reason, error) {
+	env := goEnv(opts)
+	if opts.GOPATH != "" {
+		env = append(env, "GOPATH="+opts.GOPATH, "GO111MODULE=off")
+	}
+	if len(pkgs) == 0 {
+		pkgs = []string{"./..."}
+	}
+	graph, err := listPackageGraph(ctx, env, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	roots := []string{}
+	for path, pkg := range graph {
+		if pkg.Root {
+			roots = append(roots, path)
+		}
+	}
+	sort.Strings(roots)
+	why := &Reason{Imports: [][]string{}}
+	for _, root := range roots {
+		chain := importChain(graph, root, module)
+		if chain != nil {
+			why.Imports = append(why.Imports, chain)
+		}
+	}
+	sort.SliceStable(why.Imports, func(i, j int) bool {
+		return len(why.Imports[i]) < len(why.Imports[j])
+	})
+	if len(why.Imports) > 0 || opts.GOPATH != "" {
+		return why, nil
+	}
+	modules, err := moduleMode(ctx, env)
+	if err != nil || !modules {
+		return why, err
+	}
+	b, err := runGo(ctx, env, "list", "-m")
+	if err != nil {
+		return nil, err
+	}
+	mains := strings.Fields(b.String())
+	if len(mains) == 0 {
+		return why, nil
+	}
+	requires, err := moduleGraph(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	why.Requires = requireChain(requires, mains[0], module)
+	return why, nil
+}
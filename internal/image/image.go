@@ -0,0 +1,375 @@
+// Package image unpacks container images into a filesystem tree so their
+// content can be scanned.
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/rootfs"
+)
+
+// Unpack extracts the layers of the image designated by ref into dir, which
+// must exist. ref is either an OCI image layout directory, an image archive
+// written by "docker save" or in OCI format, or an image reference which is
+// then exported with docker, and pulled first if necessary.
+func Unpack(ref, dir string) error {
+	fi, err := os.Stat(ref)
+	if err == nil && fi.IsDir() {
+		return unpackLayout(ref, dir)
+	}
+	if err == nil {
+		return unpackArchive(ref, dir)
+	}
+	archive, err := ioutil.TempFile("", "go-licenses-image")
+	if err != nil {
+		return err
+	}
+	archive.Close()
+	defer os.Remove(archive.Name())
+	err = dockerSave(ref, archive.Name())
+	if err != nil {
+		return err
+	}
+	return unpackArchive(archive.Name(), dir)
+}
+
+func runDocker(args ...string) error {
+	cmd := exec.Command("docker", args...)
+	var berr bytes.Buffer
+	cmd.Stderr = &berr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("'docker %s' failed with:\n%s",
+			strings.Join(args, " "), berr.String())
+	}
+	return nil
+}
+
+// dockerSave exports image ref to archive path, pulling it if it is not
+// available locally.
+func dockerSave(ref, path string) error {
+	err := runDocker("image", "inspect", ref)
+	if err != nil {
+		err = runDocker("pull", ref)
+		if err != nil {
+			return err
+		}
+	}
+	return runDocker("save", "-o", path, ref)
+}
+
+// unpackArchive extracts the image archive at path into a temporary layout
+// directory, then unpacks it.
+func unpackArchive(path, dir string) error {
+	layout, err := ioutil.TempDir("", "go-licenses-layout")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(layout)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = extractFiles(f, layout)
+	if err != nil {
+		return fmt.Errorf("could not extract %s: %s", path, err)
+	}
+	return unpackLayout(layout, dir)
+}
+
+// extractFiles extracts the regular files and directories of a tar stream.
+func extractFiles(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name, ok := cleanName(h.Name)
+		if !ok || h.Typeflag != tar.TypeReg {
+			continue
+		}
+		p := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			return err
+		}
+		err = writeFile(p, tr, 0644)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type dockerManifest struct {
+	Layers []string
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// digestRe matches the digests of OCI content descriptors.
+var digestRe = regexp.MustCompile(`^[a-z0-9]+(?:[+._-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+
+// blobPath returns the path of the blob with digest in layout directory, or
+// an error if digest is malformed.
+func blobPath(layout, digest string) (string, error) {
+	if !digestRe.MatchString(digest) {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	return filepath.Join(layout, "blobs", strings.Replace(digest, ":", "/", 1)), nil
+}
+
+// layerPaths returns the paths of the layers of the single image in layout
+// directory, bottom first. Both docker and OCI layouts are supported.
+func layerPaths(layout string) ([]string, error) {
+	manifests := []dockerManifest{}
+	err := readJSON(filepath.Join(layout, "manifest.json"), &manifests)
+	if err == nil {
+		if len(manifests) != 1 {
+			return nil, fmt.Errorf("expected one image, got %d", len(manifests))
+		}
+		paths := []string{}
+		for _, l := range manifests[0].Layers {
+			name := path.Clean(l)
+			if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+				return nil, fmt.Errorf("layer %q is outside the image", l)
+			}
+			paths = append(paths, filepath.Join(layout, filepath.FromSlash(name)))
+		}
+		return paths, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	index := ociIndex{}
+	err = readJSON(filepath.Join(layout, "index.json"), &index)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if len(index.Manifests) != 1 {
+			return nil, fmt.Errorf("expected one image, got %d", len(index.Manifests))
+		}
+		desc := index.Manifests[0]
+		if !strings.Contains(desc.MediaType, "index") &&
+			!strings.Contains(desc.MediaType, "manifest.list") {
+			break
+		}
+		// Nested index, like the ones of multi-platform images.
+		index = ociIndex{}
+		p, err := blobPath(layout, desc.Digest)
+		if err == nil {
+			err = readJSON(p, &index)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	manifest := ociManifest{}
+	p, err := blobPath(layout, index.Manifests[0].Digest)
+	if err == nil {
+		err = readJSON(p, &manifest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, l := range manifest.Layers {
+		p, err := blobPath(layout, l.Digest)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+func unpackLayout(layout, dir string) error {
+	paths, err := layerPaths(layout)
+	if err != nil {
+		return fmt.Errorf("could not read image manifest: %s", err)
+	}
+	for _, p := range paths {
+		err := unpackLayer(p, dir)
+		if err != nil {
+			return fmt.Errorf("could not unpack layer %s: %s", p, err)
+		}
+	}
+	return nil
+}
+
+// openLayer returns a reader over the uncompressed tar stream of the layer at
+// path.
+func openLayer(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{zr, f}, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{br, f}, nil
+}
+
+// cleanName returns the cleaned relative path of a tar entry and false if it
+// escapes the extraction directory.
+func cleanName(name string) (string, bool) {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return "", false
+	}
+	return filepath.FromSlash(name[1:]), true
+}
+
+// unpackLayer applies the layer at path on dir, honoring whiteout files.
+func unpackLayer(path, dir string) error {
+	r, err := openLayer(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name, ok := cleanName(h.Name)
+		if !ok {
+			continue
+		}
+		// Symbolic links created by previous entries are resolved within
+		// dir, so entries below them are never written outside of it.
+		parent, err := rootfs.Resolve(dir, filepath.Dir(name))
+		if err != nil {
+			return err
+		}
+		base := filepath.Base(name)
+		p := filepath.Join(parent, base)
+		if base == ".wh..wh..opq" {
+			// Opaque whiteout: hide the lower layers directory content.
+			fis, _ := ioutil.ReadDir(parent)
+			for _, fi := range fis {
+				os.RemoveAll(filepath.Join(parent, fi.Name()))
+			}
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			os.RemoveAll(filepath.Join(parent, base[len(".wh."):]))
+			continue
+		}
+		err = os.MkdirAll(parent, 0755)
+		if err != nil {
+			return err
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(p, 0755)
+		case tar.TypeReg:
+			os.RemoveAll(p)
+			err = writeFile(p, tr, os.FileMode(h.Mode)&0755|0600)
+		case tar.TypeSymlink:
+			os.RemoveAll(p)
+			// Links are left unresolved, rootfs.Resolve handles them.
+			err = os.Symlink(h.Linkname, p)
+		case tar.TypeLink:
+			target, ok := cleanName(h.Linkname)
+			if !ok {
+				continue
+			}
+			targetParent, err := rootfs.Resolve(dir, filepath.Dir(target))
+			if err != nil {
+				return err
+			}
+			os.RemoveAll(p)
+			err = os.Link(filepath.Join(targetParent, filepath.Base(target)), p)
+			if err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// FindBinaries returns the paths of the executable regular files of the
+// filesystem tree at dir for which isBinary returns true.
+func FindBinaries(dir string, isBinary func(path string) bool) ([]string, error) {
+	paths := []string{}
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() && fi.Mode()&0111 != 0 && isBinary(p) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	return paths, err
+}
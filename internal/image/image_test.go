@@ -0,0 +1,210 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	Name     string
+	Content  string
+	Linkname string
+}
+
+func writeTar(t *testing.T, path string, compress bool, entries []tarEntry) {
+	b := &bytes.Buffer{}
+	tw := tar.NewWriter(b)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.Name, Mode: 0644, Size: int64(len(e.Content))}
+		switch {
+		case strings.HasSuffix(e.Name, "/"):
+			h.Typeflag = tar.TypeDir
+			h.Mode = 0755
+		case e.Linkname != "":
+			h.Typeflag = tar.TypeSymlink
+			h.Linkname = e.Linkname
+		default:
+			h.Typeflag = tar.TypeReg
+		}
+		err := tw.WriteHeader(h)
+		if err == nil {
+			_, err = tw.Write([]byte(e.Content))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	if compress {
+		zb := &bytes.Buffer{}
+		zw := gzip.NewWriter(zb)
+		zw.Write(data)
+		zw.Close()
+		data = zb.Bytes()
+	}
+	err := ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listTree(t *testing.T, dir string) string {
+	files := []string{}
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, _ := os.Readlink(p)
+			rel += " -> " + target
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return strings.Join(files, "\n")
+}
+
+func TestUnpackDockerLayout(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	layout := filepath.Join(tmp, "layout")
+	os.MkdirAll(filepath.Join(layout, "l1"), 0755)
+	os.MkdirAll(filepath.Join(layout, "l2"), 0755)
+	writeTar(t, filepath.Join(layout, "l1", "layer.tar"), false, []tarEntry{
+		{Name: "etc/"},
+		{Name: "etc/removed", Content: "x"},
+		{Name: "opt/data/old", Content: "x"},
+		{Name: "../escape", Content: "x"},
+		{Name: "usr/share/doc/foo/copyright", Content: "MIT"},
+	})
+	writeTar(t, filepath.Join(layout, "l2", "layer.tar"), true, []tarEntry{
+		{Name: "etc/.wh.removed"},
+		{Name: "opt/data/.wh..wh..opq"},
+		{Name: "opt/data/new", Content: "x"},
+		{Name: "usr/share/doc/libfoo", Linkname: "foo"},
+	})
+	err = ioutil.WriteFile(filepath.Join(layout, "manifest.json"),
+		[]byte(`[{"Layers": ["l1/layer.tar", "l2/layer.tar"]}]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(tmp, "root")
+	os.Mkdir(root, 0755)
+	err = Unpack(layout, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := listTree(t, root)
+	wanted := `escape
+etc
+opt
+opt/data
+opt/data/new
+usr
+usr/share
+usr/share/doc
+usr/share/doc/foo
+usr/share/doc/foo/copyright
+usr/share/doc/libfoo -> foo`
+	if got != wanted {
+		t.Fatalf("unexpected tree:\n%s\n!=\n%s", got, wanted)
+	}
+}
+
+func TestUnpackSymlinkedParents(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	outside := filepath.Join(tmp, "outside")
+	os.Mkdir(outside, 0755)
+	err = ioutil.WriteFile(filepath.Join(outside, "kept"), []byte("x"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := filepath.Join(tmp, "layout")
+	os.MkdirAll(filepath.Join(layout, "l1"), 0755)
+	os.MkdirAll(filepath.Join(layout, "l2"), 0755)
+	writeTar(t, filepath.Join(layout, "l1", "layer.tar"), false, []tarEntry{
+		{Name: "abs", Linkname: outside},
+		{Name: "rel", Linkname: "../../outside"},
+		{Name: "lib", Linkname: "usr/lib"},
+		{Name: "usr/lib/"},
+		{Name: "abs/written", Content: "x"},
+		{Name: "rel/written", Content: "x"},
+		{Name: "lib/libfoo.so", Content: "x"},
+	})
+	writeTar(t, filepath.Join(layout, "l2", "layer.tar"), false, []tarEntry{
+		{Name: "abs/.wh.kept"},
+		{Name: "rel/.wh..wh..opq"},
+	})
+	err = ioutil.WriteFile(filepath.Join(layout, "manifest.json"),
+		[]byte(`[{"Layers": ["l1/layer.tar", "l2/layer.tar"]}]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(tmp, "root")
+	os.Mkdir(root, 0755)
+	err = Unpack(layout, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := listTree(t, outside); got != "kept" {
+		t.Fatalf("files changed outside the root:\n%s", got)
+	}
+	// Links are resolved within the root, like the filesystem of the image.
+	for _, name := range []string{"usr/lib/libfoo.so", strings.TrimPrefix(outside, "/") + "/written"} {
+		if _, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s not written in the root: %s", name, err)
+		}
+	}
+}
+
+func TestLayerPaths(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{"manifest.json", `[{"Layers": ["../secret.tar"]}]`},
+		{"manifest.json", `[{"Layers": ["/etc/secret.tar"]}]`},
+		{"index.json", `{"manifests": [{"digest": "sha256:../../../etc/passwd"}]}`},
+		{"index.json", `{"manifests": [{"digest": "../x:abc"}]}`},
+	}
+	for _, test := range tests {
+		layout := filepath.Join(tmp, "layout")
+		os.RemoveAll(layout)
+		os.Mkdir(layout, 0755)
+		err := ioutil.WriteFile(filepath.Join(layout, test.file), []byte(test.content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths, err := layerPaths(layout)
+		if err == nil {
+			t.Errorf("%s accepted: %v", test.content, paths)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package image
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/groove-x/go-licenses/internal/apk"
+	"github.com/groove-x/go-licenses/internal/deb"
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/internal/rootfs"
+	"github.com/groove-x/go-licenses/internal/rpm"
+)
+
+// packageDatabases maps the package databases looked up in images to the
+// function listing their licenses.
+var packageDatabases = []struct {
+	Path string
+	List func(root string) ([]report.License, error)
+}{
+	{"/var/lib/dpkg/status", deb.ListLicenses},
+	{"/lib/apk/db/installed", func(root string) ([]report.License, error) {
+		return apk.ListLicenses(root, true)
+	}},
+	{"/var/lib/rpm", func(root string) ([]report.License, error) {
+		return rpm.ListLicenses(root, true)
+	}},
+	{"/usr/lib/sysimage/rpm", func(root string) ([]report.License, error) {
+		return rpm.ListLicenses(root, true)
+	}},
+}
+
+// ListLicenses unpacks the image designated by ref, see Unpack, and returns
+// the licenses of its operating system packages followed by the ones of the
+// modules linked in its Go binaries.
+func ListLicenses(ref string) ([]report.License, error) {
+	dir, err := ioutil.TempDir("", "go-licenses-rootfs")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	err = Unpack(ref, dir)
+	if err != nil {
+		return nil, err
+	}
+	licenses := []report.License{}
+	for _, db := range packageDatabases {
+		path, err := rootfs.Resolve(dir, db.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		l, err := db.List(dir)
+		if err != nil {
+			return nil, err
+		}
+		licenses = append(licenses, l...)
+		break
+	}
+	binaries, err := FindBinaries(dir, gomod.IsBinary)
+	if err != nil {
+		return nil, err
+	}
+	if len(binaries) > 0 {
+		l, err := gomod.ListBinaryLicenses(binaries)
+		if err != nil {
+			return nil, err
+		}
+		licenses = append(licenses, l...)
+	}
+	return licenses, nil
+}
@@ -0,0 +1,163 @@
+// Package inventory reconciles manually maintained dependency inventories with
+// scan results.
+package inventory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// Entry is a dependency recorded in a manually maintained inventory.
+type Entry struct {
+	Package string
+	Version string
+	License string
+	Line    int
+}
+
+// Read parses a CSV inventory of dependencies. The first row must
+// name the columns: one of "package", "module" or "name" is required,
+// "license" and "version" are optional and other columns are ignored.
+func Read(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read inventory header: %s", err)
+	}
+	pkgCol, versionCol, licenseCol := -1, -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "package", "module", "name":
+			if pkgCol < 0 {
+				pkgCol = i
+			}
+		case "version":
+			versionCol = i
+		case "license":
+			licenseCol = i
+		}
+	}
+	if pkgCol < 0 {
+		return nil, fmt.Errorf("inventory has no package, module or name column")
+	}
+	field := func(record []string, col int) string {
+		if col < 0 || col >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[col])
+	}
+	entries := []Entry{}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		e := Entry{
+			Package: field(record, pkgCol),
+			Version: field(record, versionCol),
+			License: field(record, licenseCol),
+			Line:    line,
+		}
+		if e.Package == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Contradiction is an inventory entry whose license differs from the
+// detected one.
+type Contradiction struct {
+	Entry   Entry
+	License report.License
+}
+
+type Reconciliation struct {
+	// Missing are scanned dependencies absent from the inventory.
+	Missing []report.License
+	// Stale are inventory entries which are no longer dependencies, or whose
+	// version differs from the scanned one.
+	Stale []Entry
+	// Contradicted are inventory entries whose license does not match the
+	// one detected with enough confidence.
+	Contradicted []Contradiction
+}
+
+func (r *Reconciliation) Empty() bool {
+	return len(r.Missing) == 0 && len(r.Stale) == 0 && len(r.Contradicted) == 0
+}
+
+// Reconcile compares an inventory against scanned licenses.
+func Reconcile(inventory []Entry, licenses []report.License,
+	confidence float64) *Reconciliation {
+
+	rec := &Reconciliation{}
+	entries := map[string]Entry{}
+	for _, e := range inventory {
+		entries[e.Package] = e
+	}
+	scanned := map[string]bool{}
+	for _, l := range licenses {
+		scanned[l.Package] = true
+		e, ok := entries[l.Package]
+		if !ok {
+			rec.Missing = append(rec.Missing, l)
+			continue
+		}
+		if e.Version != "" && l.Version != "" && e.Version != l.Version {
+			rec.Stale = append(rec.Stale, e)
+		}
+		if e.License != "" && l.Template != nil && l.Score >= confidence &&
+			!l.Template.MatchesName(e.License) {
+			rec.Contradicted = append(rec.Contradicted, Contradiction{
+				Entry:   e,
+				License: l,
+			})
+		}
+	}
+	for _, e := range inventory {
+		if !scanned[e.Package] {
+			rec.Stale = append(rec.Stale, e)
+		}
+	}
+	return rec
+}
+
+// Write prints the differences found by reconciliation as a table.
+func Write(w io.Writer, rec *Reconciliation) error {
+	lines := []string{}
+	for _, l := range rec.Missing {
+		lines = append(lines, fmt.Sprintf("missing\t%s\t%s", l.Package, l.Version))
+	}
+	for _, e := range rec.Stale {
+		detail := fmt.Sprintf("(line %d)", e.Line)
+		if e.Version != "" {
+			detail = e.Version + " " + detail
+		}
+		lines = append(lines, fmt.Sprintf("stale\t%s\t%s", e.Package, detail))
+	}
+	for _, c := range rec.Contradicted {
+		lines = append(lines, fmt.Sprintf("contradicted\t%s\t%s != %s (line %d)",
+			c.Entry.Package, c.Entry.License, c.License.Template.Title, c.Entry.Line))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 1, 4, 2, ' ', 0)
+	_, err := io.WriteString(tw, strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		return err
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,44 @@
+package inventory
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+func TestReconcile(t *testing.T) {
+	inventory, err := Read(strings.NewReader(
+		"Name,Version,License,Owner\n" +
+			"a,v1.0.0,MIT,team\n" +
+			"b,v1.0.0,apache 2.0,team\n" +
+			"c,v2.0.0,MIT,team\n" +
+			"d,,BSD,team\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	gpl := &matcher.Template{Title: "GNU General Public License v3.0", ID: "GPL-3.0"}
+	licenses := []report.License{
+		{Package: "a", Version: "v1.0.0", Template: mit, Score: 1},
+		{Package: "b", Version: "v1.0.0", Template: gpl, Score: 1},
+		{Package: "c", Version: "v2.1.0", Template: mit, Score: 1},
+		{Package: "e", Version: "v0.1.0", Template: mit, Score: 1},
+	}
+	rec := Reconcile(inventory, licenses, 0.9)
+	b := &bytes.Buffer{}
+	err = Write(b, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `missing       e  v0.1.0
+stale         c  v2.0.0 (line 4)
+stale         d  (line 5)
+contradicted  b  apache 2.0 != GNU General Public License v3.0 (line 3)
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected reconciliation:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
@@ -0,0 +1,150 @@
+// Package lock records detected licenses and license file hashes in a lock
+// file, and verifies later scans against it.
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// DefaultPath is the lock file path used when none is set.
+const DefaultPath = "licenses.lock"
+
+// Entry records the license of a package version.
+type Entry struct {
+	Package string `json:"package"`
+	Version string `json:"version,omitempty"`
+	// License is the SPDX identifier or title of the license detected with
+	// enough confidence, or empty.
+	License string `json:"license,omitempty"`
+	// Hash is the SHA-256 of the license file, if any.
+	Hash string `json:"sha256,omitempty"`
+}
+
+// Make returns the lock entries of licenses, sorted by package.
+func Make(licenses []report.License, confidence float64) ([]Entry, error) {
+	entries := []Entry{}
+	for _, l := range licenses {
+		e := Entry{
+			Package: l.Package,
+			Version: l.Version,
+		}
+		if l.Template != nil && l.Score >= confidence {
+			e.License = l.Template.ID
+			if e.License == "" {
+				e.License = l.Template.Title
+			}
+		} else if l.Declared != "" {
+			e.License = l.Declared
+		}
+		e.Hash = l.Hash
+		if e.Hash == "" && l.Path != "" {
+			hash, err := report.HashFile(l.Path)
+			if err != nil {
+				return nil, err
+			}
+			e.Hash = hash
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Package < entries[j].Package
+	})
+	return entries, nil
+}
+
+// Write writes entries as a JSON array.
+func Write(w io.Writer, entries []Entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Read reads the lock file at path.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries := []Entry{}
+	err = json.NewDecoder(f).Decode(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	return entries, nil
+}
+
+// Mismatch is a package whose license differs from the locked one.
+type Mismatch struct {
+	Locked  *Entry
+	Current Entry
+	Reason  string
+}
+
+// Verify compares current entries with locked ones. Packages are matched by
+// name so upgrades are verified too: a mismatch is reported for packages
+// missing from the lock, and for packages whose detected license or license
+// file changed, whatever their version.
+func Verify(locked, current []Entry) []Mismatch {
+	byPackage := map[string]*Entry{}
+	for i := range locked {
+		byPackage[locked[i].Package] = &locked[i]
+	}
+	mismatches := []Mismatch{}
+	for _, e := range current {
+		l := byPackage[e.Package]
+		reason := ""
+		switch {
+		case l == nil:
+			reason = "not locked"
+		case l.License != e.License:
+			reason = fmt.Sprintf("license changed from %s to %s",
+				orUnknown(l.License), orUnknown(e.License))
+		case l.Hash != e.Hash:
+			reason = "license file changed"
+		default:
+			continue
+		}
+		mismatches = append(mismatches, Mismatch{
+			Locked:  l,
+			Current: e,
+			Reason:  reason,
+		})
+	}
+	return mismatches
+}
+
+func orUnknown(license string) string {
+	if license == "" {
+		return "?"
+	}
+	return license
+}
+
+// WriteMismatches prints mismatches as a table.
+func WriteMismatches(w io.Writer, mismatches []Mismatch) error {
+	tw := tabwriter.NewWriter(w, 1, 4, 2, ' ', 0)
+	for _, m := range mismatches {
+		versions := m.Current.Version
+		if m.Locked != nil && m.Locked.Version != m.Current.Version {
+			versions = m.Locked.Version + " -> " + m.Current.Version
+		}
+		line := strings.Join([]string{m.Current.Package, versions, m.Reason}, "\t")
+		_, err := io.WriteString(tw, line+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,33 @@
+package lock
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	locked := []Entry{
+		{Package: "a", Version: "v1", License: "MIT", Hash: "1"},
+		{Package: "b", Version: "v1", License: "MIT", Hash: "2"},
+		{Package: "c", Version: "v1", License: "MIT", Hash: "3"},
+		{Package: "d", Version: "v1", License: "MIT", Hash: "4"},
+	}
+	current := []Entry{
+		{Package: "a", Version: "v2", License: "MIT", Hash: "1"},
+		{Package: "b", Version: "v2", License: "BUSL-1.1", Hash: "5"},
+		{Package: "c", Version: "v1", License: "MIT", Hash: "6"},
+		{Package: "e", Version: "v1", License: "MIT", Hash: "7"},
+	}
+	got := []string{}
+	for _, m := range Verify(locked, current) {
+		got = append(got, m.Current.Package+": "+m.Reason)
+	}
+	wanted := []string{
+		"b: license changed from MIT to BUSL-1.1",
+		"c: license file changed",
+		"e: not locked",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(wanted) {
+		t.Fatalf("unexpected mismatches:\n%q\n!=\n%q", got, wanted)
+	}
+}
@@ -0,0 +1,39 @@
+package matcher
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// rePatentGrant matches explicit patent grants: "Grant of Patent License"
+	// sections, licenses under patent claims, and grants of patent licenses,
+	// like in Apache-2.0, MPL-2.0, GPL-3.0 and PATENTS files.
+	rePatentGrant = regexp.MustCompile(`grant of patent licen[cs]e|under patent claims\b|` +
+		`\b(?:grants?|includes)\b[^.;]{0,300}?\b(?:patent licen[cs]e|` +
+		`under (?:its |their |any )?(?:licensed patents|necessary claims))`)
+	// reTrademark matches clauses withholding trademark rights, like "This
+	// License does not grant permission to use the trade names, trademarks".
+	reTrademark = regexp.MustCompile(`\b(?:not|no)\b[^.;]{0,120}?\b(?:grant|licen[cs]e|rights?)` +
+		`\b[^.;]{0,120}?\btrade ?marks?\b`)
+)
+
+// Clauses tells which clauses a license text holds, beyond its template
+// match.
+type Clauses struct {
+	// PatentGrant is set for explicit patent license grants.
+	PatentGrant bool
+	// Trademark is set for clauses withholding trademark rights.
+	Trademark bool
+}
+
+// FindClauses detects the patent grant and trademark clauses of a license
+// text, whatever template it matches, so added PATENTS grants and modified
+// licenses are flagged too.
+func FindClauses(license []byte) Clauses {
+	text := strings.Join(strings.Fields(strings.ToLower(string(license))), " ")
+	return Clauses{
+		PatentGrant: rePatentGrant.MatchString(text),
+		Trademark:   reTrademark.MatchString(text),
+	}
+}
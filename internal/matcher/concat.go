@@ -0,0 +1,193 @@
+package matcher
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/normalize"
+)
+
+const (
+	// partScore is the minimum score of the parts of concatenated license
+	// files.
+	partScore = 0.9
+	// maxJoinedSegments is the maximum number of segments joined to match a
+	// part, for license texts holding separators or titles, like the "GNU
+	// GENERAL PUBLIC LICENSE" heading the terms of the GPL-2.0.
+	maxJoinedSegments = 4
+	// minSegmentWords is the number of words below which segments are only
+	// labels, like the module paths of vendored license files, and are joined
+	// to the next part.
+	minSegmentWords = 10
+)
+
+// reTitle matches license title lines, like "MIT License" or "The BSD
+// 3-Clause License", which start license texts.
+var reTitle = regexp.MustCompile(`(?i)^[^.:;]{0,60}\blicen[cs]e\b[^.:;]{0,30}$`)
+
+// isSeparator returns true for separator lines, made of at least five times
+// the same punctuation character, like the "=====" lines of vendored license
+// files.
+func isSeparator(line []byte) bool {
+	line = bytes.Replace(line, []byte(" "), nil, -1)
+	if len(line) < 5 || !strings.ContainsRune("-=*_#~+", rune(line[0])) {
+		return false
+	}
+	for _, c := range line {
+		if c != line[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitSegments splits a license file at separator lines and at title lines
+// following a blank line, returning the segments between them.
+func splitSegments(license []byte) [][]byte {
+	segments := [][]byte{}
+	current := []byte{}
+	flush := func() {
+		if len(bytes.TrimSpace(current)) > 0 {
+			segments = append(segments, current)
+		}
+		current = []byte{}
+	}
+	blank := true
+	for _, line := range bytes.Split(normalize.Decode(license), []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if isSeparator(trimmed) {
+			flush()
+			blank = true
+			continue
+		}
+		if blank && len(strings.Fields(string(trimmed))) <= 8 && reTitle.Match(trimmed) {
+			flush()
+		}
+		current = append(append(current, line...), '\n')
+		blank = len(trimmed) == 0
+	}
+	flush()
+	return segments
+}
+
+// matchParts matches the parts of a license file made of several license
+// texts, like the LICENSE files of vendored dependencies, split with
+// splitSegments. Consecutive segments are joined until they match a template
+// with a score of partScore or more, short ones being labels joined to the
+// next part. It returns nil unless the license holds at least two parts and
+// all of them match.
+func matchParts(license []byte, templates []*Template) []MatchResult {
+	segments := splitSegments(license)
+	if len(segments) < 2 {
+		return nil
+	}
+	parts := []MatchResult{}
+	// ends are the indices following the last segment of parts.
+	ends := []int{}
+	for i := 0; i < len(segments); {
+		found := false
+		data := []byte{}
+		for j := i; j < len(segments) && j < i+maxJoinedSegments; j++ {
+			data = append(data, segments[j]...)
+			if len(Words(data)) < minSegmentWords && j+1 < len(segments) {
+				continue
+			}
+			if m := bestMatch(data, templates); m.Template != nil && m.Score >= partScore {
+				parts = append(parts, m)
+				ends = append(ends, j+1)
+				i, found = j+1, true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		// Trailing sections, like the appendix of the Apache license, may
+		// follow a title line.
+		n := len(parts)
+		if n == 0 {
+			return nil
+		}
+		start := 0
+		if n > 1 {
+			start = ends[n-2]
+		}
+		data = bytes.Join(segments[start:i+1], nil)
+		m := bestMatch(data, templates)
+		if m.Template != parts[n-1].Template || m.Score < partScore {
+			return nil
+		}
+		parts[n-1], ends[n-1] = m, i+1
+		i++
+	}
+	if len(parts) < 2 {
+		return nil
+	}
+	return parts
+}
+
+// Combine returns a template standing for all the licenses of parts, the
+// templates matching the parts of a concatenated license file, in order of
+// appearance. Its title and SPDX identifier join the ones of the distinct
+// parts with "AND", the latter only if they all have one. It imposes the
+// conditions of all parts but only grants the rights all of them grant. If
+// all parts share the same template, it is returned.
+func Combine(parts []*Template) *Template {
+	distinct := []*Template{}
+	seen := map[string]bool{}
+	for _, t := range parts {
+		if !seen[t.Title] {
+			seen[t.Title] = true
+			distinct = append(distinct, t)
+		}
+	}
+	if len(distinct) == 1 {
+		return distinct[0]
+	}
+	c := &Template{
+		Parts:       distinct,
+		Category:    distinct[0].Category,
+		OSIApproved: true,
+		FSFLibre:    true,
+		Permitted:   append([]string{}, distinct[0].Permitted...),
+	}
+	titles, ids := []string{}, []string{}
+	for _, t := range distinct {
+		titles = append(titles, t.Title)
+		if t.ID != "" {
+			ids = append(ids, t.ID)
+		}
+		if t.Category != c.Category {
+			c.Category = ""
+		}
+		c.OSIApproved = c.OSIApproved && t.OSIApproved
+		c.FSFLibre = c.FSFLibre && t.FSFLibre
+		for _, r := range t.Required {
+			if !hasString(c.Required, r) {
+				c.Required = append(c.Required, r)
+			}
+		}
+		permitted := []string{}
+		for _, p := range c.Permitted {
+			if hasString(t.Permitted, p) {
+				permitted = append(permitted, p)
+			}
+		}
+		c.Permitted = permitted
+	}
+	c.Title = strings.Join(titles, " AND ")
+	if len(ids) == len(distinct) {
+		c.ID = strings.Join(ids, " AND ")
+	}
+	return c
+}
+
+func hasString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package matcher
+
+// Chunk is a run of words of a diff. Op is '=' for words common to both
+// texts, '-' for words only in the first one and '+' for words only in the
+// second one.
+type Chunk struct {
+	Op    byte
+	Words []string
+}
+
+// maxEdits bounds the number of edits Diff looks for. Texts further apart are
+// reported as entirely replaced.
+const maxEdits = 2000
+
+// Diff returns the word differences turning a into b, as computed by the
+// Myers algorithm.
+func Diff(a, b []string) []Chunk {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > maxEdits {
+		max = maxEdits
+	}
+	// trace[d] holds the furthest x reached on diagonals -d..d after d edits.
+	trace := [][]int{}
+	v := map[int]int{1: 0}
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			x := 0
+			if k == -d || (k != d && v[k-1] < v[k+1]) {
+				x = v[k+1]
+			} else {
+				x = v[k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		row := make([]int, 2*d+1)
+		for k := -d; k <= d; k++ {
+			row[k+d] = v[k]
+		}
+		trace = append(trace, row)
+	}
+	if !found {
+		return appendChunk(appendChunk(nil, '-', a...), '+', b...)
+	}
+	// Walk back the trace to recover the edits, last first.
+	type edit struct {
+		op   byte
+		word string
+	}
+	edits := []edit{}
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{'=', a[x]})
+		}
+		if x > prevX {
+			x--
+			edits = append(edits, edit{'-', a[x]})
+		} else {
+			y--
+			edits = append(edits, edit{'+', b[y]})
+		}
+	}
+	for x > 0 {
+		x--
+		edits = append(edits, edit{'=', a[x]})
+	}
+	chunks := []Chunk{}
+	for i := len(edits) - 1; i >= 0; i-- {
+		chunks = appendChunk(chunks, edits[i].op, edits[i].word)
+	}
+	return chunks
+}
+
+// appendChunk appends words to chunks, extending the last chunk if it has
+// the same operation.
+func appendChunk(chunks []Chunk, op byte, words ...string) []Chunk {
+	if len(words) == 0 {
+		return chunks
+	}
+	if len(chunks) > 0 && chunks[len(chunks)-1].Op == op {
+		last := &chunks[len(chunks)-1]
+		last.Words = append(last.Words, words...)
+		return chunks
+	}
+	return append(chunks, Chunk{Op: op, Words: append([]string{}, words...)})
+}
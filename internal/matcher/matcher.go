@@ -0,0 +1,669 @@
+// Package matcher detects licenses by comparing the words of license files
+// with the ones of well-known license templates.
+package matcher
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/groove-x/go-licenses/assets"
+	"github.com/groove-x/go-licenses/internal/normalize"
+)
+
+// Template is a reference license text to match license files against.
+type Template struct {
+	Title    string
+	ID       string // SPDX identifier, if any
+	Nickname string
+	// Category is the license family, like "BSD" or "GPL".
+	Category string
+	// Language is the language code of translated license texts, like "ja".
+	// Translations share the SPDX identifier of the original license.
+	Language string
+	// File is the name of the template file.
+	File string
+	// Required lists the conditions the license imposes, like
+	// "include-copyright" or "disclose-source".
+	Required []string
+	// Permitted lists the rights the license grants, like "patent-grant".
+	Permitted []string
+	// OSIApproved and FSFLibre tell whether the license is approved by the
+	// Open Source Initiative and free according to the Free Software
+	// Foundation, as recorded in the SPDX license list.
+	OSIApproved bool
+	FSFLibre    bool
+	// Text is the license text following the front matter.
+	Text  string
+	Words map[string]int
+	// Parts lists the templates combined by Combine, for concatenated
+	// license files.
+	Parts []*Template
+	// Optional lists the sections of Text delimited by "<<beginOptional>>"
+	// and "<<endOptional>>" lines, like the appendix of the Apache license,
+	// which license files may omit.
+	Optional []*Section
+	// optional holds the words only appearing in optional sections.
+	optional map[string]bool
+	// ids and optionalIDs are the vocabulary identifiers of Words, the ones
+	// of optional words in the latter, set by ParseTemplate.
+	ids         []int
+	optionalIDs []int
+}
+
+// Section is an optional section of a template.
+type Section struct {
+	// Title is the first line of the section.
+	Title string
+	Words map[string]int
+}
+
+const (
+	beginOptional = "<<beginOptional>>"
+	endOptional   = "<<endOptional>>"
+)
+
+// ParseTemplate parses a license template made of a YAML-like front matter
+// delimited by "---" lines, followed by the license text.
+func ParseTemplate(content string) (*Template, error) {
+	t := Template{}
+	text := []byte{}
+	required := []byte{}
+	var section *Section
+	sectionText := []byte{}
+	state := 0
+	list := ""
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if state == 0 {
+			if line == "---" {
+				state = 1
+			}
+		} else if state == 1 {
+			if line == "---" {
+				state = 2
+			} else {
+				if strings.HasPrefix(line, "title:") {
+					t.Title = strings.TrimSpace(line[len("title:"):])
+				} else if strings.HasPrefix(line, "spdx-id:") {
+					t.ID = strings.TrimSpace(line[len("spdx-id:"):])
+				} else if strings.HasPrefix(line, "category:") {
+					t.Category = strings.TrimSpace(line[len("category:"):])
+				} else if strings.HasPrefix(line, "language:") {
+					t.Language = strings.TrimSpace(line[len("language:"):])
+				} else if strings.HasPrefix(line, "nickname:") {
+					t.Nickname = strings.TrimSpace(line[len("nickname:"):])
+				} else if strings.HasPrefix(line, "osi-approved:") {
+					t.OSIApproved = strings.TrimSpace(line[len("osi-approved:"):]) == "true"
+				} else if strings.HasPrefix(line, "fsf-libre:") {
+					t.FSFLibre = strings.TrimSpace(line[len("fsf-libre:"):]) == "true"
+				} else if strings.HasPrefix(line, "- ") {
+					switch list {
+					case "required":
+						t.Required = append(t.Required, strings.TrimSpace(line[2:]))
+					case "permitted":
+						t.Permitted = append(t.Permitted, strings.TrimSpace(line[2:]))
+					}
+					continue
+				}
+				list = ""
+				if strings.HasSuffix(line, ":") {
+					list = strings.TrimSuffix(line, ":")
+				}
+			}
+		} else if state == 2 {
+			if line == beginOptional || line == endOptional {
+				if (line == beginOptional) == (section != nil) {
+					return nil, fmt.Errorf("unbalanced %s", line)
+				}
+				if section != nil {
+					section.Words = MakeWordSet(sectionText)
+					t.Optional = append(t.Optional, section)
+					section = nil
+				} else {
+					section = &Section{}
+					sectionText = sectionText[:0]
+				}
+				continue
+			}
+			if section != nil {
+				if section.Title == "" {
+					section.Title = line
+				}
+				sectionText = append(sectionText, scanner.Bytes()...)
+				sectionText = append(sectionText, []byte("\n")...)
+			} else {
+				required = append(required, scanner.Bytes()...)
+				required = append(required, []byte("\n")...)
+			}
+			text = append(text, scanner.Bytes()...)
+			text = append(text, []byte("\n")...)
+		}
+	}
+	if section != nil {
+		return nil, fmt.Errorf("unbalanced %s", beginOptional)
+	}
+	t.Text = string(text)
+	t.Words = MakeWordSet(text)
+	optional := map[string]int{}
+	if len(t.Optional) > 0 {
+		requiredWords := MakeWordSet(required)
+		t.optional = map[string]bool{}
+		for w, pos := range t.Words {
+			if _, ok := requiredWords[w]; !ok {
+				t.optional[w] = true
+				optional[w] = pos
+			}
+		}
+		t.ids = vocabulary.intern(requiredWords)
+	} else {
+		t.ids = vocabulary.intern(t.Words)
+	}
+	t.optionalIDs = vocabulary.intern(optional)
+	return &t, scanner.Err()
+}
+
+// TemplatesDir is a directory of template files replacing the templates
+// embedded in the assets package, if set. It defaults to the
+// GOLICENSES_TEMPLATES environment variable.
+var TemplatesDir = os.Getenv("GOLICENSES_TEMPLATES")
+
+// LoadTemplates parses the .txt template files of TemplatesDir, or the ones
+// embedded in the assets package, sorted by file name.
+func LoadTemplates() ([]*Template, error) {
+	var files fs.FS = assets.Templates
+	if TemplatesDir != "" {
+		files = os.DirFS(TemplatesDir)
+	}
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+	templates := []*Template{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".txt" {
+			continue
+		}
+		data, err := fs.ReadFile(files, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		templ, err := ParseTemplate(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %s", e.Name(), err)
+		}
+		templ.File = e.Name()
+		templates = append(templates, templ)
+	}
+	return templates, nil
+}
+
+// vocab interns the words of templates, numbered in order of appearance.
+// License words are looked up once per match instead of once per template,
+// and share the strings of template words.
+type vocab struct {
+	mu    sync.RWMutex
+	ids   map[string]int
+	words []string
+}
+
+var vocabulary = &vocab{ids: map[string]int{}}
+
+// intern adds the words of set to the vocabulary and returns their
+// identifiers.
+func (v *vocab) intern(set map[string]int) []int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	ids := make([]int, 0, len(set))
+	for w := range set {
+		id, ok := v.ids[w]
+		if !ok {
+			id = len(v.words)
+			v.ids[w] = id
+			v.words = append(v.words, w)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// isWordByte returns true for the ASCII bytes of words, the ones matched by
+// [\w'] in regexps.
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '_' || c == '\'' ||
+		'A' <= c && c <= 'Z'
+}
+
+// Classes of runes, telling how they make words.
+const (
+	separator = iota
+	letter
+	katakana
+	ideograph
+)
+
+// runeClass returns the class of the rune starting data, and its length.
+// Letters, digits, marks, underscores and apostrophes make words, like
+// [\p{L}\p{M}\p{N}_']+ in regexps. Texts without spaces between words are
+// segmented as in Unicode Standard Annex #29: each ideograph and hiragana is a
+// word, katakana sequences are words.
+func runeClass(data []byte) (int, int) {
+	if data[0] < utf8.RuneSelf {
+		if isWordByte(data[0]) {
+			return letter, 1
+		}
+		return separator, 1
+	}
+	r, n := utf8.DecodeRune(data)
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana):
+		return ideograph, n
+	case unicode.Is(unicode.Katakana, r) || r == 'ー':
+		return katakana, n
+	case unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r):
+		return letter, n
+	}
+	return separator, n
+}
+
+// tokenize calls fn with the normalized words of data, in order. The word
+// passed to fn is only valid during the call.
+func tokenize(data []byte, fn func(word []byte)) {
+	data = normalize.Clean(data)
+	for i := 0; i < len(data); {
+		class, n := runeClass(data[i:])
+		if class == separator {
+			i += n
+			continue
+		}
+		j := i + n
+		for class != ideograph && j < len(data) {
+			next, n := runeClass(data[j:])
+			if next != class {
+				break
+			}
+			j += n
+		}
+		fn(data[i:j])
+		i = j
+	}
+}
+
+// Words returns the normalized words of data, in order.
+func Words(data []byte) []string {
+	words := []string{}
+	vocabulary.mu.RLock()
+	defer vocabulary.mu.RUnlock()
+	tokenize(data, func(word []byte) {
+		if id, ok := vocabulary.ids[string(word)]; ok {
+			words = append(words, vocabulary.words[id])
+		} else {
+			words = append(words, string(word))
+		}
+	})
+	return words
+}
+
+// stopWords are the English words too frequent in license texts to tell them
+// apart. They are left out of word sets, so they neither inflate the
+// similarity of unrelated licenses nor dilute the differences of close ones.
+// Words bearing meaning in legal texts, like "not", "no" or "any", are kept.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "has": true, "have": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "which": true, "with": true,
+}
+
+// MakeWordSet returns the set of normalized words of data, mapped to the
+// position of their first occurrence. Stop words are left out.
+func MakeWordSet(data []byte) map[string]int {
+	words := map[string]int{}
+	i := 0
+	vocabulary.mu.RLock()
+	defer vocabulary.mu.RUnlock()
+	tokenize(data, func(word []byte) {
+		// Non-matching words are likely in the license header, to mention
+		// copyrights and authors. Try to preserve the initial sequences,
+		// to display them later.
+		if _, ok := words[string(word)]; !ok && !stopWords[string(word)] {
+			if id, ok := vocabulary.ids[string(word)]; ok {
+				words[vocabulary.words[id]] = i
+			} else {
+				words[string(word)] = i
+			}
+		}
+		i++
+	})
+	return words
+}
+
+// wordBuffer flags the vocabulary words of a license, to count the ones
+// shared with templates without hashing them again.
+type wordBuffer struct {
+	present []bool
+	set     []int
+}
+
+var wordBuffers = sync.Pool{New: func() interface{} { return &wordBuffer{} }}
+
+// newWordBuffer returns a buffer flagging the vocabulary words of set. It
+// should be released after use.
+func newWordBuffer(set map[string]int) *wordBuffer {
+	b := wordBuffers.Get().(*wordBuffer)
+	vocabulary.mu.RLock()
+	defer vocabulary.mu.RUnlock()
+	if len(b.present) < len(vocabulary.words) {
+		b.present = make([]bool, len(vocabulary.words))
+	}
+	for w := range set {
+		if id, ok := vocabulary.ids[w]; ok {
+			b.present[id] = true
+			b.set = append(b.set, id)
+		}
+	}
+	return b
+}
+
+// release clears the buffer and returns it to the pool.
+func (b *wordBuffer) release() {
+	for _, id := range b.set {
+		b.present[id] = false
+	}
+	b.set = b.set[:0]
+	wordBuffers.Put(b)
+}
+
+// commonWords counts the words of the license shared with template t, and the
+// words of t to score the license against: the ones of t, except the words of
+// optional sections missing from the license.
+func (b *wordBuffer) commonWords(set map[string]int, t *Template) (int, int) {
+	common, optional := 0, 0
+	if t.ids == nil {
+		// Templates not made by ParseTemplate have no identifiers.
+		for w := range set {
+			if _, ok := t.Words[w]; ok {
+				common++
+			}
+		}
+		return common, len(t.Words)
+	}
+	for _, id := range t.ids {
+		if id < len(b.present) && b.present[id] {
+			common++
+		}
+	}
+	for _, id := range t.optionalIDs {
+		if id < len(b.present) && b.present[id] {
+			optional++
+		}
+	}
+	return common + optional, len(t.ids) + optional
+}
+
+type Word struct {
+	Text string
+	Pos  int
+}
+
+type sortedWords []Word
+
+func (s sortedWords) Len() int {
+	return len(s)
+}
+
+func (s sortedWords) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s sortedWords) Less(i, j int) bool {
+	return s[i].Pos < s[j].Pos
+}
+
+type MatchResult struct {
+	Template     *Template
+	Score        float64
+	ExtraWords   []string
+	MissingWords []string
+	// LicenseWords and TemplateWords count the distinct words of the license
+	// and the template, CommonWords the ones they share. Score is twice
+	// CommonWords divided by their sum, where license words missing from short
+	// templates weigh less, see ExtraWordsWeight. Words of optional template sections
+	// missing from the license are neither counted nor listed in
+	// MissingWords.
+	LicenseWords  int
+	TemplateWords int
+	CommonWords   int
+	// MissingSections lists the titles of the optional template sections
+	// mostly missing from the license.
+	MissingSections []string
+	// Clauses are the patent and trademark clauses of the license, set by
+	// Match.
+	Clauses Clauses
+	// Parts are the matches of the license texts concatenated in the
+	// license, in order, if Match split it.
+	Parts []MatchResult
+}
+
+func sortAndReturnWords(words []Word) []string {
+	sort.Sort(sortedWords(words))
+	tokens := []string{}
+	for _, w := range words {
+		tokens = append(tokens, w.Text)
+	}
+	return tokens
+}
+
+// matchTemplate compares the words of a license with the ones of template t.
+func matchTemplate(words map[string]int, t *Template) MatchResult {
+	extra := []Word{}
+	missing := []Word{}
+	common := 0
+	for w, pos := range words {
+		_, ok := t.Words[w]
+		if ok {
+			common++
+		} else {
+			extra = append(extra, Word{
+				Text: w,
+				Pos:  pos,
+			})
+		}
+	}
+	templateWords := len(t.Words)
+	for w, pos := range t.Words {
+		if _, ok := words[w]; !ok {
+			if t.optional[w] {
+				templateWords--
+				continue
+			}
+			missing = append(missing, Word{
+				Text: w,
+				Pos:  pos,
+			})
+		}
+	}
+	return MatchResult{
+		Template:        t,
+		Score:           score(common, len(words), templateWords),
+		ExtraWords:      sortAndReturnWords(extra),
+		MissingWords:    sortAndReturnWords(missing),
+		LicenseWords:    len(words),
+		TemplateWords:   templateWords,
+		CommonWords:     common,
+		MissingSections: missingSections(words, t),
+	}
+}
+
+// shortTemplateWords is the number of distinct words below which templates
+// are short, like the WTFPL or 0BSD ones.
+const shortTemplateWords = 64
+
+// ExtraWordsWeight returns the weight of the license words missing from a
+// template of templateWords distinct words, in scores. Short templates, like
+// public domain dedications, are often shipped with a few lines of notice or
+// author details, which would otherwise make up a large part of the license
+// and sink its score: the fewer the template words, the less the extra license
+// words weigh, down to half.
+func ExtraWordsWeight(templateWords int) float64 {
+	if templateWords >= shortTemplateWords {
+		return 1
+	}
+	if templateWords <= shortTemplateWords/2 {
+		return 0.5
+	}
+	return float64(templateWords) / shortTemplateWords
+}
+
+// score returns the similarity of a license of licenseWords distinct words
+// and a template of templateWords, sharing common words.
+func score(common, licenseWords, templateWords int) float64 {
+	extra := float64(licenseWords-common) * ExtraWordsWeight(templateWords)
+	return 2 * float64(common) / (float64(common) + extra + float64(templateWords))
+}
+
+// missingSections returns the titles of the optional sections of t whose
+// words, other than the ones of the required text, are mostly missing from
+// words.
+func missingSections(words map[string]int, t *Template) []string {
+	titles := []string{}
+	for _, s := range t.Optional {
+		own, present := 0, 0
+		for w := range s.Words {
+			if !t.optional[w] {
+				continue
+			}
+			own++
+			if _, ok := words[w]; ok {
+				present++
+			}
+		}
+		if own > 0 && 2*present < own {
+			titles = append(titles, s.Title)
+		}
+	}
+	return titles
+}
+
+// Match returns the best license template matching supplied data, its score
+// between 0 and 1 and the list of words appearing in license but not in the
+// matched template. License files holding separator or title lines are
+// matched again part by part, in case they concatenate several license texts,
+// which the words of the longest one would hide: if all their parts match,
+// and either they match several templates or the file as a whole matches
+// poorly, the result holds them and a template combining theirs, see Combine,
+// scored as the worst part.
+func Match(license []byte, templates []*Template) MatchResult {
+	r := bestMatch(license, templates)
+	if r.Template == nil {
+		return r
+	}
+	if parts := matchParts(license, templates); parts != nil {
+		matched := []*Template{}
+		score := 1.0
+		for _, p := range parts {
+			matched = append(matched, p.Template)
+			if p.Score < score {
+				score = p.Score
+			}
+		}
+		if t := Combine(matched); len(t.Parts) > 0 || r.Score < partScore {
+			r = MatchResult{
+				Template:     t,
+				Score:        score,
+				ExtraWords:   []string{},
+				MissingWords: []string{},
+				Parts:        parts,
+			}
+		}
+	}
+	r.Clauses = FindClauses(license)
+	return r
+}
+
+// bestMatch returns the best license template matching supplied data, as a
+// whole.
+func bestMatch(license []byte, templates []*Template) MatchResult {
+	words := MakeWordSet(license)
+	b := newWordBuffer(words)
+	best, bestScore := -1, -1.0
+	for i, t := range templates {
+		common, templateWords := b.commonWords(words, t)
+		score := score(common, len(words), templateWords)
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	b.release()
+	if best < 0 {
+		return MatchResult{Score: -1, ExtraWords: []string{}, MissingWords: []string{}}
+	}
+	// Only the best match needs the extra and missing words.
+	return matchTemplate(words, templates[best])
+}
+
+// Rank returns the results of matching supplied data against all templates,
+// best first.
+func Rank(license []byte, templates []*Template) []MatchResult {
+	results := []MatchResult{}
+	words := MakeWordSet(license)
+	for _, t := range templates {
+		results = append(results, matchTemplate(words, t))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// normalizeName reduces a license name to its lowercase letters and digits so
+// "Apache-2.0" and "apache 2.0" compare equal.
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// versionSuffixes mark SPDX identifiers of GNU licenses restricted to or
+// extending beyond one version. Templates have a single text for both.
+var versionSuffixes = []string{"-only", "-or-later", "+"}
+
+// MatchesName returns true if name designates the template by its title,
+// nickname or SPDX identifier.
+func (t *Template) MatchesName(name string) bool {
+	for _, suffix := range versionSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			name = name[:len(name)-len(suffix)]
+			break
+		}
+	}
+	n := normalizeName(name)
+	for _, s := range []string{t.Title, t.Nickname, t.ID} {
+		if s != "" && normalizeName(s) == n {
+			return true
+		}
+	}
+	return false
+}
+
+// FindTemplate returns the template designated by name, or nil.
+func FindTemplate(templates []*Template, name string) *Template {
+	for _, t := range templates {
+		if t.MatchesName(name) {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package matcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/normalize"
+)
+
+func TestParseTemplateRequired(t *testing.T) {
+	templ, err := ParseTemplate("---\ntitle: Foo\nrequired:\n  - include-copyright\n" +
+		"  - disclose-source\n\npermitted:\n  - commercial-use\n---\nfoo bar\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(templ.Required, ",")
+	if got != "include-copyright,disclose-source" {
+		t.Fatalf("unexpected required conditions: %s", got)
+	}
+	got = strings.Join(templ.Permitted, ",")
+	if got != "commercial-use" {
+		t.Fatalf("unexpected permissions: %s", got)
+	}
+}
+
+func TestOptionalSections(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apache *Template
+	for _, templ := range templates {
+		if templ.ID == "Apache-2.0" {
+			apache = templ
+		}
+	}
+	if apache == nil || len(apache.Optional) != 1 {
+		t.Fatalf("Apache-2.0 template without optional section")
+	}
+	if strings.Contains(apache.Text, beginOptional) {
+		t.Fatalf("optional section markers left in template text")
+	}
+	text := apache.Text[:strings.Index(apache.Text, "APPENDIX")]
+	m := Match([]byte(text), templates)
+	if m.Template != apache || m.Score < .99 {
+		t.Fatalf("license without appendix matched %s at %.3f", m.Template.Title, m.Score)
+	}
+	got := strings.Join(m.MissingSections, "|")
+	if got != "APPENDIX: How to apply the Apache License to your work." {
+		t.Fatalf("unexpected missing sections: %q", got)
+	}
+	if len(m.MissingWords) > 0 {
+		t.Fatalf("unexpected missing words: %v", m.MissingWords)
+	}
+	m = Match([]byte(apache.Text), templates)
+	if m.Score < .99 || len(m.MissingSections) > 0 {
+		t.Fatalf("unexpected full text match: %.3f, %v", m.Score, m.MissingSections)
+	}
+	_, err = ParseTemplate("---\ntitle: Foo\n---\nfoo\n" + beginOptional + "\nbar\n")
+	if err == nil {
+		t.Fatalf("unbalanced optional section not reported")
+	}
+}
+
+func TestShortTemplates(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]*Template{}
+	for _, templ := range templates {
+		if templ.Language == "" {
+			byID[templ.ID] = templ
+		}
+	}
+	wtfpl := `Copyright © 2015 John Doe <john@example.com>
+This work is free. You can redistribute it and/or modify it under the
+terms of the Do What The Fuck You Want To Public License, Version 2,
+as published by Sam Hocevar. See the COPYING file for more details.
+
+` + byID["WTFPL"].Text
+	tests := []struct {
+		license string
+		wanted  string
+	}{
+		{wtfpl, "WTFPL"},
+		{strings.Replace(byID["0BSD"].Text, "[fullname]", "Rob Landley", 1), "0BSD"},
+		{byID["ISC"].Text, "ISC"},
+	}
+	for _, test := range tests {
+		m := Match([]byte(test.license), templates)
+		if m.Template.ID != test.wanted || m.Score < .9 {
+			t.Errorf("%s license matched %s with score %.2f", test.wanted,
+				m.Template.ID, m.Score)
+		}
+	}
+	if w := ExtraWordsWeight(len(byID["MIT"].Words)); w != 1 {
+		t.Errorf("unexpected extra words weight of MIT template: %.2f", w)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range Validate(templates) {
+		t.Errorf("embedded template: %s", err)
+	}
+	duplicate, err := ParseTemplate("---\ntitle: My MIT\nspdx-id: MIT\n---\n" +
+		templates[0].Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicate.File = "my_mit.txt"
+	errs := []string{}
+	for _, err := range Validate(append(templates, duplicate)) {
+		errs = append(errs, err.Error())
+	}
+	got := strings.Join(errs, "\n")
+	for _, wanted := range []string{
+		"my_mit.txt: missing category",
+		"my_mit.txt: spdx-id MIT already used by mit.txt",
+		"my_mit.txt: text matches " + templates[0].File + " better",
+	} {
+		if !strings.Contains(got, wanted) {
+			t.Errorf("%q not reported in:\n%s", wanted, got)
+		}
+	}
+}
+
+func TestTemplatesDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"foo.txt":   "---\ntitle: Foo\nspdx-id: Foo\ncategory: Foo\n---\nfoo bar\n",
+		"README.md": "not a template",
+	} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(dir string) { TemplatesDir = dir }(TemplatesDir)
+	TemplatesDir = dir
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 || templates[0].Title != "Foo" || templates[0].File != "foo.txt" {
+		t.Fatalf("unexpected templates: %+v", templates)
+	}
+}
+
+func TestWords(t *testing.T) {
+	reWords := regexp.MustCompile(`[\p{L}\p{M}\p{N}_']+`)
+	data := "The Software's \"AS IS\", l'œuvre_1.0 naïve\tfoo-bar\r\nÉTÉ 2.0+"
+	wanted := []string{}
+	for _, m := range reWords.FindAll(normalize.Clean([]byte(data)), -1) {
+		wanted = append(wanted, string(m))
+	}
+	got := strings.Join(Words([]byte(data)), "|")
+	if got != strings.Join(wanted, "|") {
+		t.Fatalf("unexpected words: %s != %s", got, strings.Join(wanted, "|"))
+	}
+}
+
+func TestWordsJapanese(t *testing.T) {
+	got := strings.Join(Words([]byte("本ソフトウェアを、サブライセンスする。MIT License")), "|")
+	wanted := "本|ソフトウェア|を|サブライセンス|す|る|mit|license"
+	if got != wanted {
+		t.Fatalf("unexpected words: %s != %s", got, wanted)
+	}
+}
+
+func TestMatchJapanese(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, templ := range templates {
+		if templ.Language != "ja" {
+			continue
+		}
+		text := strings.Replace(templ.Text, "[year]", "2021", 1)
+		text = strings.Replace(text, "[fullname]", "株式会社 GROOVE X", 1)
+		m := Match([]byte(text), templates)
+		if m.Template != templ || m.Score < .95 {
+			t.Errorf("%s matched %s with score %.2f", templ.File, m.Template.File, m.Score)
+		}
+	}
+}
+
+func TestMakeWordSetStopWords(t *testing.T) {
+	words := MakeWordSet([]byte("The copyright of the Software is not granted"))
+	wanted := map[string]int{"copyright": 1, "software": 4, "not": 6, "granted": 7}
+	if len(words) != len(wanted) {
+		t.Fatalf("unexpected words: %v", words)
+	}
+	for w, pos := range wanted {
+		if got, ok := words[w]; !ok || got != pos {
+			t.Errorf("unexpected position of %q: %d, %v", w, got, ok)
+		}
+	}
+}
+
+func TestMatchesNameVersionSuffix(t *testing.T) {
+	tmpl := &Template{Title: "GNU General Public License v2.0", ID: "GPL-2.0"}
+	for _, name := range []string{"GPL-2.0", "GPL-2.0-only", "GPL-2.0-or-later", "GPL-2.0+"} {
+		if !tmpl.MatchesName(name) {
+			t.Errorf("%q does not match %s", name, tmpl.ID)
+		}
+	}
+	if tmpl.MatchesName("GPL-3.0-or-later") {
+		t.Errorf("GPL-3.0-or-later matches %s", tmpl.ID)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	format := func(chunks []Chunk) string {
+		parts := []string{}
+		for _, c := range chunks {
+			parts = append(parts, string(c.Op)+strings.Join(c.Words, " "))
+		}
+		return strings.Join(parts, "|")
+	}
+	tests := []struct {
+		a, b   string
+		wanted string
+	}{
+		{"a b c", "a b c", "=a b c"},
+		{"a b c d", "a x c d e", "=a|-b|+x|=c d|+e"},
+		{"", "a b", "+a b"},
+		{"a b", "", "-a b"},
+	}
+	for _, test := range tests {
+		got := format(Diff(strings.Fields(test.a), strings.Fields(test.b)))
+		if got != test.wanted {
+			t.Errorf("unexpected diff of %q and %q: %s != %s", test.a, test.b,
+				got, test.wanted)
+		}
+	}
+}
+
+// benchmarkTemplates returns the embedded templates and the text of the
+// Apache license, the longest common one, with a copyright header.
+func benchmarkTemplates(b *testing.B) ([]*Template, []byte) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		b.Fatal(err)
+	}
+	apache := FindTemplate(templates, "Apache-2.0")
+	if apache == nil {
+		b.Fatal("Apache-2.0 template not found")
+	}
+	return templates, []byte("Copyright 2020 The Authors\n\n" + apache.Text)
+}
+
+func BenchmarkMakeWordSet(b *testing.B) {
+	_, license := benchmarkTemplates(b)
+	b.SetBytes(int64(len(license)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MakeWordSet(license)
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	templates, license := benchmarkTemplates(b)
+	b.SetBytes(int64(len(license)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Match(license, templates)
+	}
+}
+
+func BenchmarkRank(b *testing.B) {
+	templates, license := benchmarkTemplates(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Rank(license, templates)
+	}
+}
+
+func TestFindClauses(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := map[string]Clauses{
+		"Apache-2.0":   {PatentGrant: true, Trademark: true},
+		"MPL-2.0":      {PatentGrant: true, Trademark: true},
+		"GPL-3.0":      {PatentGrant: true},
+		"GPL-2.0":      {},
+		"MIT":          {},
+		"BSD-3-Clause": {},
+	}
+	for _, templ := range templates {
+		w, ok := wanted[templ.ID]
+		if !ok || templ.Language != "" {
+			continue
+		}
+		if got := FindClauses([]byte(templ.Text)); got != w {
+			t.Errorf("unexpected %s clauses: %+v != %+v", templ.ID, got, w)
+		}
+	}
+	patents := `Google hereby grants to You a perpetual, worldwide,
+non-exclusive, no-charge, royalty-free, irrevocable (except as stated in this
+section) patent license to make, have made, use, offer to sell, sell, import,
+transfer and otherwise run, modify and propagate the contents of this
+implementation of Go.`
+	if got := FindClauses([]byte(patents)); !got.PatentGrant || got.Trademark {
+		t.Errorf("unexpected PATENTS clauses: %+v", got)
+	}
+}
+
+func TestMatchConcatenated(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]*Template{}
+	for _, templ := range templates {
+		if templ.Language == "" {
+			byID[templ.ID] = templ
+		}
+	}
+	vendored := ""
+	for _, id := range []string{"Apache-2.0", "MIT", "BSD-3-Clause", "GPL-2.0", "MIT"} {
+		vendored += strings.Repeat("=", 80) + "\n= vendor/example.com/" +
+			strings.ToLower(id) + " licensed under: =\n\n" + byID[id].Text +
+			"\n= vendor/example.com/" + strings.ToLower(id) + "/LICENSE 0123abcd\n"
+	}
+	vendored += strings.Repeat("=", 80) + "\n"
+	titled := "MIT License\n\n" + byID["MIT"].Text + "\n\nISC License\n\n" +
+		byID["ISC"].Text + "\n\n" + byID["Apache-2.0"].Text
+	tests := []struct {
+		license string
+		title   string
+		id      string
+		parts   int
+	}{
+		{vendored, "Apache License 2.0 AND MIT License AND BSD 3-clause \"New\" or \"Revised\" License AND " +
+			"GNU General Public License v2.0", "Apache-2.0 AND MIT AND BSD-3-Clause AND GPL-2.0", 5},
+		{titled, "MIT License AND ISC License AND Apache License 2.0", "MIT AND ISC AND Apache-2.0", 3},
+	}
+	for _, test := range tests {
+		m := Match([]byte(test.license), templates)
+		if m.Template == nil || len(m.Parts) != test.parts || m.Score < .9 {
+			t.Fatalf("concatenated license not split: %d parts, score %.2f", len(m.Parts), m.Score)
+		}
+		if m.Template.Title != test.title || m.Template.ID != test.id {
+			t.Errorf("unexpected combined template: %q, %q", m.Template.Title, m.Template.ID)
+		}
+	}
+	for _, templ := range templates {
+		if m := Match([]byte(templ.Text), templates); len(m.Parts) > 0 {
+			t.Errorf("%s license split in %d parts", templ.Title, len(m.Parts))
+		}
+	}
+	c := Combine([]*Template{byID["MIT"], byID["GPL-2.0"]})
+	if !c.OSIApproved || hasString(c.Permitted, "patent-grant") ||
+		!hasString(c.Required, "disclose-source") || len(c.Parts) != 2 {
+		t.Errorf("unexpected combined template: %+v", c)
+	}
+	if Combine([]*Template{byID["MIT"], byID["MIT"]}) != byID["MIT"] {
+		t.Errorf("template combined with itself")
+	}
+}
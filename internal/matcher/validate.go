@@ -0,0 +1,60 @@
+package matcher
+
+import "fmt"
+
+// templateName returns the file name of t, or its title if it has none.
+func templateName(t *Template) string {
+	if t.File != "" {
+		return t.File
+	}
+	return t.Title
+}
+
+// Validate returns the problems of templates breaking detection: a missing
+// title, category or SPDX identifier, identifiers or titles shared by several
+// templates, and license texts not matching their own template best with a
+// score of 1. Templates without text, like "No License", never match and need
+// no SPDX identifier. Translations share the identifier of the original
+// license, once per language.
+func Validate(templates []*Template) []error {
+	errs := []error{}
+	ids := map[string]*Template{}
+	titles := map[string]*Template{}
+	for i, t := range templates {
+		name := templateName(t)
+		if name == "" {
+			name = fmt.Sprintf("template #%d", i+1)
+		}
+		if t.Title == "" {
+			errs = append(errs, fmt.Errorf("%s: missing title", name))
+		} else if other, ok := titles[t.Title]; ok {
+			errs = append(errs, fmt.Errorf("%s: title %q already used by %s", name,
+				t.Title, templateName(other)))
+		} else {
+			titles[t.Title] = t
+		}
+		if t.Category == "" {
+			errs = append(errs, fmt.Errorf("%s: missing category", name))
+		}
+		if len(t.Words) == 0 {
+			continue
+		}
+		if t.ID == "" {
+			errs = append(errs, fmt.Errorf("%s: missing spdx-id", name))
+		} else if other, ok := ids[t.ID+"/"+t.Language]; ok {
+			errs = append(errs, fmt.Errorf("%s: spdx-id %s already used by %s", name,
+				t.ID, templateName(other)))
+		} else {
+			ids[t.ID+"/"+t.Language] = t
+		}
+		m := Match([]byte(t.Text), templates)
+		if m.Template != t {
+			errs = append(errs, fmt.Errorf("%s: text matches %s better, with score %.2f",
+				name, templateName(m.Template), m.Score))
+		} else if m.Score < 1 {
+			errs = append(errs, fmt.Errorf("%s: text matches itself with score %.2f",
+				name, m.Score))
+		}
+	}
+	return errs
+}
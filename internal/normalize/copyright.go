@@ -0,0 +1,135 @@
+package normalize
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// reCopyrightPrefix matches the "Copyright" word and the copyright signs
+	// following it, in all their variants.
+	reCopyrightPrefix = regexp.MustCompile(`^(?i)copyright(?:\s*(?:©|\(c\)))*\s*`)
+	// reYears matches the years leading copyright statements, like
+	// "2015-2019, 2021".
+	reYears = regexp.MustCompile(`^\d{4}(?:\s*[-–]\s*\d{4})?(?:\s*,?\s*\d{4}(?:\s*[-–]\s*\d{4})?)*`)
+	reYear  = regexp.MustCompile(`(\d{4})(?:\s*[-–]\s*(\d{4}))?`)
+	// reReserved matches the "all rights reserved" mention closing
+	// statements, which holders use inconsistently.
+	reReserved = regexp.MustCompile(`(?i)[\s,]*all rights reserved[\s.]*$`)
+)
+
+// parseCopyright splits a copyright statement into its years and holder. It
+// returns a nil slice if the statement does not start with years, like
+// "Copyright [year] name", or with open ranges, like "2015-present".
+func parseCopyright(statement string) ([]int, string) {
+	s := strings.Join(strings.Fields(statement), " ")
+	s = reCopyrightPrefix.ReplaceAllString(s, "")
+	m := reYears.FindString(s)
+	if m == "" {
+		return nil, ""
+	}
+	years := []int{}
+	for _, r := range reYear.FindAllStringSubmatch(m, -1) {
+		first, _ := strconv.Atoi(r[1])
+		last := first
+		if r[2] != "" {
+			last, _ = strconv.Atoi(r[2])
+		}
+		for y := first; y <= last; y++ {
+			years = append(years, y)
+		}
+	}
+	rest := s[len(m):]
+	if r := strings.TrimSpace(rest); len(years) == 0 || strings.HasPrefix(r, "-") ||
+		strings.HasPrefix(r, "–") {
+		return nil, ""
+	}
+	holder := strings.TrimLeft(rest, " ,")
+	holder = strings.TrimPrefix(holder, "by ")
+	return years, holder
+}
+
+// formatYears returns years as a comma-separated list, consecutive years
+// collapsed into ranges like "2015-2017".
+func formatYears(years []int) string {
+	sort.Ints(years)
+	parts := []string{}
+	for i := 0; i < len(years); {
+		j := i
+		for j+1 < len(years) && years[j+1] <= years[j]+1 {
+			j++
+		}
+		if years[j] == years[i] {
+			parts = append(parts, strconv.Itoa(years[i]))
+		} else {
+			parts = append(parts, strconv.Itoa(years[i])+"-"+strconv.Itoa(years[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// MergeCopyrights merges copyright statements, as returned by Copyrights,
+// by holder: the years of statements naming the same holder, compared
+// case-insensitively and ignoring "All rights reserved" mentions and trailing
+// punctuation, are merged into collapsed ranges, and the copyright sign
+// variants are written as "(c)". Statements are returned in order of first
+// appearance of their holder, those without years unchanged and
+// deduplicated.
+func MergeCopyrights(statements []string) []string {
+	type entry struct {
+		holder string
+		years  []int
+		raw    string
+	}
+	entries := []*entry{}
+	byKey := map[string]*entry{}
+	for _, statement := range statements {
+		years, holder := parseCopyright(statement)
+		key := "raw:" + statement
+		if years != nil {
+			holder = reReserved.ReplaceAllString(holder, "")
+			key = strings.ToLower(strings.TrimRight(holder, " .,"))
+		}
+		if e, ok := byKey[key]; ok {
+			e.years = append(e.years, years...)
+			continue
+		}
+		e := &entry{holder: holder, years: years, raw: statement}
+		byKey[key] = e
+		entries = append(entries, e)
+	}
+	merged := []string{}
+	for _, e := range entries {
+		if e.years == nil {
+			merged = append(merged, e.raw)
+			continue
+		}
+		s := "Copyright (c) " + formatYears(e.years)
+		if e.holder != "" {
+			s += " " + e.holder
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// StripCopyrights returns data decoded, without the lines holding copyright
+// statements nor the blank lines leading the remaining text, so license
+// texts shared by several holders can be written once.
+func StripCopyrights(data []byte) []byte {
+	lines := [][]byte{}
+	for _, line := range bytes.Split(Decode(data), []byte("\n")) {
+		if reCopyrightAt.Match(bytes.TrimSpace(line)) {
+			continue
+		}
+		if len(lines) == 0 && len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -0,0 +1,129 @@
+// Package normalize converts license files to a canonical text form before
+// they are matched against templates.
+package normalize
+
+import (
+	"bytes"
+	"encoding/binary"
+	"regexp"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+
+	reCopyright = regexp.MustCompile(
+		`(?i)\s*Copyright (?:©|\(c\))?\s*(?:\d{4}|\[year\]).*`)
+	// reCopyrightAt is reCopyright without leading spaces, anchored. Matching
+	// it at each "copyright" is much faster than letting reCopyright try every
+	// position of long texts.
+	reCopyrightAt = regexp.MustCompile(
+		`^(?i)Copyright (?:©|\(c\))?\s*(?:\d{4}|\[year\]).*`)
+	copyright = []byte("copyright")
+)
+
+// Decode returns data converted to UTF-8 with LF line endings. UTF-16 input
+// is detected with its byte order mark or, lacking one, by the presence of
+// NUL bytes. Input which is not valid UTF-8 is assumed to be Latin-1.
+func Decode(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		data = data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		data = decodeUTF16(data[2:], binary.LittleEndian)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		data = decodeUTF16(data[2:], binary.BigEndian)
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		data = decodeUTF16(data, binary.LittleEndian)
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		data = decodeUTF16(data, binary.BigEndian)
+	case !utf8.Valid(data):
+		data = decodeLatin1(data)
+	}
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	data = bytes.Replace(data, []byte("\r"), []byte("\n"), -1)
+	return data
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, order.Uint16(data[i:]))
+	}
+	buf := make([]byte, 0, len(data))
+	for _, r := range utf16.Decode(units) {
+		buf = appendRune(buf, r)
+	}
+	return buf
+}
+
+func decodeLatin1(data []byte) []byte {
+	buf := make([]byte, 0, len(data)+len(data)/8)
+	for _, c := range data {
+		buf = appendRune(buf, rune(c))
+	}
+	return buf
+}
+
+func appendRune(buf []byte, r rune) []byte {
+	var tmp [utf8.UTFMax]byte
+	n := utf8.EncodeRune(tmp[:], r)
+	return append(buf, tmp[:n]...)
+}
+
+// Clean decodes data, lowercases it and removes copyright statements, which
+// vary between otherwise identical licenses.
+func Clean(data []byte) []byte {
+	data = bytes.ToLower(Decode(data))
+	return removeCopyrights(data)
+}
+
+// removeCopyrights removes the copyright statements of lowercase data along
+// with the spaces preceding them, like replacing reCopyright matches.
+func removeCopyrights(data []byte) []byte {
+	cleaned := data[:0]
+	last := 0
+	for pos := 0; pos < len(data); {
+		i := bytes.Index(data[pos:], copyright)
+		if i < 0 {
+			break
+		}
+		i += pos
+		m := reCopyrightAt.FindIndex(data[i:])
+		if m == nil {
+			pos = i + len(copyright)
+			continue
+		}
+		start := i
+		for start > last && isSpace(data[start-1]) {
+			start--
+		}
+		cleaned = append(cleaned, data[last:start]...)
+		last = i + m[1]
+		pos = last
+	}
+	return append(cleaned, data[last:]...)
+}
+
+// isSpace returns true for the characters matched by \s in regexps.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// Copyrights returns the distinct copyright statements of data, one per line,
+// in order of appearance.
+func Copyrights(data []byte) []string {
+	seen := map[string]bool{}
+	statements := []string{}
+	for _, m := range reCopyright.FindAll(Decode(data), -1) {
+		s := string(bytes.TrimSpace(m))
+		if !seen[s] {
+			seen[s] = true
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
@@ -0,0 +1,108 @@
+package normalize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	data := `The MIT License (MIT)
+
+	Copyright (c) 2013 Ben Johnson
+	
+	Some other lines.
+	And more.
+	`
+	cleaned := string(Clean([]byte(data)))
+	wanted := "the mit license (mit)\n\t\n\tsome other lines.\n\tand more.\n\t"
+	if wanted != cleaned {
+		t.Fatalf("license data mismatch: %q\n!=\n%q", cleaned, wanted)
+	}
+}
+
+func TestCleanCopyrightSign(t *testing.T) {
+	for _, data := range []string{
+		"Copyright © 2013 Ben Johnson\nfoo",
+		"Copyright \xa9 2013 Ben Johnson\nfoo",
+	} {
+		cleaned := string(Clean([]byte(data)))
+		if cleaned != "\nfoo" {
+			t.Fatalf("copyright not removed from %q: %q", data, cleaned)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		Data   string
+		Wanted string
+	}{
+		{"plain\r\ntext\r", "plain\ntext\n"},
+		{"\xef\xbb\xbfbom", "bom"},
+		{"\xff\xfeM\x00I\x00T\x00\r\x00\n\x00", "MIT\n"},
+		{"\xfe\xff\x00M\x00I\x00T", "MIT"},
+		{"M\x00I\x00T\x00", "MIT"},
+		{"Fran\xe7ois", "François"},
+	}
+	for _, test := range tests {
+		got := string(Decode([]byte(test.Data)))
+		if got != test.Wanted {
+			t.Fatalf("decoding %q: %q != %q", test.Data, got, test.Wanted)
+		}
+	}
+}
+
+func TestCopyrights(t *testing.T) {
+	data := "Copyright (c) 2013 Ben Johnson\n\nThe above copyright notice\n" +
+		"  Copyright 2015 Foo Bar\nCopyright (c) 2013 Ben Johnson\n"
+	got := strings.Join(Copyrights([]byte(data)), "|")
+	wanted := "Copyright (c) 2013 Ben Johnson|Copyright 2015 Foo Bar"
+	if got != wanted {
+		t.Fatalf("%q != %q", got, wanted)
+	}
+}
+
+func TestRemoveCopyrights(t *testing.T) {
+	for _, data := range []string{
+		"copyright 2013 a\n  copyright (c) 2014 b\nfoo",
+		"the copyright holders\n\ncopyright [year] x",
+		"xcopyright 2020 y\n\tcopyright ©\n2021 z",
+		"copyright notice\ncopyright",
+		"",
+	} {
+		got := string(removeCopyrights([]byte(data)))
+		wanted := string(reCopyright.ReplaceAll([]byte(data), nil))
+		if got != wanted {
+			t.Errorf("removing copyrights of %q: %q != %q", data, got, wanted)
+		}
+	}
+}
+
+func TestMergeCopyrights(t *testing.T) {
+	statements := []string{
+		"Copyright (c) 2015 Foo Inc.",
+		"Copyright 2016-2017 Bar",
+		"Copyright © 2016 Foo Inc. All rights reserved.",
+		"COPYRIGHT (C) 2019, 2018 foo inc",
+		"Copyright [year] Baz",
+		"Copyright 2020-present Qux",
+		"Copyright [year] Baz",
+		"Copyright (c) 2021 by Bar",
+	}
+	got := strings.Join(MergeCopyrights(statements), "|")
+	wanted := "Copyright (c) 2015-2016, 2018-2019 Foo Inc.|" +
+		"Copyright (c) 2016-2017, 2021 Bar|Copyright [year] Baz|Copyright 2020-present Qux"
+	if got != wanted {
+		t.Fatalf("%q != %q", got, wanted)
+	}
+}
+
+func TestStripCopyrights(t *testing.T) {
+	data := "\nCopyright (c) 2013 Ben Johnson\n  Copyright 2015 Foo\n\n" +
+		"Permission is hereby granted.\n\nThe above copyright notice.\n"
+	got := string(StripCopyrights([]byte(data)))
+	wanted := "Permission is hereby granted.\n\nThe above copyright notice.\n"
+	if got != wanted {
+		t.Fatalf("%q != %q", got, wanted)
+	}
+}
@@ -0,0 +1,129 @@
+// Package policy evaluates detected licenses against allowed and denied
+// license lists.
+package policy
+
+import (
+	"fmt"
+
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+// Policy lists licenses by SPDX identifier, title or nickname. Denied
+// licenses are always violations. If Allow is not empty, licenses missing from
+// it are violations too. Require lists the properties licenses must have,
+// among RequireValues.
+type Policy struct {
+	Allow   []string `json:"allow,omitempty"`
+	Deny    []string `json:"deny,omitempty"`
+	Require []string `json:"require,omitempty"`
+}
+
+// Empty returns true if the policy sets no rule.
+func (p *Policy) Empty() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0 && len(p.Require) == 0
+}
+
+// RequireValues are the license properties policies can require. Besides
+// approvals, licenses can be required to permit commercial use or
+// modifications, which non-commercial and no-derivatives licenses, like some
+// Creative Commons ones, forbid. Clauses are detected in license files:
+// "patent-grant" requires an explicit patent grant, and "no-trademark-clause"
+// rejects clauses withholding trademark rights.
+var RequireValues = []string{"osi-approved", "fsf-libre", "commercial-use", "modifications",
+	"patent-grant", "no-trademark-clause"}
+
+// hasProperty returns true if license l, whose template is set, has the
+// named property. Unknown properties are never satisfied.
+func hasProperty(l report.License, property string) bool {
+	t := l.Template
+	switch property {
+	case "patent-grant":
+		return l.PatentGrant
+	case "no-trademark-clause":
+		return !l.TrademarkClause
+	case "osi-approved":
+		return t.OSIApproved
+	case "fsf-libre":
+		return t.FSFLibre
+	case "commercial-use", "modifications":
+		for _, p := range t.Permitted {
+			if p == property {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Violation is a license breaking the policy.
+type Violation struct {
+	License report.License
+	Reason  string
+}
+
+// Check returns the licenses breaking the policy. Licenses not detected with
+// enough confidence are violations unless the policy is empty.
+func (p *Policy) Check(licenses []report.License, confidence float64) []Violation {
+	if p.Empty() {
+		return nil
+	}
+	violations := []Violation{}
+	for _, l := range licenses {
+		reason := p.Evaluate(l, confidence)
+		if reason != "" {
+			violations = append(violations, Violation{
+				License: l,
+				Reason:  reason,
+			})
+		}
+	}
+	return violations
+}
+
+// Evaluate returns why the license breaks the policy, or an empty string.
+// Unlike Check, licenses not detected with enough confidence are violations
+// even if the policy is empty. Each license contained in concatenated license
+// files must comply.
+func (p *Policy) Evaluate(l report.License, confidence float64) string {
+	if l.Template == nil || l.Score < confidence {
+		return "unknown license"
+	}
+	for _, t := range l.Template.Parts {
+		part := l
+		part.Template = t
+		if reason := p.Evaluate(part, confidence); reason != "" {
+			return reason
+		}
+	}
+	if len(l.Template.Parts) > 0 {
+		return ""
+	}
+	for _, name := range p.Deny {
+		if l.Template.MatchesName(name) {
+			return fmt.Sprintf("%s is denied", l.Template.Title)
+		}
+	}
+	for _, property := range p.Require {
+		if hasProperty(l, property) {
+			continue
+		}
+		switch property {
+		case "commercial-use", "modifications":
+			return fmt.Sprintf("%s does not permit %s", l.Template.Title, property)
+		case "patent-grant":
+			return fmt.Sprintf("%s has no explicit patent grant", l.Template.Title)
+		case "no-trademark-clause":
+			return fmt.Sprintf("%s has a trademark clause", l.Template.Title)
+		}
+		return fmt.Sprintf("%s is not %s", l.Template.Title, property)
+	}
+	if len(p.Allow) == 0 {
+		return ""
+	}
+	for _, name := range p.Allow {
+		if l.Template.MatchesName(name) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s is not allowed", l.Template.Title)
+}
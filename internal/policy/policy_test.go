@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+func TestCheck(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	gpl := &matcher.Template{Title: "GNU General Public License v3.0", ID: "GPL-3.0"}
+	isc := &matcher.Template{Title: "ISC License", ID: "ISC"}
+	licenses := []report.License{
+		{Package: "a", Template: mit, Score: 1},
+		{Package: "b", Template: gpl, Score: 1},
+		{Package: "c", Template: isc, Score: 1},
+		{Package: "d", Template: mit, Score: 0.5},
+		{Package: "e", Template: matcher.Combine([]*matcher.Template{mit, isc}), Score: 1},
+		{Package: "f", Template: matcher.Combine([]*matcher.Template{mit, gpl}), Score: 1},
+	}
+	p := &Policy{Allow: []string{"mit", "GNU GPL v3.0"}, Deny: []string{"GPL-3.0"}}
+	violations := p.Check(licenses, 0.9)
+	wanted := []string{
+		"b: GNU General Public License v3.0 is denied",
+		"c: ISC License is not allowed",
+		"d: unknown license",
+		"e: ISC License is not allowed",
+		"f: GNU General Public License v3.0 is denied",
+	}
+	if len(violations) != len(wanted) {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+	for i, v := range violations {
+		got := v.License.Package + ": " + v.Reason
+		if got != wanted[i] {
+			t.Fatalf("%q != %q", got, wanted[i])
+		}
+	}
+	if v := (&Policy{}).Check(licenses, 0.9); len(v) != 0 {
+		t.Fatalf("empty policy reported violations: %v", v)
+	}
+}
+
+func TestCheckRequire(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", OSIApproved: true, FSFLibre: true}
+	wtfpl := &matcher.Template{Title: "WTFPL", FSFLibre: true}
+	licenses := []report.License{
+		{Package: "a", Template: mit, Score: 1},
+		{Package: "b", Template: wtfpl, Score: 1},
+	}
+	p := &Policy{Require: []string{"osi-approved"}}
+	violations := p.Check(licenses, 0.9)
+	if len(violations) != 1 || violations[0].Reason != "WTFPL is not osi-approved" {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+	p = &Policy{Require: []string{"fsf-libre"}}
+	if violations := p.Check(licenses, 0.9); len(violations) != 0 {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+	nc := &matcher.Template{Title: "CC BY-NC 4.0", Permitted: []string{"modifications"}}
+	licenses = append(licenses, report.License{Package: "c", Template: nc, Score: 1})
+	mit.Permitted = []string{"commercial-use", "modifications"}
+	wtfpl.Permitted = mit.Permitted
+	p = &Policy{Require: []string{"commercial-use"}}
+	violations = p.Check(licenses, 0.9)
+	if len(violations) != 1 || violations[0].Reason != "CC BY-NC 4.0 does not permit commercial-use" {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+	apache := &matcher.Template{Title: "Apache License 2.0"}
+	licenses = []report.License{
+		{Package: "a", Template: mit, Score: 1},
+		{Package: "d", Template: apache, Score: 1, PatentGrant: true, TrademarkClause: true},
+	}
+	p = &Policy{Require: []string{"patent-grant"}}
+	violations = p.Check(licenses, 0.9)
+	if len(violations) != 1 || violations[0].Reason != "MIT License has no explicit patent grant" {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+	p = &Policy{Require: []string{"no-trademark-clause"}}
+	violations = p.Check(licenses, 0.9)
+	if len(violations) != 1 || violations[0].Reason != "Apache License 2.0 has a trademark clause" {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+}
+
+func TestWaive(t *testing.T) {
+	gpl := &matcher.Template{Title: "GNU General Public License v3.0", ID: "GPL-3.0"}
+	violations := []Violation{
+		{License: report.License{Package: "a", Template: gpl, Score: 1}},
+		{License: report.License{Package: "b", Template: gpl, Score: 1}},
+		{License: report.License{Package: "c", Template: gpl, Score: 1}},
+		{License: report.License{Package: "d", Declared: "BUSL-1.1"}},
+	}
+	waivers := []Waiver{
+		{Package: "a", License: "GPL-3.0", Expires: "2020-01-31"},
+		{Package: "b", License: "MIT", Expires: "2020-01-31"},
+		{Package: "c", Expires: "2020-01-30"},
+		{Package: "d", License: "BUSL-1.1", Expires: "2020-01-31"},
+	}
+	now := time.Date(2020, 1, 31, 12, 0, 0, 0, time.Local)
+	remaining, expired := Waive(violations, waivers, now)
+	if len(remaining) != 2 || remaining[0].License.Package != "b" ||
+		remaining[1].License.Package != "c" {
+		t.Fatalf("unexpected remaining violations: %v", remaining)
+	}
+	if len(expired) != 1 || expired[0].Package != "c" {
+		t.Fatalf("unexpected expired waivers: %v", expired)
+	}
+}
@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// dateLayout is the format of waiver expiry dates.
+const dateLayout = "2006-01-02"
+
+// Waiver suppresses the violations of a package until it expires, so known
+// violations do not fail checks while they are being addressed.
+type Waiver struct {
+	Package string `json:"package"`
+	// License restricts the waiver to the violations of one license,
+	// designated by SPDX identifier, title or nickname. Empty matches all.
+	License string `json:"license,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	// Expires is the last day the waiver applies, as YYYY-MM-DD.
+	Expires string `json:"expires"`
+}
+
+// ReadWaivers reads a JSON array of waivers.
+func ReadWaivers(path string) ([]Waiver, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	waivers := []Waiver{}
+	err = json.Unmarshal(data, &waivers)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	err = ValidateWaivers(waivers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return waivers, nil
+}
+
+// ValidateWaivers returns an error if a waiver has no package or an invalid
+// expiry date.
+func ValidateWaivers(waivers []Waiver) error {
+	for _, w := range waivers {
+		if w.Package == "" {
+			return fmt.Errorf("waiver without package")
+		}
+		_, err := time.ParseInLocation(dateLayout, w.Expires, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid expiry date of %s waiver: %q",
+				w.Package, w.Expires)
+		}
+	}
+	return nil
+}
+
+// Expired returns true if the waiver no longer applies at now.
+func (w *Waiver) Expired(now time.Time) bool {
+	t, err := time.ParseInLocation(dateLayout, w.Expires, time.Local)
+	if err != nil {
+		return true
+	}
+	return !now.Before(t.AddDate(0, 0, 1))
+}
+
+func (w *Waiver) covers(v Violation) bool {
+	if w.Package != v.License.Package {
+		return false
+	}
+	if w.License == "" {
+		return true
+	}
+	if v.License.Template != nil && v.License.Template.MatchesName(w.License) {
+		return true
+	}
+	return v.License.Declared != "" && v.License.Declared == w.License
+}
+
+// Waive returns the violations not covered by unexpired waivers, and the
+// expired waivers.
+func Waive(violations []Violation, waivers []Waiver, now time.Time) (
+	[]Violation, []Waiver) {
+
+	active := []Waiver{}
+	expired := []Waiver{}
+	for _, w := range waivers {
+		if w.Expired(now) {
+			expired = append(expired, w)
+		} else {
+			active = append(active, w)
+		}
+	}
+	remaining := []Violation{}
+	for _, v := range violations {
+		waived := false
+		for i := range active {
+			if active[i].covers(v) {
+				waived = true
+				break
+			}
+		}
+		if !waived {
+			remaining = append(remaining, v)
+		}
+	}
+	return remaining, expired
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// archiveTime is the modification time of archived files, fixed so the
+// archives of identical reports are identical.
+var archiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveWriter adds files to an archive.
+type archiveWriter interface {
+	Add(name string, data []byte) error
+	Close() error
+}
+
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchive) Add(name string, data []byte) error {
+	w, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: archiveTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (a *zipArchive) Close() error {
+	return a.zw.Close()
+}
+
+type tarArchive struct {
+	tw *tar.Writer
+}
+
+func (a *tarArchive) Add(name string, data []byte) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: archiveTime,
+		Format:  tar.FormatPAX,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.tw.Write(data)
+	return err
+}
+
+func (a *tarArchive) Close() error {
+	return a.tw.Close()
+}
+
+// archiveName returns the name of the file at path in the archive of l: a
+// directory named after its package, prefixed with its source unless it is a
+// Go module.
+func archiveName(l License, file string) string {
+	dir := l.Package
+	if l.Source != "" && l.Source != "go" {
+		dir = l.Source + "/" + dir
+	}
+	return path.Join(dir, filepath.Base(file))
+}
+
+// WriteArchive writes the license and NOTICE files of licenses as a zip or
+// tar archive, named by format, like with the save command. A manifest.json
+// file lists the records of licenses, whose paths are relative to the
+// archive root.
+func WriteArchive(w io.Writer, format string, licenses []License) error {
+	var a archiveWriter
+	switch format {
+	case "zip":
+		a = &zipArchive{zip.NewWriter(w)}
+	case "tar":
+		a = &tarArchive{tar.NewWriter(w)}
+	default:
+		return fmt.Errorf("unknown archive format %q", format)
+	}
+	records := []Record{}
+	files := map[string]string{}
+	names := []string{}
+	for _, l := range licenses {
+		r := NewRecord(l)
+		for _, f := range []struct {
+			path string
+			name *string
+		}{{l.Path, &r.Path}, {l.Notice, &r.Notice}} {
+			if f.path == "" {
+				continue
+			}
+			*f.name = archiveName(l, f.path)
+			if _, ok := files[*f.name]; !ok {
+				names = append(names, *f.name)
+			}
+			files[*f.name] = f.path
+		}
+		records = append(records, r)
+	}
+	manifest, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = a.Add("manifest.json", append(manifest, '\n'))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		data, err := ioutil.ReadFile(files[name])
+		if err != nil {
+			return err
+		}
+		err = a.Add(name, data)
+		if err != nil {
+			return err
+		}
+	}
+	return a.Close()
+}
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// obligations lists the license conditions reported in release checklists,
+// in display order, with the task they translate to.
+var obligations = []struct {
+	Condition string
+	Task      string
+}{
+	{"include-copyright", "Include copyright and license notices"},
+	{"document-changes", "Document changes made to the licensed code"},
+	{"disclose-source", "Provide the source code or a written source offer"},
+	{"network-use-disclose", "Provide the source code to network users"},
+	{"library-usage", "Allow relinking with modified versions of the library"},
+}
+
+// WriteChecklist writes a markdown checklist of the obligations derived from
+// supplied licenses, to be completed and archived with a release. Licenses
+// scoring below confidence are listed for manual review.
+func WriteChecklist(w io.Writer, release string, licenses []License,
+	confidence float64) error {
+
+	lines := []string{
+		"# License obligations for " + release,
+		"",
+	}
+	licenses = Sorted(licenses, confidence)
+	unknown := []License{}
+	for _, o := range obligations {
+		lines = append(lines, "## "+o.Task, "")
+		titles := []string{}
+		items := []string{}
+		for _, l := range licenses {
+			if l.Template == nil || l.Score < confidence {
+				continue
+			}
+			if !hasString(l.Template.Required, o.Condition) {
+				continue
+			}
+			if !hasString(titles, l.Template.Title) {
+				titles = append(titles, l.Template.Title)
+			}
+			items = append(items, fmt.Sprintf("- [ ] %s (%s)", l.Package,
+				l.Template.Title))
+		}
+		if len(items) == 0 {
+			lines = append(lines, "Not applicable.", "")
+			continue
+		}
+		lines = append(lines, "Required by: "+strings.Join(titles, ", "), "")
+		lines = append(lines, items...)
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, "## Reproduce NOTICE files", "")
+	notices := 0
+	for _, l := range licenses {
+		if l.Notice != "" {
+			lines = append(lines, fmt.Sprintf("- [ ] %s: %s", l.Package, l.Notice))
+			notices++
+		}
+	}
+	if notices == 0 {
+		lines = append(lines, "Not applicable.")
+	}
+	lines = append(lines, "")
+
+	for _, l := range licenses {
+		if l.Template == nil || l.Score < confidence {
+			unknown = append(unknown, l)
+		}
+	}
+	if len(unknown) > 0 {
+		lines = append(lines, "## Review unidentified licenses", "")
+		for _, l := range unknown {
+			item := "- [ ] " + l.Package
+			if l.Path != "" {
+				item += ": " + l.Path
+			}
+			lines = append(lines, item)
+		}
+		lines = append(lines, "")
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func hasString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package report
+
+// ANSI escape sequences coloring table entries. They all have the same
+// length so colored columns stay aligned.
+const (
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorRed     = "\x1b[31m"
+	colorDefault = "\x1b[39m"
+	colorReset   = "\x1b[0m"
+)
+
+// severityColor returns the color of l in tables: red for policy violations
+// and unknown licenses, yellow for matches below confidence, green for exact
+// matches, the default color otherwise.
+func severityColor(l License, opts Options) string {
+	reason := evaluate(l, opts)
+	switch {
+	case l.Template != nil && l.Score < opts.Confidence &&
+		(reason == "" || reason == unknownReason):
+		return colorYellow
+	case reason != "" || (l.Template == nil && l.Declared == ""):
+		return colorRed
+	case l.Template != nil && l.Score > .99:
+		return colorGreen
+	}
+	return colorDefault
+}
+
+// colorize colors the first line of s.
+func colorize(s, color string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' || s[i] == '\t' {
+			return color + s[:i] + colorReset + s[i:]
+		}
+	}
+	return color + s + colorReset
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// Change is a package whose license differs between two reports.
+type Change struct {
+	Old License
+	New License
+}
+
+// Diff lists the differences between two reports.
+type Diff struct {
+	Added   []License
+	Removed []License
+	Changed []Change
+}
+
+// Empty returns true if the reports are equivalent.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// licenseName returns the title of the license template if it scores at
+// least confidence, the declared license otherwise, or "?".
+func licenseName(l License, confidence float64) string {
+	if l.Template != nil && l.Score >= confidence {
+		return l.Template.Title
+	}
+	if l.Declared != "" {
+		return l.Declared
+	}
+	return "?"
+}
+
+// Compare returns the packages added, removed or whose license changed
+// between before and after reports. Packages are identified by source and
+// name, so upgrades keeping the same license are not reported. Licenses are
+// compared by name, as computed with confidence.
+func Compare(before, after []License, confidence float64) *Diff {
+	type key struct {
+		Source  string
+		Package string
+	}
+	olds := map[key]License{}
+	for _, l := range before {
+		olds[key{l.Source, l.Package}] = l
+	}
+	news := map[key]License{}
+	for _, l := range after {
+		news[key{l.Source, l.Package}] = l
+	}
+	d := &Diff{}
+	for _, l := range after {
+		o, ok := olds[key{l.Source, l.Package}]
+		if !ok {
+			d.Added = append(d.Added, l)
+		} else if licenseName(o, confidence) != licenseName(l, confidence) {
+			d.Changed = append(d.Changed, Change{Old: o, New: l})
+		}
+	}
+	for _, l := range before {
+		if _, ok := news[key{l.Source, l.Package}]; !ok {
+			d.Removed = append(d.Removed, l)
+		}
+	}
+	d.Added = Sorted(d.Added, confidence)
+	d.Removed = Sorted(d.Removed, confidence)
+	sort.SliceStable(d.Changed, func(i, j int) bool {
+		return Less(d.Changed[i].New, d.Changed[j].New, confidence)
+	})
+	return d
+}
+
+// WriteDiff writes the differences as a table, one package per line, marked
+// with "+" if added, "-" if removed and "~" if its license changed.
+func WriteDiff(w io.Writer, d *Diff, confidence float64) error {
+	tw := tabwriter.NewWriter(w, 1, 4, 2, ' ', 0)
+	write := func(mark string, l License, version, license string) error {
+		_, err := tw.Write([]byte(mark + "\t" + l.Source + "\t" + l.Package + "\t" +
+			version + "\t" + license + "\n"))
+		return err
+	}
+	for _, l := range d.Added {
+		err := write("+", l, l.Version, licenseName(l, confidence))
+		if err != nil {
+			return err
+		}
+	}
+	for _, l := range d.Removed {
+		err := write("-", l, l.Version, licenseName(l, confidence))
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range d.Changed {
+		version := c.New.Version
+		if c.Old.Version != c.New.Version {
+			version = c.Old.Version + " -> " + c.New.Version
+		}
+		err := write("~", c.New, version, licenseName(c.Old, confidence)+" -> "+
+			licenseName(c.New, confidence))
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
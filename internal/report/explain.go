@@ -0,0 +1,171 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+const (
+	// explainColumn is the width of side-by-side diff columns.
+	explainColumn = 37
+	// explainContext is the number of unchanged lines displayed around
+	// differences when diffs are not displayed in full.
+	explainContext = 3
+	// explainCandidates is the number of templates listed with their score.
+	explainCandidates = 5
+)
+
+// wrapWords wraps words into lines of at most width characters. Longer
+// words are left on their own line.
+func wrapWords(words []string, width int) []string {
+	lines := []string{}
+	line := ""
+	for _, w := range words {
+		if line != "" && len(line)+1+len(w) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// sideBySide returns the lines displaying left and right columns separated
+// by marker.
+func sideBySide(left, right []string, marker string) []string {
+	lines := []string{}
+	for i := 0; i < len(left) || i < len(right); i++ {
+		l, r := "", ""
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		lines = append(lines, strings.TrimRight(
+			fmt.Sprintf("%-*s %s %s", explainColumn, l, marker, r), " "))
+	}
+	return lines
+}
+
+// diffLines renders chunks side by side, marking changed lines with "|",
+// lines only on the left with "<" and lines only on the right with ">".
+// Unless full is set, unchanged lines further than explainContext lines from
+// a difference are elided.
+func diffLines(chunks []matcher.Chunk, full bool) []string {
+	lines := []string{}
+	for i := 0; i < len(chunks); i++ {
+		c := chunks[i]
+		switch c.Op {
+		case '=':
+			same := wrapWords(c.Words, explainColumn)
+			block := sideBySide(same, same, " ")
+			if !full {
+				head, tail := explainContext, explainContext
+				if i == 0 {
+					head = 0
+				}
+				if i == len(chunks)-1 {
+					tail = 0
+				}
+				if len(block) > head+tail+1 {
+					elided := len(block) - head - tail
+					block = append(append(append([]string{}, block[:head]...),
+						fmt.Sprintf("... %d identical lines", elided)),
+						block[len(block)-tail:]...)
+				}
+			}
+			lines = append(lines, block...)
+		case '-':
+			left := wrapWords(c.Words, explainColumn)
+			if i+1 < len(chunks) && chunks[i+1].Op == '+' {
+				i++
+				right := wrapWords(chunks[i].Words, explainColumn)
+				lines = append(lines, sideBySide(left, right, "|")...)
+			} else {
+				lines = append(lines, sideBySide(left, nil, "<")...)
+			}
+		case '+':
+			right := wrapWords(c.Words, explainColumn)
+			lines = append(lines, sideBySide(nil, right, ">")...)
+		}
+	}
+	return lines
+}
+
+// WriteExplanation explains how the license file text of l was matched: the
+// template it was compared to, the score breakdown, the best scoring
+// candidates and a side-by-side diff of the normalized words of the license
+// file and the template. Unless full is set, unchanged parts of the diff are
+// elided.
+func WriteExplanation(w io.Writer, l License, text []byte,
+	templates []*matcher.Template, confidence float64, full bool) error {
+
+	results := matcher.Rank(text, templates)
+	if len(results) == 0 {
+		return fmt.Errorf("no license template")
+	}
+	result := results[0]
+	for _, r := range results {
+		if r.Template == l.Template {
+			result = r
+			break
+		}
+	}
+	name := l.Package
+	if l.Version != "" {
+		name += " " + l.Version
+	}
+	t := result.Template
+	title := t.Title
+	if t.ID != "" {
+		title += " (" + t.ID + ")"
+	}
+	verdict := "trusted"
+	if result.Score < confidence {
+		verdict = fmt.Sprintf("below the %.0f%% confidence threshold", 100*confidence)
+	}
+	breakdown := fmt.Sprintf("2 x %d common words / (%d license words + %d template words)",
+		result.CommonWords, result.LicenseWords, result.TemplateWords)
+	extra := result.LicenseWords - result.CommonWords
+	if w := matcher.ExtraWordsWeight(result.TemplateWords); w < 1 && extra > 0 {
+		breakdown = fmt.Sprintf("2 x %d common words / (%d common words + %.2f x %d extra "+
+			"license words + %d template words), short template", result.CommonWords,
+			result.CommonWords, w, extra, result.TemplateWords)
+	}
+	lines := []string{
+		"Package:   " + name,
+		"File:      " + l.Path,
+		"Template:  " + title,
+		fmt.Sprintf("Score:     %.1f%%, %s", 100*result.Score, verdict),
+		"           " + breakdown,
+		fmt.Sprintf("           %d extra words, %d missing words",
+			len(result.ExtraWords), len(result.MissingWords)),
+	}
+	if len(result.MissingSections) > 0 {
+		lines = append(lines, "Omitted:   "+strings.Join(result.MissingSections, ", ")+
+			", optional")
+	}
+	lines = append(lines, "", "Candidates:")
+	for i, r := range results {
+		if i == explainCandidates {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %5.1f%%  %s", 100*r.Score, r.Template.Title))
+	}
+	lines = append(lines, "")
+	lines = append(lines, sideBySide([]string{"LICENSE FILE"}, []string{"TEMPLATE"}, " ")...)
+	lines = append(lines, diffLines(matcher.Diff(matcher.Words(text),
+		matcher.Words([]byte(t.Text))), full)...)
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
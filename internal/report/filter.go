@@ -0,0 +1,97 @@
+package report
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter selects the licenses written in reports. License patterns are
+// case-insensitive path.Match patterns, like "GPL-*", matched against the
+// SPDX identifier, title and nickname of licenses detected with enough
+// confidence, or the identifiers of declared licenses. The "unknown" pattern
+// matches licenses not detected with enough confidence.
+type Filter struct {
+	// Only keeps the licenses matching any of its patterns, if not empty.
+	Only []string
+	// Exclude drops the licenses matching any of its patterns.
+	Exclude []string
+	// MinScore drops the licenses whose match scores below it.
+	MinScore float64
+}
+
+// Empty returns true if the filter keeps all licenses.
+func (f *Filter) Empty() bool {
+	return len(f.Only) == 0 && len(f.Exclude) == 0 && f.MinScore == 0
+}
+
+// Validate returns an error if a pattern is malformed.
+func (f *Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Only...), f.Exclude...) {
+		_, err := path.Match(strings.ToLower(pattern), "")
+		if err != nil {
+			return fmt.Errorf("invalid license pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// licenseMatches returns true if l matches pattern.
+func licenseMatches(l License, pattern string, confidence float64) bool {
+	pattern = strings.ToLower(pattern)
+	unknown := l.Template == nil || l.Score < confidence
+	if pattern == "unknown" {
+		return unknown
+	}
+	names := []string{}
+	if !unknown {
+		names = append(names, l.Template.ID, l.Template.Title, l.Template.Nickname)
+		for _, t := range l.Template.Parts {
+			names = append(names, t.ID, t.Title, t.Nickname)
+		}
+	} else if l.Declared != "" {
+		names = append(names, l.Declared)
+		names = append(names, ExpressionIDs(l.Declared)...)
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		ok, _ := path.Match(pattern, strings.ToLower(name))
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(l License, patterns []string, confidence float64) bool {
+	for _, p := range patterns {
+		if licenseMatches(l, p, confidence) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the licenses selected by the filter, as detected with
+// confidence.
+func (f *Filter) Apply(licenses []License, confidence float64) []License {
+	if f.Empty() {
+		return licenses
+	}
+	kept := []License{}
+	for _, l := range licenses {
+		if len(f.Only) > 0 && !matchesAny(l, f.Only, confidence) {
+			continue
+		}
+		if matchesAny(l, f.Exclude, confidence) {
+			continue
+		}
+		if l.Score < f.MinScore {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept
+}
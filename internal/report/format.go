@@ -0,0 +1,467 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+// Formats lists the output formats supported by Write.
+var Formats = []string{"table", "csv", "json", "html", "notice", "sarif", "junit", "ndjson",
+	"spdx", "zip", "tar"}
+
+// Options control how licenses are written.
+type Options struct {
+	// Confidence is the minimum score of trusted matches.
+	Confidence float64
+	// Words lists the words differing from matched templates, in table
+	// format.
+	Words bool
+	// Versions lists package versions and origins, in table format.
+	Versions bool
+	// Template is the path of a text/template file rendering licenses
+	// instead of format, executed with the data of TemplateModel, see
+	// WriteTemplate.
+	Template      string
+	TemplateModel string
+	// Check returns why a license violates the license policy, or an empty
+	// string, for formats reporting violations. Licenses scoring below
+	// Confidence are violations if it is nil.
+	Check func(l License) string
+	// GroupBy groups packages in table and json formats. The only supported
+	// value is "license".
+	GroupBy string
+	// Color colors table entries with ANSI escape sequences: green for exact
+	// matches, yellow for matches below Confidence and red for unknown
+	// licenses and policy violations.
+	Color bool
+	// Filter selects the licenses written.
+	Filter Filter
+	// Reproducible makes output depend on licenses only, so regenerated
+	// files do not change between runs and machines: absolute file paths are
+	// left out of formats which do not embed file contents, and SPDX
+	// documents are created at SOURCE_DATE_EPOCH, or the Unix epoch.
+	Reproducible bool
+	// Roots are directories, like the module cache, license file paths are
+	// written relative to, with slashes, so reports are comparable across
+	// machines. Formats embedding file contents keep absolute paths.
+	Roots []string
+}
+
+// Write writes the licenses selected by the filter of opts in named format,
+// or with the template of opts, sorted with Less. License file hashes are
+// computed if missing.
+func Write(w io.Writer, format string, licenses []License, opts Options) error {
+	err := opts.Filter.Validate()
+	if err != nil {
+		return err
+	}
+	licenses, err = withHashes(opts.Filter.Apply(licenses, opts.Confidence))
+	if err != nil {
+		return err
+	}
+	licenses = Sorted(withProjects(licenses), opts.Confidence)
+	if opts.Template != "" {
+		return WriteTemplate(w, opts.Template, opts.TemplateModel, licenses,
+			opts.Confidence)
+	}
+	if len(opts.Roots) > 0 && !readsFiles(format) {
+		licenses = RelativePaths(licenses, opts.Roots)
+	}
+	if opts.Reproducible && !readsFiles(format) {
+		licenses = withoutAbsolutePaths(licenses)
+	}
+	switch opts.GroupBy {
+	case "":
+	case "license":
+		return WriteGroups(w, format, licenses, opts)
+	default:
+		return fmt.Errorf("cannot group by %q, supported values: %v", opts.GroupBy,
+			GroupByValues)
+	}
+	switch format {
+	case "table":
+		return WriteTable(w, licenses, opts)
+	case "csv":
+		return WriteCSV(w, licenses, opts.Confidence)
+	case "json":
+		return WriteJSON(w, licenses)
+	case "html":
+		return WriteHTML(w, licenses, opts.Confidence)
+	case "notice":
+		return WriteNotice(w, licenses, opts.Confidence)
+	case "sarif":
+		return WriteSARIF(w, licenses, opts)
+	case "junit":
+		return WriteJUnit(w, licenses, opts)
+	case "ndjson":
+		return WriteNDJSON(w, licenses)
+	case "spdx":
+		return writeSPDX(w, licenses, opts.Confidence, creationTime(opts.Reproducible))
+	case "zip", "tar":
+		return WriteArchive(w, format, licenses)
+	}
+	return fmt.Errorf("unknown format %q, supported formats: %v", format, Formats)
+}
+
+// readsFiles returns true if format embeds the content of license files.
+func readsFiles(format string) bool {
+	return format == "html" || format == "notice" || format == "zip" || format == "tar"
+}
+
+// withoutAbsolutePaths returns a copy of licenses whose absolute license
+// file, notice file and group paths are cleared. Hashes identify license
+// files instead.
+func withoutAbsolutePaths(licenses []License) []License {
+	relative := make([]License, len(licenses))
+	for i, l := range licenses {
+		for _, p := range []*string{&l.Path, &l.Notice, &l.Group} {
+			if filepath.IsAbs(*p) {
+				*p = ""
+			}
+		}
+		relative[i] = l
+	}
+	return relative
+}
+
+// relativePath returns path relative to the root it is the closest to, with
+// slashes, or path if it is in none of roots.
+func relativePath(path string, roots []string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	relative := path
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if relative == path || len(rel) < len(relative) {
+			relative = rel
+		}
+	}
+	return relative
+}
+
+// RelativePaths returns a copy of licenses whose license file, notice file
+// and group paths are relative to the closest of roots.
+func RelativePaths(licenses []License, roots []string) []License {
+	relative := make([]License, len(licenses))
+	for i, l := range licenses {
+		for _, p := range []*string{&l.Path, &l.Notice, &l.Group} {
+			*p = relativePath(*p, roots)
+		}
+		relative[i] = l
+	}
+	return relative
+}
+
+// ResolvePaths returns a copy of licenses whose relative license file,
+// notice file and group paths are made absolute, joined to the first of roots
+// holding them. Paths found in none of roots are left as is.
+func ResolvePaths(licenses []License, roots []string) []License {
+	resolved := make([]License, len(licenses))
+	for i, l := range licenses {
+		for _, p := range []*string{&l.Path, &l.Notice, &l.Group} {
+			if *p == "" || filepath.IsAbs(*p) {
+				continue
+			}
+			for _, root := range roots {
+				abs := filepath.Join(root, filepath.FromSlash(*p))
+				if _, err := os.Stat(abs); err == nil {
+					*p = abs
+					break
+				}
+			}
+		}
+		resolved[i] = l
+	}
+	return resolved
+}
+
+// creationTime returns the creation time of documents: SOURCE_DATE_EPOCH if
+// set, as with reproducible builds, the Unix epoch if reproducible, or the
+// current time.
+func creationTime(reproducible bool) time.Time {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if n, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			return time.Unix(n, 0).UTC()
+		}
+	}
+	if reproducible {
+		return time.Unix(0, 0).UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Record is the serialized form of a License used by machine readable
+// formats. License and SPDX are set for the best matching template, whatever
+// its score. SPDXReplacement is the replacement of SPDX if it is deprecated.
+type Record struct {
+	Source          string            `json:"source,omitempty"`
+	Package         string            `json:"package"`
+	Version         string            `json:"version,omitempty"`
+	PURL            string            `json:"purl,omitempty"`
+	Sum             string            `json:"sum,omitempty"`
+	Origin          string            `json:"origin,omitempty"`
+	VCS             *VCS              `json:"vcs,omitempty"`
+	Project         string            `json:"project,omitempty"`
+	Group           string            `json:"group,omitempty"`
+	Root            bool              `json:"root,omitempty"`
+	Tool            bool              `json:"tool,omitempty"`
+	Parent          string            `json:"parent,omitempty"`
+	LinkedBy        []string          `json:"linked_by,omitempty"`
+	Deprecated      string            `json:"deprecated,omitempty"`
+	Retracted       string            `json:"retracted,omitempty"`
+	Overridden      bool              `json:"overridden,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	License         string            `json:"license,omitempty"`
+	SPDX            string            `json:"spdx,omitempty"`
+	SPDXReplacement string            `json:"spdx_replacement,omitempty"`
+	OSIApproved     bool              `json:"osi_approved,omitempty"`
+	FSFLibre        bool              `json:"fsf_libre,omitempty"`
+	Contained       []string          `json:"contained,omitempty"`
+	Declared        string            `json:"declared,omitempty"`
+	DeclaredBy      string            `json:"declared_by,omitempty"`
+	Detector        string            `json:"detector,omitempty"`
+	Score           float64           `json:"score"`
+	Path            string            `json:"path,omitempty"`
+	Hash            string            `json:"sha256,omitempty"`
+	URL             string            `json:"url,omitempty"`
+	Notice          string            `json:"notice,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	Requires        []string          `json:"requires,omitempty"`
+	ExtraWords      []string          `json:"extra_words,omitempty"`
+	MissingWords    []string          `json:"missing_words,omitempty"`
+	MissingSections []string          `json:"missing_sections,omitempty"`
+	PatentGrant     bool              `json:"patent_grant,omitempty"`
+	TrademarkClause bool              `json:"trademark_clause,omitempty"`
+}
+
+// NewRecord returns the record describing l.
+func NewRecord(l License) Record {
+	r := Record{
+		Source:          l.Source,
+		Package:         l.Package,
+		Version:         l.Version,
+		PURL:            PURL(l),
+		Sum:             l.Sum,
+		Origin:          l.Origin,
+		VCS:             l.VCS,
+		Project:         l.Project,
+		Group:           l.Group,
+		Root:            l.Root,
+		Tool:            l.Tool,
+		Parent:          l.Parent,
+		LinkedBy:        l.LinkedBy,
+		Deprecated:      l.Deprecated,
+		Retracted:       l.Retracted,
+		Overridden:      l.Overridden,
+		Metadata:        l.Metadata,
+		Declared:        l.Declared,
+		DeclaredBy:      l.DeclaredBy,
+		Detector:        l.Detector,
+		Score:           l.Score,
+		Path:            l.Path,
+		Hash:            l.Hash,
+		URL:             l.URL,
+		Notice:          l.Notice,
+		Error:           l.Err,
+		Requires:        l.Requires,
+		ExtraWords:      l.ExtraWords,
+		MissingWords:    l.MissingWords,
+		MissingSections: l.MissingSections,
+		PatentGrant:     l.PatentGrant,
+		TrademarkClause: l.TrademarkClause,
+	}
+	if l.Template != nil {
+		r.License = l.Template.Title
+		r.SPDX = l.Template.ID
+		r.SPDXReplacement = deprecatedIDs[l.Template.ID]
+		r.OSIApproved = l.Template.OSIApproved
+		r.FSFLibre = l.Template.FSFLibre
+		for _, t := range l.Template.Parts {
+			r.Contained = append(r.Contained, t.Title)
+		}
+	}
+	return r
+}
+
+// ToLicense returns the license described by the record. Its template is
+// looked up by title in templates, a template holding only the title and
+// SPDX identifier is made up if none is found. Templates of concatenated
+// license files are combined from the ones of their contained licenses.
+func (r Record) ToLicense(templates []*matcher.Template) License {
+	l := License{
+		Source:          r.Source,
+		Package:         r.Package,
+		Version:         r.Version,
+		Sum:             r.Sum,
+		Origin:          r.Origin,
+		VCS:             r.VCS,
+		Project:         r.Project,
+		Group:           r.Group,
+		Root:            r.Root,
+		Tool:            r.Tool,
+		Parent:          r.Parent,
+		LinkedBy:        r.LinkedBy,
+		Deprecated:      r.Deprecated,
+		Retracted:       r.Retracted,
+		Overridden:      r.Overridden,
+		Metadata:        r.Metadata,
+		Declared:        r.Declared,
+		DeclaredBy:      r.DeclaredBy,
+		Detector:        r.Detector,
+		Score:           r.Score,
+		Path:            r.Path,
+		Hash:            r.Hash,
+		URL:             r.URL,
+		Notice:          r.Notice,
+		Err:             r.Error,
+		Requires:        r.Requires,
+		ExtraWords:      r.ExtraWords,
+		MissingWords:    r.MissingWords,
+		MissingSections: r.MissingSections,
+		PatentGrant:     r.PatentGrant,
+		TrademarkClause: r.TrademarkClause,
+	}
+	if r.License == "" {
+		return l
+	}
+	if len(r.Contained) > 0 {
+		parts := []*matcher.Template{}
+		for _, title := range r.Contained {
+			t := findTemplate(templates, title)
+			if t == nil {
+				t = &matcher.Template{Title: title}
+			}
+			parts = append(parts, t)
+		}
+		l.Template = matcher.Combine(parts)
+		return l
+	}
+	if l.Template = findTemplate(templates, r.License); l.Template == nil {
+		l.Template = &matcher.Template{Title: r.License, ID: r.SPDX,
+			OSIApproved: r.OSIApproved, FSFLibre: r.FSFLibre}
+	}
+	return l
+}
+
+// findTemplate returns the template of templates titled title, or nil.
+func findTemplate(templates []*matcher.Template, title string) *matcher.Template {
+	for _, t := range templates {
+		if t.Title == title {
+			return t
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes licenses as a JSON array of records.
+func WriteJSON(w io.Writer, licenses []License) error {
+	records := []Record{}
+	for _, l := range licenses {
+		records = append(records, NewRecord(l))
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadJSON reads licenses written by WriteJSON.
+func ReadJSON(r io.Reader, templates []*matcher.Template) ([]License, error) {
+	records := []Record{}
+	err := json.NewDecoder(r).Decode(&records)
+	if err != nil {
+		return nil, err
+	}
+	licenses := []License{}
+	for _, rec := range records {
+		licenses = append(licenses, rec.ToLicense(templates))
+	}
+	return licenses, nil
+}
+
+// WriteCSV writes licenses as CSV with a header line. License columns are
+// left empty for matches scoring below confidence. Metadata fields are
+// written in additional columns named after them.
+func WriteCSV(w io.Writer, licenses []License, confidence float64) error {
+	keys := metadataKeys(licenses)
+	cw := csv.NewWriter(w)
+	err := cw.Write(append([]string{"source", "package", "version", "license", "spdx",
+		"score", "path", "url"}, keys...))
+	if err != nil {
+		return err
+	}
+	for _, l := range licenses {
+		title, id := "", ""
+		if l.Template != nil && l.Score >= confidence {
+			title, id = l.Template.Title, l.Template.ID
+		}
+		row := []string{l.Source, l.Package, l.Version, title, id,
+			strconv.FormatFloat(l.Score, 'f', 2, 64), l.Path, l.URL}
+		for _, key := range keys {
+			row = append(row, l.Metadata[key])
+		}
+		err := cw.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// metadataKeys returns the sorted metadata field names of licenses.
+func metadataKeys(licenses []License) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, l := range licenses {
+		for key := range l.Metadata {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Merge concatenates lists of licenses, dropping entries with the same
+// source, package and version as a previous one, and sorts them with Less,
+// comparing any matched license.
+func Merge(lists ...[]License) []License {
+	type key struct {
+		Source  string
+		Package string
+		Version string
+	}
+	seen := map[key]bool{}
+	merged := []License{}
+	for _, licenses := range lists {
+		for _, l := range licenses {
+			k := key{l.Source, l.Package, l.Version}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			merged = append(merged, l)
+		}
+	}
+	return Sorted(merged, 0)
+}
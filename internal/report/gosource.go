@@ -0,0 +1,54 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+)
+
+// WriteGo writes a Go source file of package pkg declaring a Licenses slice
+// with the package, version, license name and license text of licenses, so
+// programs can display the licenses of their dependencies. Licenses are
+// sorted with Less.
+func WriteGo(w io.Writer, pkg string, licenses []License, confidence float64) error {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, `// Code generated by go-licenses generate-go. DO NOT EDIT.
+
+package %s
+
+// License describes the license of a dependency.
+type License struct {
+	Package string
+	Version string
+	License string
+	SPDX    string
+	Text    string
+}
+
+// Licenses lists the licenses of the dependencies.
+var Licenses = []License{
+`, pkg)
+	for _, l := range Sorted(licenses, confidence) {
+		text, err := readText(l.Path)
+		if err != nil {
+			return err
+		}
+		id := ""
+		if l.Template != nil && l.Score >= confidence {
+			id = l.Template.ID
+		}
+		fmt.Fprintf(b, "{\nPackage: %s,\nVersion: %s,\nLicense: %s,\nSPDX: %s,\nText: %s,\n},\n",
+			strconv.Quote(l.Package), strconv.Quote(l.Version),
+			strconv.Quote(licenseName(l, confidence)), strconv.Quote(id),
+			strconv.Quote(text))
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// GroupByValues lists the supported values of Options.GroupBy.
+var GroupByValues = []string{"license"}
+
+// LicenseGroup lists the packages under a license.
+type LicenseGroup struct {
+	License  string   `json:"license"`
+	SPDX     string   `json:"spdx,omitempty"`
+	Packages []Record `json:"packages"`
+}
+
+// GroupByLicense groups licenses by name, as computed with confidence.
+// Groups are sorted by name, unknown licenses last.
+func GroupByLicense(licenses []License, confidence float64) []LicenseGroup {
+	groups := []LicenseGroup{}
+	index := map[string]int{}
+	for _, l := range licenses {
+		name := licenseName(l, confidence)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			g := LicenseGroup{License: name, Packages: []Record{}}
+			if l.Template != nil && l.Score >= confidence {
+				g.SPDX = l.Template.ID
+			}
+			groups = append(groups, g)
+		}
+		groups[i].Packages = append(groups[i].Packages, NewRecord(l))
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].License, groups[j].License
+		if (a == "?") != (b == "?") {
+			return b == "?"
+		}
+		return a < b
+	})
+	return groups
+}
+
+// WriteGroups writes licenses grouped by license in table or json format.
+// Tables have a section per license, listing its packages and versions.
+func WriteGroups(w io.Writer, format string, licenses []License, opts Options) error {
+	groups := GroupByLicense(licenses, opts.Confidence)
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 1, 4, 2, ' ', 0)
+		sections := []string{}
+		for _, g := range groups {
+			lines := []string{fmt.Sprintf("%s (%d)", g.License, len(g.Packages))}
+			for _, r := range g.Packages {
+				lines = append(lines, "  "+r.Package+"\t"+r.Version)
+			}
+			sections = append(sections, strings.Join(lines, "\n")+"\n")
+		}
+		_, err := io.WriteString(tw, strings.Join(sections, "\n"))
+		if err != nil {
+			return err
+		}
+		return tw.Flush()
+	case "json":
+		data, err := json.MarshalIndent(groups, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+	return fmt.Errorf("grouping by license supports table and json formats only")
+}
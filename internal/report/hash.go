@@ -0,0 +1,36 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+)
+
+// HashFile returns the hex-encoded SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// withHashes returns a copy of licenses with the hashes of their license
+// files set, when missing. Files which no longer exist, like those of reports
+// read from other machines, are skipped.
+func withHashes(licenses []License) ([]License, error) {
+	hashed := make([]License, len(licenses))
+	for i, l := range licenses {
+		if l.Hash == "" && l.Path != "" {
+			hash, err := HashFile(l.Path)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			l.Hash = hash
+		}
+		hashed[i] = l
+	}
+	return hashed, nil
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+// LicenseChanged returns true if the licenses of old and new, as named with
+// confidence, differ.
+func LicenseChanged(old, new License, confidence float64) bool {
+	return licenseName(old, confidence) != licenseName(new, confidence)
+}
+
+// fileName returns the base name of the file at path, or "none".
+func fileName(path string) string {
+	if path == "" {
+		return "none"
+	}
+	return filepath.Base(path)
+}
+
+// WriteHistory compares the licenses of two versions of a module, old and
+// new, whose license files hold oldText and newText: the detected licenses,
+// the license files and, if their content changed, a side-by-side diff of
+// their normalized words. Unless full is set, unchanged parts of the diff are
+// elided.
+func WriteHistory(w io.Writer, old, new License, oldText, newText []byte,
+	confidence float64, full bool) error {
+
+	license := licenseName(old, confidence)
+	if LicenseChanged(old, new, confidence) {
+		license += " -> " + licenseName(new, confidence) + ", changed"
+	} else {
+		license += ", unchanged"
+	}
+	file := fileName(old.Path)
+	if fileName(new.Path) != file {
+		file += " -> " + fileName(new.Path)
+	}
+	changed := !bytes.Equal(oldText, newText)
+	if changed {
+		file += ", content changed"
+	} else {
+		file += ", content unchanged"
+	}
+	lines := []string{
+		"Module:    " + old.Package,
+		"Versions:  " + old.Version + " -> " + new.Version,
+		"License:   " + license,
+		"File:      " + file,
+	}
+	if changed {
+		lines = append(lines, "")
+		lines = append(lines, sideBySide([]string{old.Version}, []string{new.Version}, " ")...)
+		lines = append(lines, diffLines(matcher.Diff(matcher.Words(oldText),
+			matcher.Words(newText)), full)...)
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
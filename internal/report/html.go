@@ -0,0 +1,103 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"io/ioutil"
+
+	"github.com/groove-x/go-licenses/internal/normalize"
+)
+
+// htmlEntry is a dependency displayed in the attribution page.
+type htmlEntry struct {
+	Package string
+	Version string
+	License string
+	URL     string
+	// Note explains entries listed several times, like major versions.
+	Note     string
+	Metadata map[string]string
+	Text     string
+	Notice   string
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Open Source Licenses</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 50em; padding: 0 1em; color: #222; }
+h1 { font-size: 1.5em; }
+details { border-bottom: 1px solid #ddd; padding: 0.5em 0; }
+summary { cursor: pointer; }
+.version { color: #777; }
+.license { float: right; color: #555; }
+.note { color: #555; font-style: italic; }
+.metadata dt { float: left; clear: left; font-weight: bold; margin-right: 0.5em; }
+.metadata dd { margin: 0; }
+pre { white-space: pre-wrap; font-size: 0.85em; background: #f6f6f6; padding: 1em; }
+</style>
+</head>
+<body>
+<h1>Open Source Licenses</h1>
+<p>This software includes the following third-party components.</p>
+{{range .}}<details>
+<summary><span class="package">{{.Package}}</span> <span class="version">{{.Version}}</span> <span class="license">{{if .URL}}<a href="{{.URL}}">{{.License}}</a>{{else}}{{.License}}{{end}}</span></summary>
+{{if .Note}}<p class="note">{{.Note}}</p>
+{{end}}{{if .Metadata}}<dl class="metadata">{{range $k, $v := .Metadata}}<dt>{{$k}}</dt><dd>{{$v}}</dd>{{end}}</dl>
+{{end}}{{if .Text}}<pre>{{.Text}}</pre>
+{{end}}{{if .Notice}}<pre>{{.Notice}}</pre>
+{{end}}</details>
+{{end}}</body>
+</html>
+`))
+
+func readText(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(normalize.Decode(data)), nil
+}
+
+// majorVersionNote returns the note explaining that l is one of several
+// major versions of a Go module, or an empty string.
+func majorVersionNote(l License) string {
+	if l.Project == "" {
+		return ""
+	}
+	return "Major version " + MajorVersion(l) + " of " + l.Project +
+		", distributed as a separate module listed with the other major versions."
+}
+
+// WriteHTML writes a standalone attribution page listing licenses, with the
+// full texts of their license and notice files in collapsible sections.
+func WriteHTML(w io.Writer, licenses []License, confidence float64) error {
+	entries := []htmlEntry{}
+	for _, l := range licenses {
+		text, err := readText(l.Path)
+		if err != nil {
+			return err
+		}
+		notice, err := readText(l.Notice)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, htmlEntry{
+			Package:  l.Package,
+			Version:  l.Version,
+			License:  licenseName(l, confidence),
+			URL:      l.URL,
+			Note:     majorVersionNote(l),
+			Metadata: l.Metadata,
+			Text:     text,
+			Notice:   notice,
+		})
+	}
+	return htmlTemplate.Execute(w, entries)
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// WriteJUnit writes licenses as a JUnit XML test suite, one test case per
+// package, failing for policy violations and unknown licenses.
+func WriteJUnit(w io.Writer, licenses []License, opts Options) error {
+	suite := junitTestSuite{
+		Name:      "licenses",
+		TestCases: []junitTestCase{},
+	}
+	for _, l := range licenses {
+		name := l.Package
+		if l.Version != "" {
+			name += "@" + l.Version
+		}
+		classname := "licenses"
+		if l.Source != "" {
+			classname += "." + l.Source
+		}
+		tc := junitTestCase{
+			Name:      name,
+			ClassName: classname,
+		}
+		reason := evaluate(l, opts)
+		if reason != "" {
+			tc.Failure = &junitFailure{
+				Message: reason,
+				Text:    name + ": " + licenseName(l, opts.Confidence),
+			}
+			if l.Path != "" {
+				tc.Failure.Text += " (" + l.Path + ")"
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+string(data)+"\n")
+	return err
+}
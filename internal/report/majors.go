@@ -0,0 +1,99 @@
+package report
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// reMajorSuffix matches the major version suffix of Go module paths,
+	// like "/v2", or ".v2" for gopkg.in modules.
+	reMajorSuffix = regexp.MustCompile(`/v([2-9]|[1-9][0-9]+)$`)
+	reGopkgSuffix = regexp.MustCompile(`^(gopkg\.in/.+)\.v([0-9]+)$`)
+)
+
+// ProjectPath returns the Go module path without its major version suffix,
+// shared by all major versions of the module.
+func ProjectPath(path string) string {
+	if m := reGopkgSuffix.FindStringSubmatch(path); m != nil {
+		return m[1]
+	}
+	return reMajorSuffix.ReplaceAllString(path, "")
+}
+
+// MajorVersion returns the major version of the Go module of l, like "v2".
+func MajorVersion(l License) string {
+	if m := reGopkgSuffix.FindStringSubmatch(l.Package); m != nil {
+		return "v" + m[2]
+	}
+	if m := reMajorSuffix.FindStringSubmatch(l.Package); m != nil {
+		return "v" + m[1]
+	}
+	if strings.HasPrefix(l.Version, "v0.") {
+		return "v0"
+	}
+	return "v1"
+}
+
+// withProjects returns a copy of licenses whose Project is set for the Go
+// modules reported in several major versions, and cleared for others.
+func withProjects(licenses []License) []License {
+	paths := map[string]map[string]bool{}
+	for _, l := range licenses {
+		if source(l) != "go" {
+			continue
+		}
+		p := ProjectPath(l.Package)
+		if paths[p] == nil {
+			paths[p] = map[string]bool{}
+		}
+		paths[p][l.Package] = true
+	}
+	annotated := make([]License, len(licenses))
+	for i, l := range licenses {
+		l.Project = ""
+		if p := ProjectPath(l.Package); source(l) == "go" && len(paths[p]) > 1 {
+			l.Project = p
+		}
+		annotated[i] = l
+	}
+	return annotated
+}
+
+// MajorVersionWarnings returns warnings about the Go modules reported in
+// several major versions whose licenses, as named with confidence, differ.
+// Major versions are distinct modules, usually but not always under the same
+// license.
+func MajorVersionWarnings(licenses []License, confidence float64) []string {
+	names := map[string]map[string][]string{}
+	for _, l := range withProjects(licenses) {
+		if l.Project == "" {
+			continue
+		}
+		if names[l.Project] == nil {
+			names[l.Project] = map[string][]string{}
+		}
+		name := licenseName(l, confidence)
+		major := MajorVersion(l)
+		if !hasString(names[l.Project][name], major) {
+			names[l.Project][name] = append(names[l.Project][name], major)
+		}
+	}
+	warnings := []string{}
+	for project, byName := range names {
+		if len(byName) < 2 {
+			continue
+		}
+		licensed := []string{}
+		for name, majors := range byName {
+			sort.Strings(majors)
+			licensed = append(licensed, strings.Join(majors, ", ")+": "+name)
+		}
+		sort.Strings(licensed)
+		warnings = append(warnings, project+" major versions have different licenses: "+
+			strings.Join(licensed, "; "))
+	}
+	sort.Strings(warnings)
+	return warnings
+}
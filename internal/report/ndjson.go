@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Observer is notified while licenses are detected, so long scans can be
+// reported incrementally.
+type Observer interface {
+	// Scanning is called before detecting the license of a package, done
+	// being the number of packages already scanned out of total.
+	Scanning(done, total int, pkg string)
+	// Scanned is called with each detected license.
+	Scanned(l License)
+}
+
+// Event is a line of NDJSON output. License events embed the license record.
+type Event struct {
+	// Event is "progress", "license", "error" or "done".
+	Event string `json:"event"`
+	// Done and Total count the packages scanned by progress events, and
+	// the licenses written by the done event.
+	Done   int    `json:"done,omitempty"`
+	Total  int    `json:"total,omitempty"`
+	Module string `json:"module,omitempty"`
+	// Message describes error events.
+	Message string `json:"message,omitempty"`
+	*Record
+}
+
+// NDJSONWriter writes events as newline delimited JSON, one object per line,
+// as soon as they happen. It implements Observer.
+type NDJSONWriter struct {
+	enc   *json.Encoder
+	count int
+	err   error
+}
+
+// NewNDJSONWriter returns a writer of NDJSON events to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *NDJSONWriter) write(e Event) {
+	if n.err == nil {
+		n.err = n.enc.Encode(e)
+	}
+}
+
+// Scanning writes a progress event.
+func (n *NDJSONWriter) Scanning(done, total int, pkg string) {
+	n.write(Event{Event: "progress", Done: done, Total: total, Module: pkg})
+}
+
+// Scanned writes a license event.
+func (n *NDJSONWriter) Scanned(l License) {
+	if l.Hash == "" && l.Path != "" {
+		l.Hash, _ = HashFile(l.Path)
+	}
+	r := NewRecord(l)
+	n.count++
+	n.write(Event{Event: "license", Record: &r})
+}
+
+// Error writes an error event.
+func (n *NDJSONWriter) Error(err error) {
+	n.write(Event{Event: "error", Message: err.Error()})
+}
+
+// Close writes the done event and returns the first write error.
+func (n *NDJSONWriter) Close() error {
+	n.write(Event{Event: "done", Total: n.count})
+	return n.err
+}
+
+// WriteNDJSON writes licenses as NDJSON license events followed by a done
+// event.
+func WriteNDJSON(w io.Writer, licenses []License) error {
+	n := NewNDJSONWriter(w)
+	for _, l := range licenses {
+		n.Scanned(l)
+	}
+	return n.Close()
+}
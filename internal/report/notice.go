@@ -0,0 +1,111 @@
+package report
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/normalize"
+)
+
+const (
+	noticeRule  = "--------------------------------------------------------------------------------"
+	noticeTitle = "================================================================================"
+)
+
+// noticeEntry is a package listed in a NOTICE file, all its versions
+// together.
+type noticeEntry struct {
+	Package    string
+	Versions   []string
+	Licenses   []string
+	Copyrights []string
+	Notices    []string
+}
+
+// noticeText is a license text written once in a NOTICE file for all the
+// packages using it.
+type noticeText struct {
+	License  string
+	Text     string
+	Packages []string
+}
+
+func appendDistinct(values []string, value string) []string {
+	if value == "" || hasString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// WriteNotice writes licenses as a plain text NOTICE file: an entry per
+// package listing its versions, licenses, the copyright statements of the
+// license and NOTICE files of all its versions merged by holder, and the
+// content of its NOTICE files, followed by the license texts, written once
+// for all the packages using them since copyright statements are stripped.
+func WriteNotice(w io.Writer, licenses []License, confidence float64) error {
+	entries := []*noticeEntry{}
+	byPackage := map[string]*noticeEntry{}
+	texts := []*noticeText{}
+	byText := map[string]*noticeText{}
+	for _, l := range licenses {
+		key := l.Source + "|" + l.Package
+		e, ok := byPackage[key]
+		if !ok {
+			e = &noticeEntry{Package: l.Package}
+			byPackage[key] = e
+			entries = append(entries, e)
+		}
+		e.Versions = appendDistinct(e.Versions, l.Version)
+		e.Licenses = appendDistinct(e.Licenses, licenseName(l, confidence))
+		text, err := readText(l.Path)
+		if err != nil {
+			return err
+		}
+		notice, err := readText(l.Notice)
+		if err != nil {
+			return err
+		}
+		for _, s := range append(normalize.Copyrights([]byte(text)),
+			normalize.Copyrights([]byte(notice))...) {
+			e.Copyrights = appendDistinct(e.Copyrights, s)
+		}
+		e.Notices = appendDistinct(e.Notices, strings.TrimSpace(notice))
+		if text == "" {
+			continue
+		}
+		stripped := string(normalize.StripCopyrights([]byte(text)))
+		tkey := strings.Join(strings.Fields(strings.ToLower(stripped)), " ")
+		t, ok := byText[tkey]
+		if !ok {
+			t = &noticeText{License: licenseName(l, confidence), Text: stripped}
+			byText[tkey] = t
+			texts = append(texts, t)
+		}
+		t.Packages = appendDistinct(t.Packages, l.Package)
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("THIRD-PARTY SOFTWARE NOTICES\n\n" +
+		"This software includes the following third-party components.\n")
+	for _, e := range entries {
+		bw.WriteString("\n" + noticeRule + "\n")
+		bw.WriteString(strings.TrimSpace(e.Package+" "+strings.Join(e.Versions, ", ")) + "\n")
+		bw.WriteString("License: " + strings.Join(e.Licenses, ", ") + "\n")
+		for _, s := range normalize.MergeCopyrights(e.Copyrights) {
+			bw.WriteString(s + "\n")
+		}
+		for _, notice := range e.Notices {
+			bw.WriteString("\n" + notice + "\n")
+		}
+	}
+	if len(texts) > 0 {
+		bw.WriteString("\n" + noticeTitle + "\nLICENSE TEXTS\n")
+	}
+	for _, t := range texts {
+		bw.WriteString("\n" + noticeRule + "\n")
+		bw.WriteString(t.License + "\n")
+		bw.WriteString("Used by: " + strings.Join(t.Packages, ", ") + "\n\n")
+		bw.WriteString(strings.TrimRight(t.Text, "\n") + "\n")
+	}
+	return bw.Flush()
+}
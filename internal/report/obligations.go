@@ -0,0 +1,114 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Obligation aggregates a license condition over a whole project.
+type Obligation struct {
+	// Condition is the license condition, like "include-copyright".
+	Condition string `json:"condition"`
+	// Task describes what the condition requires.
+	Task string `json:"task"`
+	// Licenses lists the titles of the licenses imposing the condition.
+	Licenses []string `json:"licenses"`
+	// Packages counts the packages under these licenses.
+	Packages int `json:"packages"`
+}
+
+// patentGrant is the permission of licenses granting patent rights, which
+// is reported along with obligations.
+const patentGrant = "patent-grant"
+
+// Obligations returns the license obligations implied by licenses scoring
+// at least confidence, in checklist order, followed by patent grants. Only
+// conditions applying to at least one package are returned.
+func Obligations(licenses []License, confidence float64) []Obligation {
+	conditions := []Obligation{}
+	for _, o := range obligations {
+		conditions = append(conditions, Obligation{Condition: o.Condition, Task: o.Task})
+	}
+	conditions = append(conditions, Obligation{
+		Condition: patentGrant,
+		Task:      "Patent rights are granted by contributors",
+	})
+	result := []Obligation{}
+	for _, c := range conditions {
+		for _, l := range licenses {
+			if l.Template == nil || l.Score < confidence {
+				continue
+			}
+			conds := l.Template.Required
+			if c.Condition == patentGrant {
+				conds = l.Template.Permitted
+			}
+			if !hasString(conds, c.Condition) {
+				continue
+			}
+			c.Packages++
+			if !hasString(c.Licenses, l.Template.Title) {
+				c.Licenses = append(c.Licenses, l.Template.Title)
+			}
+		}
+		if c.Packages > 0 {
+			sort.Strings(c.Licenses)
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func countPackages(n int) string {
+	if n == 1 {
+		return "1 package"
+	}
+	return fmt.Sprintf("%d packages", n)
+}
+
+// WriteObligations writes the obligations implied by licenses as a table, or
+// as JSON if format is "json". Licenses scoring below confidence are counted
+// as requiring review.
+func WriteObligations(w io.Writer, format string, licenses []License,
+	confidence float64) error {
+
+	result := Obligations(licenses, confidence)
+	unknown := 0
+	for _, l := range licenses {
+		if l.Template == nil || l.Score < confidence {
+			unknown++
+		}
+	}
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "table":
+	default:
+		return fmt.Errorf("obligations cannot be written in %q format", format)
+	}
+	tw := tabwriter.NewWriter(w, 1, 4, 2, ' ', 0)
+	for _, o := range result {
+		_, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", o.Task, countPackages(o.Packages),
+			strings.Join(o.Licenses, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	if unknown > 0 {
+		_, err := fmt.Fprintf(tw, "Review unidentified licenses\t%s\t\n",
+			countPackages(unknown))
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
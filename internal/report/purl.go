@@ -0,0 +1,42 @@
+package report
+
+import (
+	"net/url"
+	"strings"
+)
+
+// purlTypes maps license sources to package URL types and namespaces.
+var purlTypes = map[string]string{
+	"":    "golang",
+	"go":  "golang",
+	"deb": "deb/debian",
+	"apk": "apk/alpine",
+}
+
+// escapePURL percent-encodes the segments of a package URL name or version.
+func escapePURL(s string) string {
+	segments := strings.Split(s, "/")
+	for i, seg := range segments {
+		segments[i] = strings.Replace(url.PathEscape(seg), "+", "%2B", -1)
+	}
+	return strings.Join(segments, "/")
+}
+
+// PURL returns the package URL identifying the package of l, like
+// "pkg:golang/github.com/pkg/errors@v0.9.1", or an empty string for sources
+// without package URL type.
+func PURL(l License) string {
+	typ, ok := purlTypes[l.Source]
+	if !ok || l.Package == "" {
+		return ""
+	}
+	name := l.Package
+	if typ == "golang" {
+		name = strings.ToLower(name)
+	}
+	purl := "pkg:" + typ + "/" + escapePURL(name)
+	if l.Version != "" {
+		purl += "@" + escapePURL(l.Version)
+	}
+	return purl
+}
@@ -0,0 +1,210 @@
+// Package report formats detected licenses.
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+// DefaultConfidence is the minimum score for a license match to be trusted.
+const DefaultConfidence = 0.9
+
+// License describes the license detected for a package or module.
+type License struct {
+	// Source names the scanner which reported the license, like "go" or
+	// "deb", or "native" for the system libraries linked by cgo packages.
+	Source  string
+	Package string
+	Version string
+	// Sum is the hash of the module content, as in go.sum, for Go modules.
+	Sum      string
+	Score    float64
+	Template *matcher.Template
+	Path     string
+	// Hash is the hex-encoded SHA-256 of the license file. It is set when
+	// licenses are written, if missing.
+	Hash string
+	// URL links to the license file upstream, if known.
+	URL          string
+	Notice       string
+	Err          string
+	ExtraWords   []string
+	MissingWords []string
+	// MissingSections lists the titles of the optional template sections
+	// missing from the license file, like the appendix of the Apache license.
+	MissingSections []string
+	// PatentGrant is set for license files granting patent licenses
+	// explicitly, and TrademarkClause for those withholding trademark rights,
+	// see matcher.FindClauses.
+	PatentGrant     bool
+	TrademarkClause bool
+	// Declared is the license expression declared by package metadata. It is
+	// displayed when no license is detected with enough confidence.
+	Declared string
+	// DeclaredBy names the remote service Declared was read from, if any.
+	DeclaredBy string
+	// Detector names the detector which found the license, when it is not
+	// the built-in word matcher.
+	Detector string
+	// Origin names the source package the package was built from, when it
+	// differs from the package name.
+	Origin string
+	// VCS is the upstream revision of Go modules, when known.
+	VCS *VCS
+	// Project is the module path without major version suffix of Go modules
+	// reported in several major versions, see ProjectPath. It is set when
+	// licenses are written.
+	Project string
+	// Group identifies the packages sharing a license file.
+	Group string
+	// Requires lists the paths of the reported Go modules required by the
+	// module, when the module graph was loaded.
+	Requires []string
+	// Root is set for the scanned module itself, as opposed to its
+	// dependencies.
+	Root bool
+	// Tool is set for modules only needed at build time by go.mod tool
+	// directives.
+	Tool bool
+	// Parent is the path of the Go module holding the files, for the
+	// licenses of sub-components like the assets embedded with go:embed
+	// directives. Package is then the directory of their license file.
+	Parent string
+	// LinkedBy lists the paths of the Go modules linking native libraries,
+	// "std" for the standard library.
+	LinkedBy []string
+	// Deprecated and Retracted hold the deprecation message of the module
+	// and the retraction rationale of its version, if any.
+	Deprecated string
+	Retracted  string
+	// Overridden is set for licenses set by the configuration file instead of
+	// being detected.
+	Overridden bool
+	// Metadata holds the free-form fields attached to the package by the
+	// configuration file, like its owner team or approval status.
+	Metadata map[string]string
+}
+
+// VCS identifies the version control revision a Go module version was made
+// from, as recorded by the go command in module origins.
+type VCS struct {
+	// Type is the version control system, like "git".
+	Type string `json:"type"`
+	// URL is the repository URL.
+	URL string `json:"url"`
+	// Subdir is the module directory in the repository, if not the root.
+	Subdir string `json:"subdir,omitempty"`
+	// Ref is the tag or branch name of the version, if any.
+	Ref string `json:"ref,omitempty"`
+	// Hash is the commit hash.
+	Hash string `json:"hash,omitempty"`
+}
+
+// DownloadLocation returns the location of the revision in SPDX VCS
+// location format, like "git+https://github.com/a/b@<hash>#sub".
+func (v *VCS) DownloadLocation() string {
+	s := v.Type + "+" + v.URL
+	if v.Hash != "" {
+		s += "@" + v.Hash
+	} else if v.Ref != "" {
+		s += "@" + v.Ref
+	}
+	if v.Subdir != "" {
+		s += "#" + v.Subdir
+	}
+	return s
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteTable writes licenses as a table, one package per line. Matches scoring
+// below confidence are reported as unknown. If words is set, the words
+// differing from the matched template are listed below each entry. If
+// versions is set, a column lists package versions and package names are
+// followed by their origin, if any. The root component is marked with
+// "(root)", modules only needed by tools with "(tool)", system libraries
+// linked by cgo packages with "(native)", licenses set by
+// configuration overrides with "(overridden)", modules reported in several
+// major versions with their major version, like "(major v2)". Deprecation and
+// retraction notices are listed below entries. If color is set, licenses are
+// colored by severity.
+func WriteTable(w io.Writer, licenses []License, opts Options) error {
+	confidence, words := opts.Confidence, opts.Words
+	indent := "\t"
+	if opts.Versions {
+		indent = "\t\t"
+	}
+	tw := tabwriter.NewWriter(w, 1, 4, 2, ' ', 0)
+	for _, l := range licenses {
+		license := "?"
+		if l.Template != nil && (l.Score >= confidence || l.Declared == "") {
+			if l.Score > .99 {
+				license = fmt.Sprintf("%s", l.Template.Title)
+			} else if l.Score >= confidence {
+				license = fmt.Sprintf("%s (%2d%%)", l.Template.Title, int(100*l.Score))
+				if words && len(l.ExtraWords) > 0 {
+					license += "\n" + indent + "+words: " + strings.Join(l.ExtraWords, ", ")
+				}
+				if words && len(l.MissingWords) > 0 {
+					license += "\n" + indent + "-words: " + strings.Join(l.MissingWords, ", ")
+				}
+				if words && len(l.MissingSections) > 0 {
+					license += "\n" + indent + "-sections: " + strings.Join(l.MissingSections, ", ")
+				}
+			} else {
+				license = fmt.Sprintf("? (%s, %2d%%)", l.Template.Title, int(100*l.Score))
+			}
+		} else if l.Declared != "" && l.DeclaredBy != "" {
+			license = l.Declared + " (remote-declared by " + l.DeclaredBy + ")"
+		} else if l.Declared != "" {
+			license = l.Declared + " (declared)"
+		} else if l.Err != "" {
+			license = strings.Replace(l.Err, "\n", " ", -1)
+		}
+		if l.Overridden {
+			license = licenseName(l, confidence) + " (overridden)"
+		}
+		if l.Deprecated != "" {
+			license += "\n" + indent + "deprecated: " + oneLine(l.Deprecated)
+		}
+		if l.Retracted != "" {
+			license += "\n" + indent + "retracted: " + oneLine(l.Retracted)
+		}
+		name := l.Package
+		if l.Root {
+			name += " (root)"
+		}
+		if l.Tool {
+			name += " (tool)"
+		}
+		if l.Source == "native" {
+			name += " (native)"
+		}
+		if l.Project != "" {
+			name += " (major " + MajorVersion(l) + ")"
+		}
+		if opts.Versions {
+			if l.Origin != "" {
+				name += " (" + l.Origin + ")"
+			}
+			name += "\t" + l.Version
+		}
+		if l.Group != "" {
+			license += "\t[" + l.Group + "]"
+		}
+		if opts.Color {
+			license = colorize(license, severityColor(l, opts))
+		}
+		_, err := tw.Write([]byte(name + "\t" + license + "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
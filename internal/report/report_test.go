@@ -0,0 +1,931 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+func TestChecklist(t *testing.T) {
+	apache := &matcher.Template{Title: "Apache License 2.0",
+		Required: []string{"include-copyright", "document-changes"}}
+	licenses := []License{
+		{Package: "a", Template: apache, Score: 1, Notice: "a/NOTICE"},
+		{Package: "b", Template: apache, Score: 0.5, Path: "b/LICENSE"},
+	}
+	b := &bytes.Buffer{}
+	err := WriteChecklist(b, "v1", licenses, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, wanted := range []string{
+		"# License obligations for v1\n",
+		"## Document changes made to the licensed code\n\nRequired by: Apache License 2.0\n\n- [ ] a (Apache License 2.0)\n",
+		"## Provide the source code or a written source offer\n\nNot applicable.\n",
+		"- [ ] a: a/NOTICE\n",
+		"## Review unidentified licenses\n\n- [ ] b: b/LICENSE\n",
+	} {
+		if !strings.Contains(b.String(), wanted) {
+			t.Fatalf("checklist does not contain %q:\n%s", wanted, b.String())
+		}
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License"}
+	licenses := []License{
+		{Package: "a", Template: mit, Score: 1},
+		{Package: "bb", Template: mit, Score: 0.95, MissingWords: []string{"mit"}},
+		{Package: "c", Template: mit, Score: 0.5},
+		{Package: "d", Err: "some\nerror"},
+		{Package: "e", Template: mit, Score: 1, Deprecated: "use\nf",
+			Retracted: "broken"},
+	}
+	b := &bytes.Buffer{}
+	err := WriteTable(b, licenses, Options{Confidence: 0.9, Words: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `a   MIT License
+bb  MIT License (95%)
+    -words: mit
+c   ? (MIT License, 50%)
+d   some error
+e   MIT License
+    deprecated: use f
+    retracted: broken
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected table:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestWriteTableColor(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License"}
+	licenses := []License{
+		{Package: "a", Template: mit, Score: 1},
+		{Package: "b", Template: mit, Score: 0.95},
+		{Package: "c", Template: mit, Score: 0.5},
+		{Package: "d", Template: mit, Score: 1},
+		{Package: "e"},
+	}
+	b := &bytes.Buffer{}
+	err := WriteTable(b, licenses, Options{Confidence: 0.9, Color: true,
+		Check: func(l License) string {
+			if l.Package == "d" {
+				return "MIT License is denied"
+			}
+			return ""
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := "a  \x1b[32mMIT License\x1b[0m\n" +
+		"b  \x1b[39mMIT License (95%)\x1b[0m\n" +
+		"c  \x1b[33m? (MIT License, 50%)\x1b[0m\n" +
+		"d  \x1b[31mMIT License\x1b[0m\n" +
+		"e  \x1b[31m?\x1b[0m\n"
+	if b.String() != wanted {
+		t.Fatalf("unexpected table:\n%q\n!=\n%q", b.String(), wanted)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	gpl := &matcher.Template{Title: "GNU General Public License v2.0", ID: "GPL-2.0"}
+	licenses := []License{
+		{Package: "a", Template: mit, Score: 1},
+		{Package: "b", Template: gpl, Score: 0.95},
+		{Package: "c", Template: gpl, Score: 0.5},
+		{Package: "d", Declared: "LGPL-2.1-only OR MIT"},
+		{Package: "e", Template: matcher.Combine([]*matcher.Template{mit, gpl}), Score: 0.95},
+	}
+	tests := []struct {
+		filter Filter
+		wanted string
+	}{
+		{Filter{}, "a b c d e"},
+		{Filter{Only: []string{"unknown"}}, "c d"},
+		{Filter{Only: []string{"gpl-*"}}, "b e"},
+		{Filter{Only: []string{"*GPL-*"}}, "b d e"},
+		{Filter{Exclude: []string{"MIT License", "unknown"}}, "b"},
+		{Filter{MinScore: 0.9}, "a b e"},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, l := range test.filter.Apply(licenses, 0.9) {
+			got = append(got, l.Package)
+		}
+		if strings.Join(got, " ") != test.wanted {
+			t.Errorf("unexpected licenses with %+v: %s != %s", test.filter,
+				strings.Join(got, " "), test.wanted)
+		}
+	}
+	err := (&Filter{Only: []string{"["}}).Validate()
+	if err == nil {
+		t.Fatal("malformed pattern accepted")
+	}
+}
+
+func TestRecordContained(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	isc := &matcher.Template{Title: "ISC License", ID: "ISC"}
+	l := License{Package: "a", Template: matcher.Combine([]*matcher.Template{mit, isc}), Score: 1}
+	r := NewRecord(l)
+	if r.License != "MIT License AND ISC License" || r.SPDX != "MIT AND ISC" ||
+		strings.Join(r.Contained, ", ") != "MIT License, ISC License" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	got := r.ToLicense([]*matcher.Template{mit})
+	if got.Template.Title != r.License || len(got.Template.Parts) != 2 ||
+		got.Template.Parts[0] != mit || got.Template.Parts[1].ID != "" {
+		t.Fatalf("unexpected license: %+v", got.Template)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	gpl := &matcher.Template{Title: "GNU General Public License v2.0", ID: "GPL-2.0"}
+	tests := []struct {
+		license License
+		wanted  []string
+	}{
+		{License{Template: gpl, Score: 1}, []string{
+			"GPL-2.0 is a deprecated SPDX identifier, use GPL-2.0-only or GPL-2.0-or-later"}},
+		{License{Template: gpl, Score: 0.5}, []string{}},
+		{License{Declared: "(GPL-2.0+ OR MIT) AND LGPL-2.1-only"}, []string{
+			"GPL-2.0+ is a deprecated SPDX identifier, use GPL-2.0-or-later"}},
+		{License{Declared: "MIT"}, []string{}},
+	}
+	for _, test := range tests {
+		got := Warnings(test.license, 0.9)
+		if fmt.Sprint(got) != fmt.Sprint(test.wanted) {
+			t.Errorf("unexpected warnings for %+v: %q != %q", test.license, got,
+				test.wanted)
+		}
+	}
+}
+
+func TestWriteTableVersions(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License"}
+	licenses := []License{
+		{Package: "libfoo1", Version: "1.2-3", Origin: "foo", Template: mit, Score: 1},
+		{Package: "bar", Version: "0.1", Declared: "GPL-2.0-or-later"},
+	}
+	b := &bytes.Buffer{}
+	err := WriteTable(b, licenses, Options{Confidence: 0.9, Versions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `libfoo1 (foo)  1.2-3  MIT License
+bar            0.1    GPL-2.0-or-later (declared)
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected table:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestMajorVersions(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License"}
+	apache := &matcher.Template{Title: "Apache License 2.0"}
+	licenses := []License{
+		{Source: "go", Package: "example.com/m/v2", Version: "v2.1.0", Template: apache,
+			Score: 1},
+		{Source: "go", Package: "example.com/m/sub", Version: "v1.0.0", Template: mit,
+			Score: 1},
+		{Source: "go", Package: "example.com/m", Version: "v1.2.0", Template: mit, Score: 1},
+		{Source: "go", Package: "gopkg.in/yaml.v3", Version: "v3.0.1", Template: mit,
+			Score: 1},
+		{Source: "go", Package: "gopkg.in/yaml.v2", Version: "v2.4.0", Template: mit,
+			Score: 1},
+	}
+	b := &bytes.Buffer{}
+	err := Write(b, "table", licenses, Options{Confidence: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `example.com/m (major v1)     MIT License
+example.com/m/v2 (major v2)  Apache License 2.0
+example.com/m/sub            MIT License
+gopkg.in/yaml.v2 (major v2)  MIT License
+gopkg.in/yaml.v3 (major v3)  MIT License
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected table:\n%s\n!=\n%s", b.String(), wanted)
+	}
+	got := strings.Join(MajorVersionWarnings(licenses, 0.9), "\n")
+	if got != "example.com/m major versions have different licenses: "+
+		"v1: MIT License; v2: Apache License 2.0" {
+		t.Fatalf("unexpected warnings: %s", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	merged := Merge([]License{
+		{Source: "go", Package: "b", Version: "v1"},
+		{Source: "go", Package: "a", Version: "v1"},
+	}, []License{
+		{Source: "deb", Package: "z", Version: "1.0"},
+		{Source: "go", Package: "a", Version: "v1"},
+		{Source: "go", Package: "a", Version: "v2"},
+	})
+	got := []string{}
+	for _, l := range merged {
+		got = append(got, l.Source+":"+l.Package+"@"+l.Version)
+	}
+	wanted := "deb:z@1.0 go:a@v1 go:a@v2 go:b@v1"
+	if strings.Join(got, " ") != wanted {
+		t.Fatalf("unexpected merge: %s != %s", strings.Join(got, " "), wanted)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License"}
+	apache := &matcher.Template{Title: "Apache License 2.0"}
+	old := []License{
+		{Source: "go", Package: "a", Version: "v1", Template: mit, Score: 1},
+		{Source: "go", Package: "b", Version: "v1", Template: mit, Score: 1},
+		{Source: "go", Package: "c", Version: "v1", Template: mit, Score: 1},
+	}
+	after := []License{
+		{Source: "go", Package: "a", Version: "v2", Template: mit, Score: 0.95},
+		{Source: "go", Package: "c", Version: "v2", Declared: "BUSL-1.1"},
+		{Source: "go", Package: "d", Version: "v1", Template: apache, Score: 1},
+	}
+	d := Compare(old, after, 0.9)
+	b := &bytes.Buffer{}
+	err := WriteDiff(b, d, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `+  go  d  v1        Apache License 2.0
+-  go  b  v1        MIT License
+~  go  c  v1 -> v2  MIT License -> BUSL-1.1
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected diff:\n%s\n!=\n%s", b.String(), wanted)
+	}
+	if Compare(old, old, 0.9).Empty() != true {
+		t.Fatalf("report differs from itself")
+	}
+}
+
+func TestWriteCSVMetadata(t *testing.T) {
+	licenses := []License{
+		{Source: "go", Package: "a", Version: "v1", Score: 1,
+			Template: &matcher.Template{Title: "MIT License", ID: "MIT"},
+			Metadata: map[string]string{"owner": "team-a", "ticket": "LEGAL-1"}},
+		{Source: "go", Package: "b", Version: "v2",
+			Metadata: map[string]string{"owner": "team-b"}},
+	}
+	b := &bytes.Buffer{}
+	err := WriteCSV(b, licenses, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `source,package,version,license,spdx,score,path,url,owner,ticket
+go,a,v1,MIT License,MIT,1.00,,,team-a,LEGAL-1
+go,b,v2,,,0.00,,,team-b,
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected output:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "LICENSE")
+	err = ioutil.WriteFile(path, []byte("Copyright <Foo>\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	licenses := []License{
+		{Package: "a", Version: "v1", Template: &matcher.Template{Title: "MIT License"},
+			Score: 1, Path: path, Metadata: map[string]string{"owner": "team-a"}},
+	}
+	b := &bytes.Buffer{}
+	err = WriteHTML(b, licenses, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`<span class="package">a</span> <span class="version">v1</span> <span class="license">MIT License</span>`,
+		`<dl class="metadata"><dt>owner</dt><dd>team-a</dd></dl>`,
+		"<pre>Copyright &lt;Foo&gt;\n</pre>",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Fatalf("%q not found in:\n%s", s, b.String())
+		}
+	}
+}
+
+func TestWriteNotice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-notice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"a1": "Copyright (c) 2015 Foo Inc.\n\nPermission is granted.\n",
+		"a2": "Copyright © 2016-2017 Foo Inc. All rights reserved.\n\nPermission is granted.\n",
+		"b":  "Copyright 2020 Bar\n\nPermission  is granted.\n",
+		"n":  "Bar component\nCopyright 2019 Bar\n",
+	}
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	licenses := []License{
+		{Package: "a", Version: "v1", Template: mit, Score: 1, Path: filepath.Join(dir, "a1")},
+		{Package: "a", Version: "v2", Template: mit, Score: 1, Path: filepath.Join(dir, "a2")},
+		{Package: "b", Version: "v1", Template: mit, Score: 1, Path: filepath.Join(dir, "b"),
+			Notice: filepath.Join(dir, "n")},
+	}
+	b := &bytes.Buffer{}
+	err = WriteNotice(b, licenses, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `THIRD-PARTY SOFTWARE NOTICES
+
+This software includes the following third-party components.
+
+` + noticeRule + `
+a v1, v2
+License: MIT License
+Copyright (c) 2015-2017 Foo Inc.
+
+` + noticeRule + `
+b v1
+License: MIT License
+Copyright (c) 2019-2020 Bar
+
+Bar component
+Copyright 2019 Bar
+
+` + noticeTitle + `
+LICENSE TEXTS
+
+` + noticeRule + `
+MIT License
+Used by: a, b
+
+Permission is granted.
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected output:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	license := filepath.Join(dir, "LICENSE")
+	err = ioutil.WriteFile(license, []byte("Copyright (c) 2020 Foo\nCopyright 2021 Bar\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := filepath.Join(dir, "report.tmpl")
+	err = ioutil.WriteFile(tmpl, []byte(`{{range .Packages}}{{.Package}}@{{.Version}}: `+
+		`{{.License}} [{{.SPDX}}] {{join .Copyrights "; "}}
+{{end}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	licenses := []License{
+		{Package: "a", Version: "v1", Template: &matcher.Template{Title: "MIT License",
+			ID: "MIT"}, Score: 1, Path: license},
+		{Package: "b", Version: "v2", Declared: "BUSL-1.1"},
+	}
+	b := &bytes.Buffer{}
+	err = Write(b, "table", licenses, Options{Confidence: 0.9, Template: tmpl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `a@v1: MIT License [MIT] Copyright (c) 2020 Foo; Copyright 2021 Bar
+b@v2: BUSL-1.1 [] 
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected output:\n%q\n!=\n%q", b.String(), wanted)
+	}
+}
+
+func TestWriteGoogleTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	license := filepath.Join(dir, "LICENSE")
+	err = ioutil.WriteFile(license, []byte("MIT text"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := filepath.Join(dir, "report.tmpl")
+	err = ioutil.WriteFile(tmpl, []byte(`{{range .}}{{.Name}},{{.Version}},`+
+		`{{.LicenseURL}},{{.LicenseName}},{{.LicenseText}}
+{{end}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	licenses := []License{
+		{Package: "a", Version: "v1", Template: &matcher.Template{Title: "MIT License",
+			ID: "MIT"}, Score: 1, Path: license, URL: "https://a/LICENSE"},
+		{Package: "b", Version: "v2", Declared: "BUSL-1.1"},
+		{Package: "c", Version: "v3", Template: &matcher.Template{Title: "MIT License",
+			ID: "MIT"}, Score: 0.5},
+	}
+	b := &bytes.Buffer{}
+	err = Write(b, "table", licenses, Options{Confidence: 0.9, Template: tmpl,
+		TemplateModel: "google"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `a,v1,https://a/LICENSE,MIT,MIT text
+b,v2,,BUSL-1.1,
+c,v3,,Unknown,
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected output:\n%q\n!=\n%q", b.String(), wanted)
+	}
+}
+
+func TestWriteGo(t *testing.T) {
+	licenses := []License{
+		{Package: "a", Version: "v1", Template: &matcher.Template{Title: "MIT License",
+			ID: "MIT"}, Score: 1},
+		{Package: "b", Declared: "BUSL-1.1"},
+	}
+	b := &bytes.Buffer{}
+	err := WriteGo(b, "thirdparty", licenses, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "licenses.go", b.Bytes(), 0)
+	if err != nil {
+		t.Fatalf("invalid Go source: %s\n%s", err, b.String())
+	}
+	if f.Name.Name != "thirdparty" {
+		t.Fatalf("unexpected package: %s", f.Name.Name)
+	}
+	for _, s := range []string{`License: "MIT License",`, `SPDX:    "MIT",`,
+		`License: "BUSL-1.1",`} {
+		if !strings.Contains(b.String(), s) {
+			t.Fatalf("%q not found in:\n%s", s, b.String())
+		}
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	gpl := &matcher.Template{Title: "GNU General Public License v3.0", ID: "GPL-3.0"}
+	licenses := []License{
+		{Source: "go", Package: "a", Version: "v1", Template: mit, Score: 1},
+		{Source: "go", Package: "b", Version: "v1", Template: gpl, Score: 1},
+		{Source: "go", Package: "c", Version: "v1", Template: mit, Score: 0.5},
+	}
+	check := func(l License) string {
+		if l.Score < 0.9 {
+			return "unknown license"
+		}
+		if l.Template == gpl {
+			return "GPL is denied"
+		}
+		return ""
+	}
+	b := &bytes.Buffer{}
+	err := Write(b, "sarif", licenses, Options{Confidence: 0.9, Check: check})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := struct {
+		Runs []struct {
+			Results []struct {
+				RuleID  string
+				Message struct{ Text string }
+			}
+		}
+	}{}
+	err = json.Unmarshal(b.Bytes(), &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, r := range log.Runs[0].Results {
+		got = append(got, r.RuleID+": "+r.Message.Text)
+	}
+	wanted := "license-policy: b@v1: GPL is denied|unknown-license: c@v1: unknown license"
+	if strings.Join(got, "|") != wanted {
+		t.Fatalf("unexpected results: %q", got)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	licenses := []License{
+		{Source: "go", Package: "a", Version: "v1", Template: mit, Score: 1},
+		{Source: "go", Package: "b", Version: "v1", Template: mit, Score: 0.5},
+	}
+	b := &bytes.Buffer{}
+	err := Write(b, "junit", licenses, Options{Confidence: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := xml.Header + `<testsuite name="licenses" tests="2" failures="1">
+  <testcase name="a@v1" classname="licenses.go"></testcase>
+  <testcase name="b@v1" classname="licenses.go">
+    <failure message="unknown license">b@v1: ?</failure>
+  </testcase>
+</testsuite>
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected output:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	n := NewNDJSONWriter(b)
+	n.Scanning(0, 2, "a")
+	n.Scanned(License{Source: "go", Package: "a", Version: "v1", Score: 0.5})
+	n.Error(fmt.Errorf("boom"))
+	err := n.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `{"event":"progress","total":2,"module":"a"}
+{"event":"license","source":"go","package":"a","version":"v1","purl":"pkg:golang/a@v1","score":0.5}
+{"event":"error","message":"boom"}
+{"event":"done","total":1}
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected output:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestWriteGroups(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	apache := &matcher.Template{Title: "Apache License 2.0", ID: "Apache-2.0"}
+	licenses := []License{
+		{Package: "a", Version: "v1", Template: mit, Score: 1},
+		{Package: "bb", Version: "v2", Template: apache, Score: 1},
+		{Package: "c", Version: "v3", Template: mit, Score: 0.5},
+		{Package: "dd", Version: "v4", Template: mit, Score: 1},
+	}
+	b := &bytes.Buffer{}
+	err := Write(b, "table", licenses, Options{Confidence: 0.9, GroupBy: "license"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `Apache License 2.0 (1)
+  bb  v2
+
+MIT License (2)
+  a   v1
+  dd  v4
+
+? (1)
+  c  v3
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected output:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestPURL(t *testing.T) {
+	tests := []struct {
+		License License
+		PURL    string
+	}{
+		{License{Source: "go", Package: "github.com/Masterminds/semver/v3",
+			Version: "v3.1.0+incompatible"},
+			"pkg:golang/github.com/masterminds/semver/v3@v3.1.0%2Bincompatible"},
+		{License{Source: "deb", Package: "libc6", Version: "2.36-9+deb12u1"},
+			"pkg:deb/debian/libc6@2.36-9%2Bdeb12u1"},
+		{License{Source: "apk", Package: "musl", Version: "1.2.4-r2"},
+			"pkg:apk/alpine/musl@1.2.4-r2"},
+		{License{Source: "rpm", Package: "bash", Version: "5.1"}, ""},
+	}
+	for _, test := range tests {
+		purl := PURL(test.License)
+		if purl != test.PURL {
+			t.Errorf("unexpected package URL: %q != %q", purl, test.PURL)
+		}
+	}
+}
+
+func TestWithHashes(t *testing.T) {
+	f, err := ioutil.TempFile("", "go-licenses-hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("license\n")
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	licenses, err := withHashes([]License{
+		{Package: "a", Path: f.Name()},
+		{Package: "b", Path: f.Name() + ".missing"},
+		{Package: "c", Path: f.Name(), Hash: "kept"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := "c0c56958ef8be5c1979366896b7e0c7206949a5aa2b23f51429c7f56b10990d3"
+	if licenses[0].Hash != wanted || licenses[1].Hash != "" ||
+		licenses[2].Hash != "kept" {
+		t.Fatalf("unexpected hashes: %+v", licenses)
+	}
+}
+
+func TestWriteSPDX(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	licenses := []License{
+		{Source: "go", Package: "example.com/main", Root: true, Template: mit,
+			Score: 1, Requires: []string{"example.com/dep"}},
+		{Source: "go", Package: "example.com/dep", Version: "v1.0.0", Template: mit,
+			Score: 0.5, Declared: "Apache-2.0",
+			VCS: &VCS{Type: "git", URL: "https://example.com/dep", Hash: "abcdef"}},
+	}
+	b := &bytes.Buffer{}
+	err := WriteSPDX(b, licenses, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := spdxDocument{}
+	err = json.Unmarshal(b.Bytes(), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Packages) != 2 || doc.Packages[0].LicenseConcluded != "MIT" ||
+		doc.Packages[1].LicenseConcluded != spdxNoAssertion ||
+		doc.Packages[1].LicenseDeclared != "Apache-2.0" ||
+		doc.Packages[0].DownloadLocation != spdxNoAssertion ||
+		doc.Packages[1].DownloadLocation != "git+https://example.com/dep@abcdef" {
+		t.Fatalf("unexpected packages: %+v", doc.Packages)
+	}
+	wanted := []spdxRelationship{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Package-1"},
+		{"SPDXRef-Package-1", "DEPENDS_ON", "SPDXRef-Package-2"},
+	}
+	if fmt.Sprint(doc.Relationships) != fmt.Sprint(wanted) {
+		t.Fatalf("unexpected relationships: %+v", doc.Relationships)
+	}
+}
+
+func TestRelativePaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := filepath.Join(dir, "mod")
+	modFile := filepath.Join(cache, "example.com", "dep@v1.0.0", "LICENSE")
+	mainFile := filepath.Join(dir, "LICENSE")
+	err = os.MkdirAll(filepath.Dir(modFile), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{modFile, mainFile} {
+		err = ioutil.WriteFile(path, []byte("license"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := filepath.Join(os.TempDir(), "other", "LICENSE")
+	licenses := []License{
+		{Package: "example.com/dep", Path: modFile},
+		{Package: "example.com/main", Path: mainFile},
+		{Package: "example.com/other", Path: other},
+	}
+	roots := []string{cache, dir}
+	relative := RelativePaths(licenses, roots)
+	paths := []string{}
+	for _, l := range relative {
+		paths = append(paths, l.Path)
+	}
+	wanted := []string{"example.com/dep@v1.0.0/LICENSE", "LICENSE", other}
+	if fmt.Sprint(paths) != fmt.Sprint(wanted) {
+		t.Fatalf("unexpected relative paths: %v", paths)
+	}
+	resolved := ResolvePaths(relative, roots)
+	for i, l := range resolved {
+		if l.Path != licenses[i].Path {
+			t.Fatalf("%s resolved to %s", licenses[i].Path, l.Path)
+		}
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	licenses := []License{
+		{Source: "go", Package: "example.com/dep", Version: "v1.0.0", Template: mit,
+			Score: 1, Path: filepath.Join(os.TempDir(), "mod", "LICENSE")},
+	}
+	opts := Options{Confidence: 0.9, Reproducible: true}
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	b := &bytes.Buffer{}
+	err := Write(b, "spdx", licenses, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"created": "1970-01-01T00:00:00Z"`) {
+		t.Fatalf("unexpected creation time:\n%s", b)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	b.Reset()
+	err = Write(b, "spdx", licenses, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"created": "2023-11-14T22:13:20Z"`) {
+		t.Fatalf("SOURCE_DATE_EPOCH ignored:\n%s", b)
+	}
+	b.Reset()
+	err = Write(b, "json", licenses, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), os.TempDir()) {
+		t.Fatalf("absolute path in reproducible output:\n%s", b)
+	}
+	if licenses[0].Path == "" {
+		t.Fatalf("licenses modified")
+	}
+}
+
+func TestWriteObligations(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License",
+		Required: []string{"include-copyright"}}
+	apache := &matcher.Template{Title: "Apache License 2.0",
+		Required:  []string{"include-copyright", "document-changes"},
+		Permitted: []string{"patent-grant"}}
+	licenses := []License{
+		{Package: "a", Template: mit, Score: 1},
+		{Package: "b", Template: apache, Score: 1},
+		{Package: "c", Template: apache, Score: 0.95},
+		{Package: "d", Template: apache, Score: 0.5},
+	}
+	b := &bytes.Buffer{}
+	err := WriteObligations(b, "table", licenses, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `Include copyright and license notices       3 packages  Apache License 2.0, MIT License
+Document changes made to the licensed code  2 packages  Apache License 2.0
+Patent rights are granted by contributors   2 packages  Apache License 2.0
+Review unidentified licenses                1 package   
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected obligations:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestWriteExplanation(t *testing.T) {
+	foo, err := matcher.ParseTemplate("---\ntitle: Foo License\nspdx-id: Foo\n---\n" +
+		"permission is granted to use this software without any warranty\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := []byte("permission is granted to use the software without warranty\n")
+	b := &bytes.Buffer{}
+	err = WriteExplanation(b, License{Package: "a", Version: "v1", Path: "LICENSE",
+		Template: foo}, text, []*matcher.Template{foo}, 0.95, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `Package:   a v1
+File:      LICENSE
+Template:  Foo License (Foo)
+Score:     92.3%, below the 95% confidence threshold
+           2 x 6 common words / (6 license words + 7 template words)
+           0 extra words, 1 missing words
+
+Candidates:
+   92.3%  Foo License
+
+LICENSE FILE                            TEMPLATE
+permission is granted to use            permission is granted to use
+the                                   | this
+software without                        software without
+                                      > any
+warranty                                warranty
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected explanation:\n%s\n!=\n%s", b.String(), wanted)
+	}
+}
+
+func TestWriteHistory(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License"}
+	busl := &matcher.Template{Title: "Business Source License 1.1"}
+	old := License{Package: "a", Version: "v1.0.0", Path: "/m@v1.0.0/LICENSE",
+		Template: mit, Score: 1}
+	new := License{Package: "a", Version: "v2.0.0", Path: "/m@v2.0.0/LICENSE.md",
+		Template: busl, Score: 1}
+	b := &bytes.Buffer{}
+	err := WriteHistory(b, old, new, []byte("use freely\n"), []byte("use in production\n"),
+		0.9, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := `Module:    a
+Versions:  v1.0.0 -> v2.0.0
+License:   MIT License -> Business Source License 1.1, changed
+File:      LICENSE -> LICENSE.md, content changed
+
+v1.0.0                                  v2.0.0
+use                                     use
+freely                                | in production
+`
+	if b.String() != wanted {
+		t.Fatalf("unexpected history:\n%s\n!=\n%s", b.String(), wanted)
+	}
+	if LicenseChanged(old, old, 0.9) || !LicenseChanged(old, new, 0.9) {
+		t.Fatalf("unexpected license changes")
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	license := filepath.Join(dir, "LICENSE")
+	notice := filepath.Join(dir, "NOTICE")
+	for _, path := range []string{license, notice} {
+		err = ioutil.WriteFile(path, []byte(filepath.Base(path)), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	licenses := []License{
+		{Source: "go", Package: "example.com/a", Path: license, Notice: notice},
+		{Source: "deb", Package: "zlib1g", Path: license},
+		{Source: "go", Package: "example.com/b"},
+	}
+	b := &bytes.Buffer{}
+	err = Write(b, "zip", licenses, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	wanted := "[manifest.json deb/zlib1g/LICENSE example.com/a/LICENSE example.com/a/NOTICE]"
+	if fmt.Sprint(names) != wanted {
+		t.Fatalf("unexpected archive files: %v", names)
+	}
+	r, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []Record{}
+	err = json.NewDecoder(r).Decode(&records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[1].Path != "example.com/a/LICENSE" || records[2].Path != "" {
+		t.Fatalf("unexpected manifest: %+v", records)
+	}
+
+	first := &bytes.Buffer{}
+	second := &bytes.Buffer{}
+	for _, b := range []*bytes.Buffer{first, second} {
+		err = Write(b, "tar", licenses, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatalf("tar archives differ")
+	}
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SARIF rule identifiers.
+const (
+	ruleUnknown = "unknown-license"
+	rulePolicy  = "license-policy"
+)
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// unknownReason is the violation reason of licenses not detected with
+// enough confidence when no policy is set.
+const unknownReason = "unknown license"
+
+// evaluate returns why l is a violation according to opts.
+func evaluate(l License, opts Options) string {
+	if opts.Check != nil {
+		return opts.Check(l)
+	}
+	if l.Template == nil || l.Score < opts.Confidence {
+		return unknownReason
+	}
+	return ""
+}
+
+// artifactURI returns the file a package result is attached to: go.mod for
+// Go modules, the license file otherwise.
+func artifactURI(l License) string {
+	if l.Source == "" || l.Source == "go" {
+		return "go.mod"
+	}
+	if l.Path != "" {
+		return l.Path
+	}
+	return l.Package
+}
+
+// WriteSARIF writes policy violations and unknown licenses as a SARIF log,
+// one result per package, located in go.mod for Go modules.
+func WriteSARIF(w io.Writer, licenses []License, opts Options) error {
+	results := []sarifResult{}
+	for _, l := range licenses {
+		reason := evaluate(l, opts)
+		if reason == "" {
+			continue
+		}
+		rule := rulePolicy
+		if reason == unknownReason {
+			rule = ruleUnknown
+		}
+		name := l.Package
+		if l.Version != "" {
+			name += "@" + l.Version
+		}
+		results = append(results, sarifResult{
+			RuleID:  rule,
+			Level:   "error",
+			Message: sarifMessage{Text: name + ": " + reason},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: artifactURI(l)},
+				},
+				LogicalLocations: []sarifLogicalLocation{{
+					FullyQualifiedName: l.Package,
+					Kind:               "module",
+				}},
+			}},
+			// Versions are left out so results are tracked across upgrades.
+			PartialFingerprints: map[string]string{
+				"package": l.Source + ":" + l.Package + ":" + reason,
+			},
+		})
+	}
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "go-licenses",
+				InformationURI: "https://github.com/groove-x/go-licenses",
+				Rules: []sarifRule{
+					{ID: ruleUnknown, ShortDescription: sarifMessage{
+						Text: "License not detected with enough confidence"}},
+					{ID: rulePolicy, ShortDescription: sarifMessage{
+						Text: "License breaking the license policy"}},
+				},
+			}},
+			Results: results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
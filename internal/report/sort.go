@@ -0,0 +1,52 @@
+package report
+
+import "sort"
+
+// Less returns true if a is written before b in reports: licenses are sorted
+// by source, an empty one being "go", project path of modules reported in
+// several major versions, package path, license name as computed with
+// confidence, version and license file path, so output does not depend on
+// the order packages were scanned or grouped in.
+func Less(a, b License, confidence float64) bool {
+	if sa, sb := source(a), source(b); sa != sb {
+		return sa < sb
+	}
+	if pa, pb := project(a), project(b); pa != pb {
+		return pa < pb
+	}
+	if a.Package != b.Package {
+		return a.Package < b.Package
+	}
+	if na, nb := licenseName(a, confidence), licenseName(b, confidence); na != nb {
+		return na < nb
+	}
+	if a.Version != b.Version {
+		return a.Version < b.Version
+	}
+	return a.Path < b.Path
+}
+
+// project returns the project path of l if set, its package path otherwise,
+// so the major versions of modules are listed together.
+func project(l License) string {
+	if l.Project != "" {
+		return l.Project
+	}
+	return l.Package
+}
+
+func source(l License) string {
+	if l.Source == "" {
+		return "go"
+	}
+	return l.Source
+}
+
+// Sorted returns a copy of licenses sorted with Less.
+func Sorted(licenses []License, confidence float64) []License {
+	sorted := append([]License{}, licenses...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return Less(sorted[i], sorted[j], confidence)
+	})
+	return sorted
+}
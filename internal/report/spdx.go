@@ -0,0 +1,149 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+const spdxNoAssertion = "NOASSERTION"
+
+// WriteSPDX writes licenses as an SPDX 2.3 JSON document, one package per
+// entry. Concluded licenses are the SPDX identifiers of templates scoring at
+// least confidence. The document describes the root package if any, all
+// packages otherwise. Go modules depend on the modules they require, and are
+// downloaded from their upstream revision, when known.
+func WriteSPDX(w io.Writer, licenses []License, confidence float64) error {
+	return writeSPDX(w, licenses, confidence, creationTime(false))
+}
+
+func writeSPDX(w io.Writer, licenses []License, confidence float64, created time.Time) error {
+	doc := spdxDocument{
+		SPDXVersion: "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        "go-licenses",
+		CreationInfo: spdxCreationInfo{
+			Created:  created.Format(time.RFC3339),
+			Creators: []string{"Tool: go-licenses"},
+		},
+		Packages:      []spdxPackage{},
+		Relationships: []spdxRelationship{},
+	}
+	ids := map[string]string{}
+	hash := sha256.New()
+	for i, l := range licenses {
+		p := spdxPackage{
+			Name:             l.Package,
+			SPDXID:           "SPDXRef-Package-" + strconv.Itoa(i+1),
+			VersionInfo:      l.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+		}
+		if l.VCS != nil {
+			p.DownloadLocation = l.VCS.DownloadLocation()
+		}
+		if l.Template != nil && l.Template.ID != "" && l.Score >= confidence {
+			p.LicenseConcluded = l.Template.ID
+		}
+		if l.Declared != "" {
+			p.LicenseDeclared = l.Declared
+		}
+		if purl := PURL(l); purl != "" {
+			p.ExternalRefs = []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  purl,
+			}}
+		}
+		if l.Source == "" || l.Source == "go" {
+			ids[l.Package] = p.SPDXID
+		}
+		doc.Packages = append(doc.Packages, p)
+		io.WriteString(hash, l.Source+" "+l.Package+" "+l.Version+"\n")
+	}
+	for i, l := range licenses {
+		if l.Root {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				Element: doc.SPDXID,
+				Type:    "DESCRIBES",
+				Related: doc.Packages[i].SPDXID,
+			})
+		}
+	}
+	if len(doc.Relationships) == 0 {
+		for _, p := range doc.Packages {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				Element: doc.SPDXID,
+				Type:    "DESCRIBES",
+				Related: p.SPDXID,
+			})
+		}
+	}
+	for i, l := range licenses {
+		for _, dep := range l.Requires {
+			if id, ok := ids[dep]; ok {
+				doc.Relationships = append(doc.Relationships, spdxRelationship{
+					Element: doc.Packages[i].SPDXID,
+					Type:    "DEPENDS_ON",
+					Related: id,
+				})
+			}
+		}
+	}
+	// The namespace identifies the set of packages, not the document
+	// instance.
+	doc.DocumentNamespace = "https://github.com/groove-x/go-licenses/spdx/" +
+		hex.EncodeToString(hash.Sum(nil))
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
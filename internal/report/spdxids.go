@@ -0,0 +1,69 @@
+package report
+
+import (
+	"strings"
+)
+
+// deprecatedIDs maps SPDX license identifiers deprecated by the SPDX license
+// list to their current replacement.
+var deprecatedIDs = map[string]string{
+	"AGPL-1.0":             "AGPL-1.0-only or AGPL-1.0-or-later",
+	"AGPL-3.0":             "AGPL-3.0-only or AGPL-3.0-or-later",
+	"BSD-2-Clause-FreeBSD": "BSD-2-Clause",
+	"BSD-2-Clause-NetBSD":  "BSD-2-Clause",
+	"bzip2-1.0.5":          "bzip2-1.0.6",
+	"eCos-2.0":             "GPL-2.0-or-later WITH eCos-exception-2.0",
+	"GFDL-1.1":             "GFDL-1.1-only or GFDL-1.1-or-later",
+	"GFDL-1.2":             "GFDL-1.2-only or GFDL-1.2-or-later",
+	"GFDL-1.3":             "GFDL-1.3-only or GFDL-1.3-or-later",
+	"GPL-1.0":              "GPL-1.0-only",
+	"GPL-1.0+":             "GPL-1.0-or-later",
+	"GPL-2.0":              "GPL-2.0-only or GPL-2.0-or-later",
+	"GPL-2.0+":             "GPL-2.0-or-later",
+	"GPL-3.0":              "GPL-3.0-only or GPL-3.0-or-later",
+	"GPL-3.0+":             "GPL-3.0-or-later",
+	"LGPL-2.0":             "LGPL-2.0-only",
+	"LGPL-2.0+":            "LGPL-2.0-or-later",
+	"LGPL-2.1":             "LGPL-2.1-only or LGPL-2.1-or-later",
+	"LGPL-2.1+":            "LGPL-2.1-or-later",
+	"LGPL-3.0":             "LGPL-3.0-only or LGPL-3.0-or-later",
+	"LGPL-3.0+":            "LGPL-3.0-or-later",
+	"Nunit":                "zlib-acknowledgement",
+	"StandardML-NJ":        "SMLNJ",
+	"wxWindows":            "GPL-2.0-or-later WITH WxWindows-exception-3.1",
+}
+
+// ExpressionIDs returns the license identifiers of an SPDX expression, like
+// "MIT" and "Apache-2.0" for "(MIT OR Apache-2.0)".
+func ExpressionIDs(expr string) []string {
+	ids := []string{}
+	for _, f := range strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(expr)) {
+		switch strings.ToUpper(f) {
+		case "AND", "OR", "WITH":
+			continue
+		}
+		ids = append(ids, f)
+	}
+	return ids
+}
+
+// Warnings returns the warnings about l: deprecated SPDX identifiers used by
+// the template detected with confidence or by the declared license, with
+// their replacement.
+func Warnings(l License, confidence float64) []string {
+	ids := []string{}
+	if l.Template != nil && l.Template.ID != "" && l.Score >= confidence {
+		ids = append(ids, l.Template.ID)
+	}
+	ids = append(ids, ExpressionIDs(l.Declared)...)
+	warnings := []string{}
+	for _, id := range ids {
+		if replacement, ok := deprecatedIDs[id]; ok {
+			w := id + " is a deprecated SPDX identifier, use " + replacement
+			if !hasString(warnings, w) {
+				warnings = append(warnings, w)
+			}
+		}
+	}
+	return warnings
+}
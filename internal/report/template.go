@@ -0,0 +1,181 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/groove-x/go-licenses/internal/normalize"
+)
+
+// TemplateData is the data model passed to user-defined output templates.
+type TemplateData struct {
+	Packages []TemplatePackage
+}
+
+// TemplatePackage describes a package or module in user-defined output
+// templates.
+type TemplatePackage struct {
+	// Source names the scanner which reported the package, like "go".
+	Source  string
+	Package string
+	Version string
+	// PURL is the package URL of the package, if any.
+	PURL string
+	// License is the title of the license detected with enough confidence,
+	// the declared license otherwise, or "?". SPDX is its SPDX identifier, if
+	// known.
+	License string
+	SPDX    string
+	// OSIApproved and FSFLibre tell whether License is OSI-approved and
+	// FSF-free.
+	OSIApproved bool
+	FSFLibre    bool
+	Declared    string
+	Score       float64
+	// Path is the license file path, Text its content, SHA256 its hash and
+	// URL a link to it upstream, if known.
+	Path   string
+	Text   string
+	SHA256 string
+	URL    string
+	// Copyrights are the copyright statements of the license file.
+	Copyrights []string
+	// Notice is the NOTICE file path and NoticeText its content.
+	Notice     string
+	NoticeText string
+	Error      string
+	// Metadata holds the fields attached to the package by the
+	// configuration file.
+	Metadata map[string]string
+}
+
+// TemplateModels lists the data models of user-defined output templates:
+// "packages", the default, executes them with a *TemplateData and "google"
+// with a []GoogleLibrary, like the report templates of google/go-licenses.
+var TemplateModels = []string{"packages", "google"}
+
+// GoogleLibrary describes a package in user-defined output templates using
+// the data model of google/go-licenses report command.
+type GoogleLibrary struct {
+	Name    string
+	Version string
+	// LicenseName is the SPDX identifier of the license detected with enough
+	// confidence, the declared license otherwise, or "Unknown".
+	LicenseName string
+	// LicenseURL is a link to the license file upstream, LicensePath its
+	// path and LicenseText its content.
+	LicenseURL  string
+	LicensePath string
+	LicenseText string
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// NewTemplateData returns the template data describing licenses, reading
+// license and notice files.
+func NewTemplateData(licenses []License, confidence float64) (*TemplateData, error) {
+	data := &TemplateData{Packages: []TemplatePackage{}}
+	for _, l := range licenses {
+		text, err := readText(l.Path)
+		if err != nil {
+			return nil, err
+		}
+		notice, err := readText(l.Notice)
+		if err != nil {
+			return nil, err
+		}
+		p := TemplatePackage{
+			Source:     l.Source,
+			Package:    l.Package,
+			Version:    l.Version,
+			PURL:       PURL(l),
+			License:    licenseName(l, confidence),
+			Declared:   l.Declared,
+			Score:      l.Score,
+			Path:       l.Path,
+			Text:       text,
+			SHA256:     l.Hash,
+			URL:        l.URL,
+			Copyrights: normalize.Copyrights([]byte(text)),
+			Notice:     l.Notice,
+			NoticeText: notice,
+			Error:      l.Err,
+			Metadata:   l.Metadata,
+		}
+		if l.Template != nil && l.Score >= confidence {
+			p.SPDX = l.Template.ID
+			p.OSIApproved = l.Template.OSIApproved
+			p.FSFLibre = l.Template.FSFLibre
+		}
+		data.Packages = append(data.Packages, p)
+	}
+	return data, nil
+}
+
+// NewGoogleTemplateData returns the template data describing licenses with
+// the data model of google/go-licenses, reading license files.
+func NewGoogleTemplateData(licenses []License, confidence float64) (
+	[]GoogleLibrary, error) {
+
+	data := []GoogleLibrary{}
+	for _, l := range licenses {
+		text, err := readText(l.Path)
+		if err != nil {
+			return nil, err
+		}
+		lib := GoogleLibrary{
+			Name:        l.Package,
+			Version:     l.Version,
+			LicenseName: l.Declared,
+			LicenseURL:  l.URL,
+			LicensePath: l.Path,
+			LicenseText: text,
+		}
+		if l.Template != nil && l.Template.ID != "" && l.Score >= confidence {
+			lib.LicenseName = l.Template.ID
+		}
+		if lib.LicenseName == "" {
+			lib.LicenseName = "Unknown"
+		}
+		data = append(data, lib)
+	}
+	return data, nil
+}
+
+// WriteTemplate renders licenses with the text/template file at path, which
+// is executed with the data of the named model of TemplateModels, a
+// *TemplateData by default. A "join" function is available in addition to
+// the builtin ones.
+func WriteTemplate(w io.Writer, path, model string, licenses []License,
+	confidence float64) error {
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).
+		Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("could not parse template: %s", err)
+	}
+	var data interface{}
+	switch model {
+	case "", "packages":
+		data, err = NewTemplateData(licenses, confidence)
+	case "google":
+		data, err = NewGoogleTemplateData(licenses, confidence)
+	default:
+		err = fmt.Errorf("unknown template model %q, expected: %s", model,
+			strings.Join(TemplateModels, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
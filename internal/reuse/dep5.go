@@ -0,0 +1,127 @@
+package reuse
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// dep5Paragraph is a Files paragraph of a dep5 file, declaring the copyright
+// and license of the files matching its patterns.
+type dep5Paragraph struct {
+	Files      []*regexp.Regexp
+	Copyrights []string
+	// License is an SPDX license expression.
+	License string
+}
+
+// dep5 is a machine-readable Debian copyright file, which REUSE projects use
+// to declare the copyright and license of files which cannot hold tags.
+type dep5 struct {
+	Paragraphs []dep5Paragraph
+}
+
+// globRegexp converts a dep5 file pattern to a regular expression matching
+// slash-separated relative paths. "*" matches any characters, slashes
+// included, "?" any single character, and a backslash escapes them.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	expr := "^"
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr += ".*"
+		case '?':
+			expr += "."
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, fmt.Errorf("trailing backslash in pattern %q", pattern)
+			}
+			i++
+			expr += regexp.QuoteMeta(pattern[i : i+1])
+		default:
+			expr += regexp.QuoteMeta(string(c))
+		}
+	}
+	return regexp.Compile(expr + "$")
+}
+
+// parseDep5 parses a dep5 file, keeping the continuation lines of the Files
+// and Copyright fields but only the first line of License fields, the license
+// expression.
+func parseDep5(data []byte) (*dep5, error) {
+	d := &dep5{}
+	fields := map[string][]string{}
+	flush := func() error {
+		defer func() { fields = map[string][]string{} }()
+		if len(fields["Files"]) == 0 {
+			return nil
+		}
+		p := dep5Paragraph{Copyrights: fields["Copyright"]}
+		if len(fields["License"]) > 0 {
+			p.License = fields["License"][0]
+		}
+		for _, line := range fields["Files"] {
+			for _, pattern := range strings.Fields(line) {
+				re, err := globRegexp(pattern)
+				if err != nil {
+					return err
+				}
+				p.Files = append(p.Files, re)
+			}
+		}
+		d.Paragraphs = append(d.Paragraphs, p)
+		return nil
+	}
+	key := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			key = ""
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if key == "" {
+				return nil, fmt.Errorf("line %d: continuation line without field", n)
+			}
+			if value := strings.TrimSpace(line); value != "." {
+				fields[key] = append(fields[key], value)
+			}
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected a field", n)
+		}
+		key = line[:i]
+		if value := strings.TrimSpace(line[i+1:]); value != "" {
+			fields[key] = append(fields[key], value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// find returns the paragraph declaring the copyright and license of the file
+// at slash-separated relative path name, the last matching one, or nil.
+func (d *dep5) find(name string) *dep5Paragraph {
+	for i := len(d.Paragraphs) - 1; i >= 0; i-- {
+		for _, re := range d.Paragraphs[i].Files {
+			if re.MatchString(name) {
+				return &d.Paragraphs[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+// Package reuse checks projects against the REUSE specification: every file
+// declares its copyright and license, with SPDX tags in its header, in a
+// ".license" file next to it or in the .reuse/dep5 file, and the texts of the
+// licenses used are stored in the LICENSES directory.
+package reuse
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+const (
+	licensesDir = "LICENSES"
+	dep5Path    = ".reuse/dep5"
+)
+
+// File is the copyright and licensing information of a project file.
+type File struct {
+	// Path is the slash-separated path of the file, relative to the project
+	// root.
+	Path       string
+	Copyrights []string
+	// Licenses are SPDX license expressions.
+	Licenses []string
+}
+
+// Problem is a violation of the REUSE specification.
+type Problem struct {
+	// Path is the slash-separated path of the file at fault, relative to the
+	// project root.
+	Path   string
+	Reason string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Reason
+}
+
+// Result is the outcome of checking a project.
+type Result struct {
+	// Files are the files checked, sorted by path.
+	Files []File
+	// Problems are sorted by path.
+	Problems []Problem
+}
+
+// REUSE-IgnoreStart
+var (
+	reLicense   = regexp.MustCompile(`SPDX-License-Identifier:[ \t]*(.*)`)
+	reCopyright = regexp.MustCompile(`^[ \t#/*;'"!%<>{}-]*((?:SPDX-(?:File|Snippet)CopyrightText:|` +
+		`Copyright(?:[ \t]*\([cC]\))?|©)[ \t]+\S.*)`)
+	// reLicenseID matches valid SPDX license identifiers.
+	reLicenseID = regexp.MustCompile(`^[A-Za-z0-9.+-]+$`)
+)
+
+// commentEnds are the comment terminators trimmed from tag values, like the
+// "*/" of "/* SPDX-License-Identifier: MIT */".
+var commentEnds = []string{"*/", "-->", "--%>", "#}", "%}", `"""`, `'''`}
+
+// REUSE-IgnoreEnd
+
+func trimTag(value string) string {
+	value = strings.TrimSpace(value)
+	for {
+		trimmed := value
+		for _, end := range commentEnds {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, end))
+		}
+		if trimmed == value {
+			return value
+		}
+		value = trimmed
+	}
+}
+
+// readTags returns the copyright and license tags of data, skipping the
+// lines between "REUSE-IgnoreStart" and "REUSE-IgnoreEnd".
+func readTags(data []byte) (copyrights, licenses []string) {
+	ignore := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "REUSE-IgnoreStart") {
+			ignore = true
+		}
+		if strings.Contains(line, "REUSE-IgnoreEnd") {
+			ignore = false
+			continue
+		}
+		if ignore {
+			continue
+		}
+		if m := reLicense.FindStringSubmatch(line); m != nil {
+			if expr := trimTag(m[1]); expr != "" {
+				licenses = append(licenses, expr)
+			}
+		} else if m := reCopyright.FindStringSubmatch(line); m != nil {
+			copyrights = append(copyrights, trimTag(m[1]))
+		}
+	}
+	return copyrights, licenses
+}
+
+// isBinary returns true if data looks like binary content, whose tags are
+// not read.
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// isIgnored returns true if the file at slash-separated relative path name
+// needs no copyright and licensing information: license texts and files
+// holding the information of others.
+func isIgnored(name string) bool {
+	if strings.HasPrefix(name, licensesDir+"/") || strings.HasPrefix(name, ".reuse/") ||
+		strings.HasPrefix(name, ".git/") || strings.Contains(name, "/.git/") {
+		return true
+	}
+	base := path.Base(name)
+	upper := strings.ToUpper(strings.TrimSuffix(base, path.Ext(base)))
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING"} {
+		if upper == prefix || strings.HasPrefix(upper, prefix+"-") {
+			return true
+		}
+	}
+	return strings.HasSuffix(base, ".license") || strings.HasSuffix(base, ".spdx")
+}
+
+// listFiles returns the slash-separated paths of the files of the project at
+// root, relative to it. Files ignored by git are left out when the project
+// is a git working tree.
+func listFiles(root string) ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others",
+		"--exclude-standard")
+	cmd.Dir = root
+	if out, err := cmd.Output(); err == nil {
+		files := []string{}
+		for _, name := range strings.Split(string(out), "\x00") {
+			if name != "" {
+				files = append(files, name)
+			}
+		}
+		return files, nil
+	}
+	files := []string{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files, err
+}
+
+// listLicenseTexts returns the paths of the license texts of the LICENSES
+// directory of the project at root, by the license identifier naming them.
+func listLicenseTexts(root string) (map[string]string, error) {
+	texts := map[string]string{}
+	infos, err := ioutil.ReadDir(filepath.Join(root, licensesDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return texts, nil
+		}
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		name := info.Name()
+		texts[strings.TrimSuffix(name, path.Ext(name))] = licensesDir + "/" + name
+	}
+	return texts, nil
+}
+
+// fileInfo returns the copyright and licensing information of the file at
+// slash-separated relative path name of the project at root. A ".license"
+// file next to it replaces the information of its header, which dep5
+// completes.
+func fileInfo(root, name string, d *dep5) (File, error) {
+	f := File{Path: name}
+	data, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(name)+".license"))
+	if os.IsNotExist(err) {
+		data, err = ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err == nil && isBinary(data) {
+			data = nil
+		}
+	}
+	if err != nil {
+		return f, err
+	}
+	f.Copyrights, f.Licenses = readTags(data)
+	if p := d.find(name); p != nil {
+		f.Copyrights = append(f.Copyrights, p.Copyrights...)
+		if p.License != "" {
+			f.Licenses = append(f.Licenses, p.License)
+		}
+	}
+	return f, nil
+}
+
+// Lint checks the project at root against the REUSE specification. License
+// texts of the LICENSES directory are also matched against templates, and
+// those matching another license than the one naming them with a score of
+// confidence or more are reported.
+func Lint(root string, templates []*matcher.Template, confidence float64) (*Result, error) {
+	r := &Result{Files: []File{}, Problems: []Problem{}}
+	d := &dep5{}
+	data, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(dep5Path)))
+	if err == nil {
+		d, err = parseDep5(data)
+		if err != nil {
+			r.Problems = append(r.Problems, Problem{dep5Path, err.Error()})
+			d = &dep5{}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	names, err := listFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	// users are the files using each license identifier.
+	users := map[string][]string{}
+	for _, name := range names {
+		if isIgnored(name) {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Deleted files are still listed by git.
+				continue
+			}
+			return nil, err
+		}
+		if !info.Mode().IsRegular() || info.Size() == 0 {
+			continue
+		}
+		f, err := fileInfo(root, name, d)
+		if err != nil {
+			return nil, err
+		}
+		r.Files = append(r.Files, f)
+		switch {
+		case len(f.Copyrights) == 0 && len(f.Licenses) == 0:
+			r.Problems = append(r.Problems, Problem{name, "missing copyright and license"})
+		case len(f.Copyrights) == 0:
+			r.Problems = append(r.Problems, Problem{name, "missing copyright"})
+		case len(f.Licenses) == 0:
+			r.Problems = append(r.Problems, Problem{name, "missing license"})
+		}
+		for _, expr := range f.Licenses {
+			for _, id := range report.ExpressionIDs(expr) {
+				if !reLicenseID.MatchString(id) {
+					r.Problems = append(r.Problems, Problem{name, "bad license identifier " + id})
+					continue
+				}
+				if len(users[id]) == 0 || users[id][len(users[id])-1] != name {
+					users[id] = append(users[id], name)
+				}
+			}
+			for _, warning := range report.Warnings(report.License{Declared: expr}, 1) {
+				r.Problems = append(r.Problems, Problem{name, warning})
+			}
+		}
+	}
+	texts, err := listLicenseTexts(root)
+	if err != nil {
+		return nil, err
+	}
+	for id, files := range users {
+		if _, ok := texts[id]; ok {
+			continue
+		}
+		reason := "missing license text, used by " + files[0]
+		if len(files) > 1 {
+			reason += fmt.Sprintf(" and %d more files", len(files)-1)
+		}
+		r.Problems = append(r.Problems, Problem{licensesDir + "/" + id + ".txt", reason})
+	}
+	for id, name := range texts {
+		if len(users[id]) == 0 {
+			r.Problems = append(r.Problems, Problem{name, "unused license text"})
+			continue
+		}
+		expected := matcher.FindTemplate(templates, id)
+		if expected == nil {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+		m := matcher.Match(data, templates)
+		if m.Template != nil && m.Score >= confidence && m.Template.ID != expected.ID {
+			r.Problems = append(r.Problems, Problem{name, fmt.Sprintf(
+				"license text matches %s, not %s", m.Template.ID, id)})
+		}
+	}
+	sort.SliceStable(r.Problems, func(i, j int) bool {
+		if r.Problems[i].Path != r.Problems[j].Path {
+			return r.Problems[i].Path < r.Problems[j].Path
+		}
+		return r.Problems[i].Reason < r.Problems[j].Reason
+	})
+	return r, nil
+}
@@ -0,0 +1,153 @@
+package reuse
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+)
+
+// REUSE-IgnoreStart
+
+func TestReadTags(t *testing.T) {
+	data := `/*
+ * SPDX-FileCopyrightText: 2024 Jane Doe <jane@example.com>
+ * SPDX-License-Identifier: MIT OR Apache-2.0 */
+# Copyright (c) 2023 Example Inc.
+// Copyrights are assigned
+<!-- SPDX-License-Identifier: CC-BY-4.0 -->
+REUSE-IgnoreStart
+SPDX-License-Identifier: GPL-3.0
+REUSE-IgnoreEnd
+`
+	copyrights, licenses := readTags([]byte(data))
+	wanted := []string{"SPDX-FileCopyrightText: 2024 Jane Doe <jane@example.com>",
+		"Copyright (c) 2023 Example Inc."}
+	if !reflect.DeepEqual(copyrights, wanted) {
+		t.Errorf("unexpected copyrights: %q != %q", copyrights, wanted)
+	}
+	wanted = []string{"MIT OR Apache-2.0", "CC-BY-4.0"}
+	if !reflect.DeepEqual(licenses, wanted) {
+		t.Errorf("unexpected licenses: %q != %q", licenses, wanted)
+	}
+}
+
+func TestParseDep5(t *testing.T) {
+	data := `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: example
+
+Files: testdata/*
+ assets/?.png
+Copyright: 2024 Jane Doe
+ 2023 Example Inc.
+License: CC0-1.0
+
+Files: testdata/keep\*.txt
+Copyright: 2024 John Doe
+License: MIT
+ Permission is hereby granted...
+`
+	d, err := parseDep5([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, wanted := range map[string]string{
+		"testdata/a/b.json":   "CC0-1.0",
+		"assets/a.png":        "CC0-1.0",
+		"assets/ab.png":       "",
+		"testdata/keep*.txt":  "MIT",
+		"testdata/keepme.txt": "CC0-1.0",
+	} {
+		got := ""
+		if p := d.find(name); p != nil {
+			got = p.License
+		}
+		if got != wanted {
+			t.Errorf("unexpected license of %s: %q != %q", name, got, wanted)
+		}
+	}
+	copyrights := d.find("assets/a.png").Copyrights
+	if len(copyrights) != 2 || copyrights[1] != "2023 Example Inc." {
+		t.Errorf("unexpected copyrights: %q", copyrights)
+	}
+}
+
+func TestLint(t *testing.T) {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := map[string]string{}
+	for _, templ := range templates {
+		texts[templ.ID] = templ.Text
+	}
+	dir, err := ioutil.TempDir("", "go-licenses-reuse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"LICENSES/MIT.txt":          texts["MIT"],
+		"LICENSES/BSD-2-Clause.txt": texts["Apache-2.0"],
+		"LICENSES/ISC.txt":          texts["ISC"],
+		"LICENSE":                   texts["MIT"],
+		".reuse/dep5": "Format: https://www.debian.org/doc/packaging-manuals/" +
+			"copyright-format/1.0/\n\nFiles: testdata/*\nCopyright: 2024 Jane Doe\n" +
+			"License: BSD-2-Clause\n",
+		"main.go": "// SPDX-FileCopyrightText: 2024 Jane Doe\n" +
+			"// SPDX-License-Identifier: MIT\n\npackage main\n",
+		"lib.go":              "// SPDX-License-Identifier: GPL-2.0 WITH Classpath-exception-2.0\n",
+		"README.md":           "# Example\n",
+		"logo.png":            "\x89PNG\x00SPDX-License-Identifier: MIT",
+		"logo.png.license":    "SPDX-FileCopyrightText: 2024 Jane Doe\nSPDX-License-Identifier: MIT\n",
+		"testdata/in.json":    "{}",
+		"testdata/bad.txt":    "SPDX-License-Identifier: MIT/X11\n",
+		"empty.txt":           "",
+		"docs/COPYING.LESSER": "text",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := Lint(dir, templates, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := []string{}
+	for _, p := range r.Problems {
+		problems = append(problems, p.String())
+	}
+	wanted := []string{
+		"LICENSES/BSD-2-Clause.txt: license text matches Apache-2.0, not BSD-2-Clause",
+		"LICENSES/Classpath-exception-2.0.txt: missing license text, used by lib.go",
+		"LICENSES/GPL-2.0.txt: missing license text, used by lib.go",
+		"LICENSES/ISC.txt: unused license text",
+		"README.md: missing copyright and license",
+		"lib.go: GPL-2.0 is a deprecated SPDX identifier, use GPL-2.0-only or GPL-2.0-or-later",
+		"lib.go: missing copyright",
+		"testdata/bad.txt: bad license identifier MIT/X11",
+	}
+	if got := strings.Join(problems, "\n"); got != strings.Join(wanted, "\n") {
+		t.Fatalf("unexpected problems:\n%s\n!=\n%s", got, strings.Join(wanted, "\n"))
+	}
+	paths := []string{}
+	for _, f := range r.Files {
+		paths = append(paths, f.Path)
+	}
+	wanted = []string{"README.md", "lib.go", "logo.png", "main.go", "testdata/bad.txt",
+		"testdata/in.json"}
+	if !reflect.DeepEqual(paths, wanted) {
+		t.Fatalf("unexpected files: %v != %v", paths, wanted)
+	}
+}
+
+// REUSE-IgnoreEnd
@@ -0,0 +1,333 @@
+// Package review implements a line-oriented command prompt to review scan
+// results and record license overrides and waivers in the configuration
+// file. It reads commands line by line and draws no full-screen interface.
+package review
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/policy"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+const help = `Commands:
+  list                          list packages matching the filter
+  filter [TERM...]              filter packages, terms being license=NAME,
+                                score<N, score>=N, unknown or violations;
+                                without terms, clear the filter
+  show N                        display the license file of package N
+  diff N                        explain the license match of package N
+  override N LICENSE [REASON]   set the license of package N
+  waive N DATE|+DAYS [REASON]   waive the violations of package N until DATE
+  save                          write overrides and waivers to the configuration
+  quit                          quit, q! discards unsaved changes`
+
+// Session is a command prompt reviewing scan results. Packages are designated
+// by their number in the complete list, whatever the filter.
+type Session struct {
+	Licenses   []report.License
+	Templates  []*matcher.Template
+	Config     *config.Config
+	ConfigPath string
+	Confidence float64
+	// Now returns the current time, used for waiver expiry dates.
+	Now func() time.Time
+
+	filter  []string
+	changed bool
+	out     io.Writer
+}
+
+// violation returns why l is a violation according to the configuration
+// policy, ignoring waivers, or an empty string.
+func (s *Session) violation(l report.License) string {
+	if s.Config.Policy.Empty() {
+		if l.Template == nil || l.Score < s.Confidence {
+			return "unknown license"
+		}
+		return ""
+	}
+	return s.Config.Policy.Evaluate(l, s.Confidence)
+}
+
+// waived returns true if an unexpired waiver of the configuration covers the
+// violation of l.
+func (s *Session) waived(l report.License, reason string) bool {
+	remaining, _ := policy.Waive([]policy.Violation{{License: l, Reason: reason}},
+		s.Config.Waivers, s.Now())
+	return len(remaining) == 0
+}
+
+// matches returns true if l matches all the filter terms.
+func (s *Session) matches(l report.License) bool {
+	for _, term := range s.filter {
+		switch {
+		case term == "unknown":
+			if l.Template != nil && l.Score >= s.Confidence {
+				return false
+			}
+		case term == "violations":
+			if s.violation(l) == "" {
+				return false
+			}
+		case strings.HasPrefix(term, "license="):
+			name := term[len("license="):]
+			if (l.Template == nil || !l.Template.MatchesName(name)) &&
+				l.Declared != name {
+				return false
+			}
+		case strings.HasPrefix(term, "score<"), strings.HasPrefix(term, "score>="):
+			op := "<"
+			if strings.HasPrefix(term, "score>=") {
+				op = ">="
+			}
+			value, _ := strconv.ParseFloat(term[len("score")+len(op):], 64)
+			if (op == "<") != (l.Score < value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseFilter validates filter terms.
+func parseFilter(terms []string) error {
+	for _, term := range terms {
+		switch {
+		case term == "unknown", term == "violations",
+			strings.HasPrefix(term, "license=") && len(term) > len("license="):
+		case strings.HasPrefix(term, "score>="), strings.HasPrefix(term, "score<"):
+			value := strings.TrimLeft(term[len("score"):], "<>=")
+			_, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid score in %q", term)
+			}
+		default:
+			return fmt.Errorf("unknown filter term %q", term)
+		}
+	}
+	return nil
+}
+
+func (s *Session) list() error {
+	tw := tabwriter.NewWriter(s.out, 1, 4, 2, ' ', 0)
+	count := 0
+	for i, l := range s.Licenses {
+		if !s.matches(l) {
+			continue
+		}
+		count++
+		license := "?"
+		if l.Template != nil {
+			license = fmt.Sprintf("%s (%d%%)", l.Template.Title, int(100*l.Score))
+		} else if l.Declared != "" {
+			license = l.Declared + " (declared)"
+		} else if l.Err != "" {
+			license = strings.Replace(l.Err, "\n", " ", -1)
+		}
+		status := ""
+		if reason := s.violation(l); reason != "" {
+			status = reason
+			if s.waived(l, reason) {
+				status += ", waived"
+			}
+		}
+		if l.Overridden {
+			status = strings.TrimPrefix(status+", overridden", ", ")
+		}
+		_, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, l.Package, l.Version,
+			license, status)
+		if err != nil {
+			return err
+		}
+	}
+	err := tw.Flush()
+	if err == nil {
+		_, err = fmt.Fprintf(s.out, "%d of %d packages\n", count, len(s.Licenses))
+	}
+	return err
+}
+
+// pkg returns the package designated by arg.
+func (s *Session) pkg(arg string) (*report.License, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(s.Licenses) {
+		return nil, fmt.Errorf("invalid package number %q", arg)
+	}
+	return &s.Licenses[n-1], nil
+}
+
+func (s *Session) show(l *report.License) error {
+	if l.Path == "" {
+		return fmt.Errorf("%s has no license file", l.Package)
+	}
+	text, err := ioutil.ReadFile(l.Path)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "%s\n\n%s", l.Path, text)
+	return err
+}
+
+func (s *Session) diff(l *report.License) error {
+	if l.Path == "" {
+		return fmt.Errorf("%s has no license file", l.Package)
+	}
+	text, err := ioutil.ReadFile(l.Path)
+	if err != nil {
+		return err
+	}
+	return report.WriteExplanation(s.out, *l, text, s.Templates, s.Confidence, false)
+}
+
+// override records the override of l license and applies it.
+func (s *Session) override(l *report.License, license, reason string) {
+	o := s.Config.Override(l.Package, "")
+	if o == nil {
+		s.Config.Overrides = append(s.Config.Overrides, config.Override{
+			Package: l.Package,
+		})
+		o = &s.Config.Overrides[len(s.Config.Overrides)-1]
+	}
+	o.License = license
+	o.Reason = reason
+	s.Config.ApplyOverride(l, s.Templates)
+	s.changed = true
+}
+
+// waive records a waiver of l violations until expires, a date or a number
+// of days prefixed with "+".
+func (s *Session) waive(l *report.License, expires, reason string) error {
+	if strings.HasPrefix(expires, "+") {
+		days, err := strconv.Atoi(expires[1:])
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid number of days %q", expires)
+		}
+		expires = s.Now().AddDate(0, 0, days).Format("2006-01-02")
+	}
+	w := policy.Waiver{
+		Package: l.Package,
+		Reason:  reason,
+		Expires: expires,
+	}
+	err := policy.ValidateWaivers([]policy.Waiver{w})
+	if err != nil {
+		return err
+	}
+	s.Config.Waivers = append(s.Config.Waivers, w)
+	s.changed = true
+	return nil
+}
+
+// run runs a command line and returns true if the session is over.
+func (s *Session) run(line string) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "list", "l":
+		return false, s.list()
+	case "filter", "f":
+		err := parseFilter(args)
+		if err != nil {
+			return false, err
+		}
+		s.filter = args
+		return false, s.list()
+	case "show", "s", "diff", "d":
+		if len(args) != 1 {
+			return false, fmt.Errorf("expect a package number")
+		}
+		l, err := s.pkg(args[0])
+		if err != nil {
+			return false, err
+		}
+		if cmd == "show" || cmd == "s" {
+			return false, s.show(l)
+		}
+		return false, s.diff(l)
+	case "override", "o", "waive", "w":
+		if len(args) < 2 {
+			return false, fmt.Errorf("expect a package number and a value")
+		}
+		l, err := s.pkg(args[0])
+		if err != nil {
+			return false, err
+		}
+		reason := strings.Join(args[2:], " ")
+		if cmd == "override" || cmd == "o" {
+			s.override(l, args[1], reason)
+			return false, nil
+		}
+		return false, s.waive(l, args[1], reason)
+	case "save":
+		err := config.Save(s.ConfigPath, s.Config)
+		if err == nil {
+			s.changed = false
+			_, err = fmt.Fprintf(s.out, "saved %s\n", s.ConfigPath)
+		}
+		return false, err
+	case "quit", "q":
+		if s.changed {
+			return false, fmt.Errorf("unsaved changes, save them or quit with q!")
+		}
+		return true, nil
+	case "q!":
+		return true, nil
+	case "help", "h", "?":
+		_, err := fmt.Fprintln(s.out, help)
+		return false, err
+	}
+	return false, fmt.Errorf("unknown command %q, type help for the command list", cmd)
+}
+
+// Run reads commands from in and writes their results to out until the
+// session is quit or in is exhausted.
+func (s *Session) Run(in io.Reader, out io.Writer) error {
+	s.out = out
+	if s.Now == nil {
+		s.Now = time.Now
+	}
+	err := s.list()
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(in)
+	for {
+		_, err := fmt.Fprint(out, "> ")
+		if err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			break
+		}
+		done, err := s.run(scanner.Text())
+		if err != nil {
+			_, err = fmt.Fprintf(out, "error: %s\n", err)
+			if err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+	if s.changed {
+		_, err := fmt.Fprintln(out, "\nunsaved changes discarded")
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,76 @@
+package review
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+)
+
+func TestSession(t *testing.T) {
+	mit := &matcher.Template{Title: "MIT License", ID: "MIT"}
+	dir, err := ioutil.TempDir("", "go-licenses-review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	s := &Session{
+		Licenses: []report.License{
+			{Package: "a", Version: "v1", Template: mit, Score: 1},
+			{Package: "b", Version: "v1", Template: mit, Score: 0.5},
+		},
+		Templates:  []*matcher.Template{mit},
+		Config:     &config.Config{},
+		ConfigPath: path,
+		Confidence: 0.9,
+		Now: func() time.Time {
+			return time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+		},
+	}
+	in := strings.Join([]string{
+		"filter unknown",
+		"waive 2 +10 pending review",
+		"filter violations",
+		"override 2 MIT reviewed",
+		"quit",
+		"save",
+		"quit",
+	}, "\n")
+	out := &bytes.Buffer{}
+	err = s.Run(strings.NewReader(in), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, wanted := range []string{
+		"2  b  v1  MIT License (50%)  unknown license\n1 of 2 packages\n",
+		"2  b  v1  MIT License (50%)  unknown license, waived\n1 of 2 packages\n",
+		"error: unsaved changes",
+		"saved " + path,
+	} {
+		if !strings.Contains(out.String(), wanted) {
+			t.Fatalf("output does not contain %q:\n%s", wanted, out.String())
+		}
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Overrides) != 1 || cfg.Overrides[0].License != "MIT" ||
+		cfg.Overrides[0].Reason != "reviewed" {
+		t.Fatalf("unexpected overrides: %+v", cfg.Overrides)
+	}
+	if len(cfg.Waivers) != 1 || cfg.Waivers[0].Expires != "2024-01-11" {
+		t.Fatalf("unexpected waivers: %+v", cfg.Waivers)
+	}
+	if !s.Licenses[1].Overridden || s.Licenses[1].Score != 1 {
+		t.Fatalf("override not applied: %+v", s.Licenses[1])
+	}
+}
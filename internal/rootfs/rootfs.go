@@ -0,0 +1,57 @@
+// Package rootfs resolves paths in filesystem trees of other systems, like
+// unpacked container images.
+package rootfs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks bounds the number of symbolic links followed when resolving a
+// path, to detect loops.
+const maxSymlinks = 40
+
+// Resolve returns the host path of path in the filesystem tree rooted at root.
+// Symbolic links are resolved within root, so absolute links do not escape
+// it. An empty root designates the host filesystem.
+func Resolve(root, path string) (string, error) {
+	if root == "" || root == "/" {
+		return path, nil
+	}
+	resolved := "/"
+	pending := strings.Split(strings.Trim(path, "/"), "/")
+	links := 0
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, name)
+		fi, err := os.Lstat(filepath.Join(root, next))
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			// Missing entries are left to the caller to report.
+			resolved = next
+			continue
+		}
+		links++
+		if links > maxSymlinks {
+			return "", fmt.Errorf("too many symbolic links in %s", path)
+		}
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+		pending = append(strings.Split(strings.Trim(target, "/"), "/"), pending...)
+	}
+	return filepath.Join(root, resolved), nil
+}
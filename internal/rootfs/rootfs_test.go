@@ -0,0 +1,46 @@
+package rootfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	root, err := ioutil.TempDir("", "rootfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	doc := filepath.Join(root, "usr", "share", "doc")
+	err = os.MkdirAll(filepath.Join(doc, "foo-common"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"libfoo1": "foo-common",
+		"libfoo2": "/usr/share/doc/foo-common",
+		"loop":    "/usr/share/doc/loop",
+	}
+	for name, target := range links {
+		err = os.Symlink(target, filepath.Join(doc, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wanted := filepath.Join(doc, "foo-common", "copyright")
+	for _, name := range []string{"libfoo1", "libfoo2"} {
+		path, err := Resolve(root, "/usr/share/doc/"+name+"/copyright")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != wanted {
+			t.Fatalf("%s resolved to %s instead of %s", name, path, wanted)
+		}
+	}
+	_, err = Resolve(root, "/usr/share/doc/loop/copyright")
+	if err == nil {
+		t.Fatalf("symbolic link loop was not detected")
+	}
+}
@@ -0,0 +1,209 @@
+// Package rpm lists the licenses of installed RPM packages.
+package rpm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/internal/rootfs"
+)
+
+// Package is an installed RPM package.
+type Package struct {
+	Name         string
+	Version      string
+	Architecture string
+	// SourceRPM is the file name of the source package.
+	SourceRPM string
+	// License is the License tag, a license expression.
+	License string
+}
+
+// fileFlagLicense marks files tagged with %license in RPM headers.
+const fileFlagLicense = 1 << 7
+
+const packagesFormat = `%{NAME}\t%{VERSION}-%{RELEASE}\t%{ARCH}\t%{SOURCERPM}\t%{LICENSE}\n`
+
+const filesFormat = `[%{NAME}\t%{FILEFLAGS}\t%{FILENAMES}\n]`
+
+// queryRPM runs an "rpm -qa" query over the database of the system rooted at
+// root.
+func queryRPM(root, format string) (*bytes.Buffer, error) {
+	args := []string{"-qa", "--qf", format}
+	if root != "" {
+		args = append(args, "--root", root)
+	}
+	cmd := exec.Command("rpm", args...)
+	var b bytes.Buffer
+	var berr bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &berr
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("'rpm %s' failed with:\n%s",
+			strings.Join(args, " "), berr.String())
+	}
+	return &b, nil
+}
+
+// FindOwners returns the names of the packages installing the files at paths
+// in the system rooted at root, by path. Files installed by no package are
+// left out.
+func FindOwners(root string, paths []string) (map[string]string, error) {
+	owners := map[string]string{}
+	for _, path := range paths {
+		args := []string{"-qf", "--qf", `%{NAME}\n`}
+		if root != "" {
+			args = append(args, "--root", root)
+		}
+		cmd := exec.Command("rpm", append(args, path)...)
+		var b bytes.Buffer
+		cmd.Stdout = &b
+		err := cmd.Run()
+		if _, ok := err.(*exec.ExitError); ok {
+			// rpm fails on files installed by no package.
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("'rpm %s' failed: %s", strings.Join(args, " "), err)
+		}
+		if name := strings.TrimSpace(strings.SplitN(b.String(), "\n", 2)[0]); name != "" {
+			owners[path] = name
+		}
+	}
+	return owners, nil
+}
+
+// parsePackages parses the output of an rpm query with packagesFormat and
+// returns packages sorted by name.
+func parsePackages(r io.Reader) ([]Package, error) {
+	pkgs := []Package{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 5 {
+			continue
+		}
+		// gpg-pubkey entries are keys imported in the database.
+		if fields[0] == "gpg-pubkey" {
+			continue
+		}
+		pkg := Package{
+			Name:         fields[0],
+			Version:      fields[1],
+			Architecture: fields[2],
+			SourceRPM:    fields[3],
+			License:      fields[4],
+		}
+		if pkg.SourceRPM == "(none)" {
+			pkg.SourceRPM = ""
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name
+	})
+	return pkgs, nil
+}
+
+// parseLicenseFiles parses the output of an rpm query with filesFormat and
+// returns the license files of each package.
+func parseLicenseFiles(r io.Reader) (map[string][]string, error) {
+	files := map[string][]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		flags, err := strconv.Atoi(fields[1])
+		if err != nil || flags&fileFlagLicense == 0 {
+			continue
+		}
+		files[fields[0]] = append(files[fields[0]], fields[2])
+	}
+	return files, scanner.Err()
+}
+
+// ListLicenses returns the licenses of RPM packages installed in the system
+// rooted at root, an empty root designating the running system. Declared
+// licenses naming a known template are trusted. Otherwise, if matchFiles is
+// set, the files tagged as licenses are matched against templates.
+func ListLicenses(root string, matchFiles bool) ([]report.License, error) {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	b, err := queryRPM(root, packagesFormat)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := parsePackages(b)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]string{}
+	if matchFiles {
+		b, err := queryRPM(root, filesFormat)
+		if err != nil {
+			return nil, err
+		}
+		files, err = parseLicenseFiles(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	licenses := []report.License{}
+	for _, pkg := range pkgs {
+		license := report.License{
+			Source:   "rpm",
+			Package:  pkg.Name,
+			Version:  pkg.Version,
+			Declared: pkg.License,
+		}
+		if t := matcher.FindTemplate(templates, pkg.License); t != nil {
+			license.Template = t
+			license.Score = 1
+			licenses = append(licenses, license)
+			continue
+		}
+		for _, file := range files[pkg.Name] {
+			path, err := rootfs.Resolve(root, file)
+			if err != nil {
+				return nil, err
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					// Documentation may be excluded from installations.
+					continue
+				}
+				return nil, err
+			}
+			m := matcher.Match(data, templates)
+			if license.Path == "" || m.Score > license.Score {
+				license.Path = path
+				license.Score = m.Score
+				license.Template = m.Template
+				license.ExtraWords = m.ExtraWords
+				license.MissingWords = m.MissingWords
+				license.PatentGrant = m.Clauses.PatentGrant
+				license.TrademarkClause = m.Clauses.Trademark
+			}
+		}
+		licenses = append(licenses, license)
+	}
+	return licenses, nil
+}
@@ -0,0 +1,39 @@
+package rpm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParsePackages(t *testing.T) {
+	out := "zlib\t1.2.11-40.el9\tx86_64\tzlib-1.2.11-40.el9.src.rpm\tzlib and Boost\n" +
+		"gpg-pubkey\tfd431d51-4ae0493b\t(none)\t(none)\tpubkey\n" +
+		"bash\t5.1.8-6.el9\tx86_64\tbash-5.1.8-6.el9.src.rpm\tGPLv3+\n"
+	pkgs, err := parsePackages(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprintf("%+v", pkgs)
+	wanted := "[{Name:bash Version:5.1.8-6.el9 Architecture:x86_64 " +
+		"SourceRPM:bash-5.1.8-6.el9.src.rpm License:GPLv3+} " +
+		"{Name:zlib Version:1.2.11-40.el9 Architecture:x86_64 " +
+		"SourceRPM:zlib-1.2.11-40.el9.src.rpm License:zlib and Boost}]"
+	if got != wanted {
+		t.Fatalf("unexpected packages:\n%s\n!=\n%s", got, wanted)
+	}
+}
+
+func TestParseLicenseFiles(t *testing.T) {
+	out := "bash\t0\t/usr/bin/bash\n" +
+		"bash\t128\t/usr/share/licenses/bash/COPYING\n" +
+		"bash\t2\t/usr/share/doc/bash/README\n"
+	files, err := parseLicenseFiles(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(files)
+	if got != "map[bash:[/usr/share/licenses/bash/COPYING]]" {
+		t.Fatalf("unexpected license files: %s", got)
+	}
+}
@@ -0,0 +1,305 @@
+// Package server implements an HTTP API looking up the licenses of Go
+// modules, so teams can share a single scanner and its caches.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/matcher"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// maxRequestSize bounds the size of scan request bodies.
+const maxRequestSize = 1 << 20
+
+// contentTypes are the content types of the report formats.
+var contentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"html":   "text/html; charset=utf-8",
+	"notice": "text/plain; charset=utf-8",
+	"json":   "application/json",
+	"ndjson": "application/x-ndjson",
+	"sarif":  "application/sarif+json",
+	"junit":  "application/xml",
+	"spdx":   "application/spdx+json",
+	"zip":    "application/zip",
+	"tar":    "application/x-tar",
+}
+
+// Server answers license lookups. Detected licenses are kept in memory, so
+// each module version is scanned once.
+type Server struct {
+	// Options configure module scans.
+	Options *gomod.Options
+	// Confidence is the minimum score of trusted matches.
+	Confidence float64
+	// Config holds the policy evaluated by violation reports and the
+	// overrides applied to detected licenses.
+	Config *config.Config
+	// Timeout bounds the duration of each request, if set.
+	Timeout time.Duration
+
+	templates []*matcher.Template
+	// scan is replaced in tests.
+	scan func(ctx context.Context, mods []*modinfo.ModulePublic,
+		opts *gomod.Options) ([]report.License, error)
+
+	mu       sync.Mutex
+	licenses map[string]report.License
+}
+
+// New returns a server scanning modules with opts.
+func New(opts *gomod.Options, cfg *config.Config, confidence float64) (*Server, error) {
+	templates, err := matcher.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		Options:    opts,
+		Confidence: confidence,
+		Config:     cfg,
+		templates:  templates,
+		scan:       gomod.ScanModules,
+		licenses:   map[string]report.License{},
+	}, nil
+}
+
+// Handler returns the HTTP handler of the API:
+//
+//	POST /scan                             licenses of the modules in body
+//	GET  /module/{path}@{version}/license  license of a module version
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scan", s.handleScan)
+	mux.HandleFunc("/module/", s.handleModule)
+	return mux
+}
+
+func (s *Server) log() *slog.Logger {
+	if s.Options.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return s.Options.Logger
+}
+
+// context returns the context of request r, bounded by the server timeout.
+func (s *Server) context(r *http.Request) (context.Context, context.CancelFunc) {
+	if s.Timeout == 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), s.Timeout)
+}
+
+// Lookup returns the licenses of mods, scanning those not looked up yet.
+// Licenses reported with an error are not kept, so transient failures like
+// network errors are retried by later lookups.
+func (s *Server) Lookup(ctx context.Context, mods []*modinfo.ModulePublic) (
+	[]report.License, error) {
+
+	licenses := []report.License{}
+	missing := []*modinfo.ModulePublic{}
+	seen := map[string]bool{}
+	s.mu.Lock()
+	for _, mod := range mods {
+		key := mod.Path + "@" + mod.Version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if l, ok := s.licenses[key]; ok && mod.Replace == nil {
+			licenses = append(licenses, l)
+		} else {
+			missing = append(missing, mod)
+		}
+	}
+	s.mu.Unlock()
+	if len(missing) > 0 {
+		scanned, err := s.scan(ctx, missing, s.Options)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		for i := range scanned {
+			l := &scanned[i]
+			if s.Config != nil {
+				s.Config.ApplyOverride(l, s.templates)
+			}
+			if l.Err == "" && !isReplaced(missing, l.Package) {
+				s.licenses[l.Package+"@"+l.Version] = *l
+			}
+		}
+		s.mu.Unlock()
+		licenses = append(licenses, scanned...)
+	}
+	sort.SliceStable(licenses, func(i, j int) bool {
+		return licenses[i].Package < licenses[j].Package
+	})
+	return licenses, nil
+}
+
+// isReplaced returns true if module path is replaced in mods. The licenses
+// of replacements are not kept under the replaced module version.
+func isReplaced(mods []*modinfo.ModulePublic, path string) bool {
+	for _, mod := range mods {
+		if mod.Path == path && mod.Replace != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseModules returns the modules listed in a scan request body: a go.mod
+// file, a JSON array of "path@version" strings, or a list of module versions
+// one per line.
+func parseModules(ctx context.Context, data []byte) ([]*modinfo.ModulePublic, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		list := []string{}
+		err := json.Unmarshal(trimmed, &list)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse module list: %s", err)
+		}
+		return gomod.ParseModuleList([]byte(strings.Join(list, "\n")))
+	case gomod.IsGoMod(data):
+		return gomod.ReadGoMod(ctx, data)
+	}
+	return gomod.ParseModuleList(data)
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := s.context(r)
+	defer cancel()
+	start := time.Now()
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	mods, err := parseModules(ctx, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if !validFormat(format) {
+		http.Error(w, fmt.Sprintf("unknown format %q, supported formats: %v",
+			format, report.Formats), http.StatusBadRequest)
+		return
+	}
+	licenses, err := s.Lookup(ctx, mods)
+	if err != nil {
+		s.log().Info("scan failed", "modules", len(mods), "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	opts := report.Options{
+		Confidence: s.Confidence,
+	}
+	if s.Config != nil {
+		p, confidence := &s.Config.Policy, s.Confidence
+		opts.Check = func(l report.License) string {
+			return p.Evaluate(l, confidence)
+		}
+	}
+	b := &bytes.Buffer{}
+	err = report.Write(b, format, licenses, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.log().Info("scanned modules", "modules", len(mods), "elapsed", time.Since(start))
+	if t, ok := contentTypes[format]; ok {
+		w.Header().Set("Content-Type", t)
+	}
+	w.Write(b.Bytes())
+}
+
+func validFormat(format string) bool {
+	for _, f := range report.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleLicense is the answer of module license requests.
+type moduleLicense struct {
+	report.Record
+	Text string `json:"text,omitempty"`
+}
+
+func (s *Server) handleModule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Module paths hold slashes, so the path is parsed by hand.
+	if !strings.HasSuffix(r.URL.Path, "/license") {
+		http.NotFound(w, r)
+		return
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/module/"), "/license")
+	i := strings.LastIndex(name, "@")
+	if i <= 0 || i == len(name)-1 {
+		http.NotFound(w, r)
+		return
+	}
+	if err := gomod.CheckModule(name[:i], name[i+1:]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := s.context(r)
+	defer cancel()
+	licenses, err := s.Lookup(ctx, []*modinfo.ModulePublic{{
+		Path:    name[:i],
+		Version: name[i+1:],
+	}})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	l := licenses[0]
+	answer := moduleLicense{Record: report.NewRecord(l)}
+	if l.Path != "" {
+		text, err := ioutil.ReadFile(l.Path)
+		if err == nil {
+			answer.Hash, err = report.HashFile(l.Path)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		answer.Text = string(text)
+	}
+	data, err := json.MarshalIndent(answer, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/groove-x/go-licenses/internal/config"
+	"github.com/groove-x/go-licenses/internal/gomod"
+	"github.com/groove-x/go-licenses/internal/report"
+	"github.com/groove-x/go-licenses/modinfo"
+)
+
+// newTestServer returns a server whose scans report the license file at
+// path for every module, and the scanned modules.
+func newTestServer(t *testing.T, path string) (*Server, *[]string) {
+	s, err := New(&gomod.Options{}, &config.Config{
+		Overrides: []config.Override{{Package: "example.com/c", License: "ISC"}},
+	}, report.DefaultConfidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanned := []string{}
+	s.scan = func(ctx context.Context, mods []*modinfo.ModulePublic,
+		opts *gomod.Options) ([]report.License, error) {
+
+		licenses := []report.License{}
+		for _, mod := range mods {
+			scanned = append(scanned, mod.Path+"@"+mod.Version)
+			licenses = append(licenses, report.License{
+				Source:  "go",
+				Package: mod.Path,
+				Version: mod.Version,
+				Path:    path,
+			})
+		}
+		return licenses, nil
+	}
+	return s, &scanned
+}
+
+func TestScan(t *testing.T) {
+	s, scanned := newTestServer(t, "")
+	h := s.Handler()
+	post := func(body, format string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", "/scan?format="+format,
+			strings.NewReader(body)))
+		return rec
+	}
+	rec := post("example.com/b@v1.0.0\nexample.com/a v1.2.0\n", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scan failed: %d %s", rec.Code, rec.Body)
+	}
+	records := []report.Record{}
+	err := json.Unmarshal(rec.Body.Bytes(), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Package != "example.com/a" ||
+		records[1].Version != "v1.0.0" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	rec = post(`["example.com/a@v1.2.0", "example.com/c@v0.1.0"]`, "csv")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("scan failed: %d %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "example.com/c,v0.1.0,ISC License,ISC") {
+		t.Fatalf("override not applied:\n%s", rec.Body)
+	}
+	got := strings.Join(*scanned, " ")
+	if got != "example.com/b@v1.0.0 example.com/a@v1.2.0 example.com/c@v0.1.0" {
+		t.Fatalf("cached modules scanned again: %s", got)
+	}
+	for body, format := range map[string]string{
+		"example.com/a\n":                               "json",
+		"example.com/a v1.2.0\n":                        "xml",
+		"x v1.0.0/../../../root/module\n":               "tar",
+		`["example.com/a@v1.0.0/../../../etc"]`:         "json",
+		"module m\n\nrequire example.com/a v1.0.0/..\n": "json",
+	} {
+		rec = post(body, format)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("invalid request %q %s accepted: %d", body, format, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/scan", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+}
+
+func TestModuleLicense(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "LICENSE")
+	err = ioutil.WriteFile(path, []byte("Public domain.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := newTestServer(t, path)
+	h := s.Handler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/module/example.com/a/v2@v2.0.0/license", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("lookup failed: %d %s", rec.Code, rec.Body)
+	}
+	answer := moduleLicense{}
+	err = json.Unmarshal(rec.Body.Bytes(), &answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.Package != "example.com/a/v2" || answer.Version != "v2.0.0" ||
+		answer.Text != "Public domain.\n" || answer.Hash == "" {
+		t.Fatalf("unexpected answer: %+v", answer)
+	}
+	for _, url := range []string{
+		"/module/example.com/a/license",
+		"/module/example.com/a@/license",
+		"/module/example.com/a@v1.0.0",
+	} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("unexpected status of %s: %d", url, rec.Code)
+		}
+	}
+	for _, url := range []string{
+		"/module/x@v1.0.0/license",
+		"/module/example.com/a@v1.0.0%2F..%2F..%2Froot/license",
+		"/module/example.com/a@master/license",
+	} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("unexpected status of %s: %d", url, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package sign signs reports with private keys, as detached signatures or
+// in-toto attestations, so their consumers can verify their provenance.
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+// ReadKey reads the PEM encoded private key at path: a PKCS#8 ECDSA, Ed25519
+// or RSA key, a SEC 1 ECDSA key or a PKCS#1 RSA key. Encrypted keys are not
+// supported.
+func ReadKey(path string) (crypto.Signer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s holds no PEM data", path)
+	}
+	var key interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported %s key type: %s", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported %s key type: %T", path, key)
+	}
+	return signer, nil
+}
+
+// Sign returns the signature of data: an ASN.1 ECDSA or PKCS#1 v1.5 RSA
+// signature of its SHA-256 digest, or an Ed25519 signature of data. They
+// can be verified with "openssl dgst -sha256 -verify" or "cosign verify-blob".
+func Sign(key crypto.Signer, data []byte) ([]byte, error) {
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		return key.Sign(rand.Reader, data, crypto.Hash(0))
+	case *ecdsa.PrivateKey, *rsa.PrivateKey:
+		digest := sha256.Sum256(data)
+		return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	return nil, fmt.Errorf("unsupported key type: %T", key)
+}
+
+// Verify returns an error unless sig is a signature of data by the private
+// key of pub, as returned by Sign.
+func Verify(pub crypto.PublicKey, data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	ok := false
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, data, sig)
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(pub, digest[:], sig)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return fmt.Errorf("unsupported key type: %T", pub)
+	}
+	if !ok {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+const (
+	// StatementType is the type of in-toto statements.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PayloadType is the DSSE payload type of in-toto statements.
+	PayloadType = "application/vnd.in-toto+json"
+	// SPDXPredicate is the predicate type of SPDX documents.
+	SPDXPredicate = "https://spdx.dev/Document"
+	// ReportPredicate is the predicate type of other reports.
+	ReportPredicate = "https://github.com/groove-x/go-licenses/report/v1"
+)
+
+// Subject is an artifact an in-toto statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Statement is an in-toto attestation statement.
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// Signature is a DSSE envelope signature.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Envelope is a DSSE envelope, as used to sign in-toto statements.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// pae returns the DSSE pre-authentication encoding of payload, which is what
+// envelopes sign.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte("DSSEv1 " + strconv.Itoa(len(payloadType)) + " " + payloadType +
+		" " + strconv.Itoa(len(payload)) + " " + string(payload))
+}
+
+// Attest returns a DSSE envelope signed with key, holding an in-toto
+// statement about the file name whose content is data. The predicate is data
+// itself if it is JSON, like SBOMs and JSON reports, or empty otherwise.
+func Attest(key crypto.Signer, name string, data []byte, predicateType string) (
+	*Envelope, error) {
+
+	digest := sha256.Sum256(data)
+	predicate := json.RawMessage("{}")
+	if json.Valid(data) {
+		predicate = json.RawMessage(data)
+	}
+	payload, err := json.Marshal(Statement{
+		Type: StatementType,
+		Subject: []Subject{{
+			Name:   name,
+			Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])},
+		}},
+		PredicateType: predicateType,
+		Predicate:     predicate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := Sign(key, pae(PayloadType, payload))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// VerifyEnvelope returns the statement of envelope e if one of its
+// signatures was made by the private key of pub.
+func VerifyEnvelope(pub crypto.PublicKey, e *Envelope) (*Statement, error) {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode payload: %s", err)
+	}
+	err = fmt.Errorf("envelope is not signed")
+	for _, s := range e.Signatures {
+		sig, decodeErr := base64.StdEncoding.DecodeString(s.Sig)
+		if decodeErr != nil {
+			err = fmt.Errorf("could not decode signature: %s", decodeErr)
+			continue
+		}
+		err = Verify(pub, pae(e.PayloadType, payload), sig)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	statement := &Statement{}
+	err = json.Unmarshal(payload, statement)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse statement: %s", err)
+	}
+	return statement, nil
+}
@@ -0,0 +1,76 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-licenses-sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"spdxVersion":"SPDX-2.3"}`)
+	for _, key := range []crypto.Signer{ecKey, edKey} {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "key.pem")
+		err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{
+			Type: "PRIVATE KEY", Bytes: der}), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := ReadKey(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := Sign(signer, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Verify(key.Public(), data, sig)
+		if err != nil {
+			t.Fatalf("%T signature not verified: %s", key, err)
+		}
+		if Verify(key.Public(), append(data, ' '), sig) == nil {
+			t.Fatalf("%T signature of modified data verified", key)
+		}
+
+		e, err := Attest(signer, "sbom.json", data, SPDXPredicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := VerifyEnvelope(key.Public(), e)
+		if err != nil {
+			t.Fatalf("%T envelope not verified: %s", key, err)
+		}
+		if s.Subject[0].Name != "sbom.json" || string(s.Predicate) != string(data) ||
+			len(s.Subject[0].Digest["sha256"]) != 64 {
+			t.Fatalf("unexpected statement: %+v", s)
+		}
+		e.PayloadType = "text/plain"
+		if _, err := VerifyEnvelope(key.Public(), e); err == nil {
+			t.Fatalf("%T envelope with modified payload type verified", key)
+		}
+	}
+}